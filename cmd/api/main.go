@@ -1,3 +1,11 @@
+// Command api serves the REST API and, as `api migrate`, manages the schema.
+//
+// Build it with the SQLite FTS5 extension so search on SQLite uses a
+// full-text index instead of LIKE:
+//
+//	go build -tags sqlite_fts5 ./cmd/api
+//
+// The tag only matters for SQLite; Postgres and MySQL need nothing extra.
 package main
 
 import (
@@ -6,6 +14,8 @@ import (
 
 	"Wrk_Api/internal/database"
 	"Wrk_Api/internal/routes"
+	"Wrk_Api/internal/search"
+	"Wrk_Api/internal/storage"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -17,9 +27,23 @@ func main() {
 		log.Println("No .env file found, relying on system env vars")
 	}
 
+	// `api migrate ...` manages the schema instead of serving
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+
 	// Initialize Database
 	database.Connect()
 
+	// Initialize full-text search
+	if err := search.Setup(database.DB); err != nil {
+		log.Fatal("Failed to set up search:", err)
+	}
+
+	// Initialize file storage
+	storage.Setup()
+
 	// Initialize Router
 	r := gin.Default()
 
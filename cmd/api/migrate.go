@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"Wrk_Api/internal/database"
+)
+
+const migrateUsage = `usage: api migrate <command>
+
+  up        apply every pending migration
+  down [n] [--force]
+            revert the last n migrations (default 1); --force also reverts
+            0001 on a database that existed before migrations, dropping its tables
+  status    list migrations and when they were applied`
+
+// runMigrate handles `api migrate ...`, using the same DB_* settings as the server.
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+
+	db, err := database.Open()
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := database.Migrate(db)
+		for _, m := range applied {
+			fmt.Println("applied", m)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("database is up to date")
+		}
+	case "down":
+		steps, force := 1, false
+		for _, arg := range args[1:] {
+			if arg == "--force" {
+				force = true
+				continue
+			}
+			steps, err = strconv.Atoi(arg)
+			if err != nil || steps < 1 {
+				log.Fatal("down takes a positive number of migrations")
+			}
+		}
+		reverted, err := database.Rollback(db, steps, force)
+		for _, m := range reverted {
+			fmt.Println("reverted", m)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(reverted) == 0 {
+			fmt.Println("no migrations to revert")
+		}
+	case "status":
+		states, err := database.Status(db)
+		if err != nil {
+			log.Fatal(err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+		for _, state := range states {
+			applied := "pending"
+			if state.AppliedAt != nil {
+				applied = state.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", state.Version, state.Name, applied)
+		}
+		w.Flush()
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+}
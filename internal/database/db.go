@@ -1,53 +1,116 @@
 package database
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 
-	"Wrk_Api/internal/models"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
 var DB *gorm.DB
 
-func Connect() {
-	var err error
-	dbPath := os.Getenv("DB_PATH")
-	if dbPath == "" {
-		dbPath = "test.db"
+// Supported values of DB_DRIVER
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+	DriverMySQL    = "mysql"
+)
+
+// Dialector returns the gorm dialector for driver. For SQLite dsn is the database file.
+func Dialector(driver, dsn string) (gorm.Dialector, error) {
+	switch driver {
+	case DriverSQLite:
+		return sqlite.Open(dsn), nil
+	case DriverPostgres:
+		return postgres.Open(dsn), nil
+	case DriverMySQL:
+		cfg, err := mysqldriver.ParseDSN(dsn)
+		if err != nil {
+			return nil, err
+		}
+		// Timestamps are scanned into time.Time
+		cfg.ParseTime = true
+		return mysql.Open(cfg.FormatDSN()), nil
+	}
+	return nil, fmt.Errorf("unsupported DB_DRIVER %q (use sqlite, postgres or mysql)", driver)
+}
+
+// GormConfig is the configuration every connection uses.
+func GormConfig() *gorm.Config {
+	return &gorm.Config{
+		// Unique violations come back as gorm.ErrDuplicatedKey on every driver
+		TranslateError: true,
+	}
+}
+
+// Open connects to the database chosen by DB_DRIVER, SQLite by default. SQLite
+// reads the file from DB_PATH; Postgres and MySQL take DB_DSN, e.g.
+// "host=db user=wrk password=... dbname=wrk" or "wrk:...@tcp(db:3306)/wrk".
+func Open() (*gorm.DB, error) {
+	driver := strings.ToLower(os.Getenv("DB_DRIVER"))
+	if driver == "" {
+		driver = DriverSQLite
+	}
+
+	dsn := os.Getenv("DB_DSN")
+	if driver == DriverSQLite {
+		dsn = os.Getenv("DB_PATH")
+		if dsn == "" {
+			dsn = "test.db"
+		}
+	} else if dsn == "" {
+		return nil, fmt.Errorf("DB_DSN is required for the %s driver", driver)
 	}
 
-	DB, err = gorm.Open(sqlite.Open(dbPath), &gorm.Config{})
+	dialector, err := Dialector(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	return gorm.Open(dialector, GormConfig())
+}
+
+// autoMigrate reports whether Connect applies pending migrations itself.
+// DB_AUTO_MIGRATE decides; by default only SQLite does, since a shared
+// database is migrated once per deploy with `api migrate up`.
+func autoMigrate(db *gorm.DB) bool {
+	if value := os.Getenv("DB_AUTO_MIGRATE"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		return err == nil && enabled
+	}
+	return db.Dialector.Name() == DriverSQLite
+}
+
+func Connect() {
+	var err error
+	DB, err = Open()
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
-	log.Println("Connected to database")
-
-	// Auto Migrate
-	err = DB.AutoMigrate(
-		&models.User{},
-		&models.Project{},
-		&models.ProjectMember{},
-		&models.Sprint{},
-		&models.UserStory{},
-		&models.Task{},
-		&models.Rubric{},
-		&models.Criteria{},
-		&models.Evaluation{},
-		&models.EvaluationCriteria{},
-		&models.Chat{},
-		&models.ChatParticipant{},
-		&models.Message{},
-		&models.Notification{},
-		&models.RetrospectiveItem{},
-		&models.Document{},
-	)
+	log.Printf("Connected to %s database", DB.Dialector.Name())
+
+	if !autoMigrate(DB) {
+		pending, err := Pending(DB)
+		if err != nil {
+			log.Fatal("Failed to read migrations:", err)
+		}
+		if len(pending) > 0 {
+			log.Fatalf("Database schema is out of date (%d pending migrations), run `api migrate up`", len(pending))
+		}
+		return
+	}
 
+	applied, err := Migrate(DB)
 	if err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
 
-	log.Println("Database migration completed")
+	log.Printf("Database migration completed (%d applied)", len(applied))
 }
@@ -0,0 +1,135 @@
+package database
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// foreignKey is a constraint 0004 adds: Column of Table references the id of
+// References. OnDelete is the referential action, empty for NO ACTION.
+type foreignKey struct {
+	Table      string
+	Column     string
+	References string
+	OnDelete   string
+}
+
+func (k foreignKey) name() string {
+	return "fk_" + k.Table + "_" + k.Column
+}
+
+// foreignKeys0004 are the references Postgres and MySQL enforce. The delete
+// handlers clear dependent rows themselves, so deletes behave the same on
+// SQLite; the actions only catch what they miss. Some columns are left out on
+// purpose: entity_id, target_id, blocker_id and blocked_id point at different
+// tables depending on a type column, scope changes outlive the stories they
+// record, and scores and peer review rounds keep their rubric criteria after
+// the rubric is deleted.
+var foreignKeys0004 = []foreignKey{
+	{"sessions", "user_id", "users", "CASCADE"},
+	{"projects", "owner_id", "users", ""},
+	{"project_members", "project_id", "projects", "CASCADE"},
+	{"project_members", "user_id", "users", "CASCADE"},
+	{"sprints", "project_id", "projects", "CASCADE"},
+	{"sprint_reports", "sprint_id", "sprints", "CASCADE"},
+	{"sprint_reports", "next_sprint_id", "sprints", "SET NULL"},
+	{"sprint_scope_changes", "sprint_id", "sprints", "CASCADE"},
+	{"sprint_scope_changes", "user_id", "users", "SET NULL"},
+	{"epics", "project_id", "projects", "CASCADE"},
+	{"user_stories", "project_id", "projects", "CASCADE"},
+	{"user_stories", "assignee_id", "users", ""},
+	{"user_stories", "sprint_id", "sprints", "SET NULL"},
+	{"user_stories", "epic_id", "epics", "SET NULL"},
+	{"retro_sessions", "project_id", "projects", "CASCADE"},
+	{"retro_sessions", "sprint_id", "sprints", "CASCADE"},
+	{"retro_sessions", "facilitator_id", "users", "SET NULL"},
+	{"retro_groups", "session_id", "retro_sessions", "CASCADE"},
+	{"retro_votes", "session_id", "retro_sessions", "CASCADE"},
+	{"retrospective_items", "sprint_id", "sprints", "CASCADE"},
+	{"retrospective_items", "session_id", "retro_sessions", "SET NULL"},
+	{"retrospective_items", "group_id", "retro_groups", "SET NULL"},
+	{"retrospective_items", "user_id", "users", "CASCADE"},
+	{"tasks", "project_id", "projects", "CASCADE"},
+	{"tasks", "user_story_id", "user_stories", "SET NULL"},
+	{"tasks", "sprint_id", "sprints", "SET NULL"},
+	{"tasks", "assignee_id", "users", ""},
+	{"tasks", "retro_item_id", "retrospective_items", "SET NULL"},
+	{"dependencies", "project_id", "projects", "CASCADE"},
+	{"dependencies", "created_by_id", "users", "SET NULL"},
+	{"worklogs", "task_id", "tasks", "CASCADE"},
+	{"worklogs", "project_id", "projects", "CASCADE"},
+	{"worklogs", "sprint_id", "sprints", "SET NULL"},
+	{"worklogs", "user_id", "users", "CASCADE"},
+	{"comments", "project_id", "projects", "CASCADE"},
+	{"comments", "author_id", "users", "CASCADE"},
+	{"comments", "parent_id", "comments", "CASCADE"},
+	{"board_columns", "project_id", "projects", "CASCADE"},
+	{"rubrics", "project_id", "projects", "CASCADE"},
+	{"criteria", "rubric_id", "rubrics", "CASCADE"},
+	{"evaluations", "project_id", "projects", "CASCADE"},
+	{"evaluations", "task_id", "tasks", "CASCADE"},
+	{"evaluations", "sprint_id", "sprints", "CASCADE"},
+	{"evaluations", "evaluator_id", "users", ""},
+	{"evaluations", "rubric_id", "rubrics", "SET NULL"},
+	{"evaluation_criteria", "evaluation_id", "evaluations", "CASCADE"},
+	{"chats", "project_id", "projects", "CASCADE"},
+	{"chat_participants", "chat_id", "chats", "CASCADE"},
+	{"chat_participants", "user_id", "users", "CASCADE"},
+	{"messages", "chat_id", "chats", "CASCADE"},
+	{"messages", "user_id", "users", "CASCADE"},
+	{"notifications", "user_id", "users", "CASCADE"},
+	{"documents", "project_id", "projects", "CASCADE"},
+	{"documents", "parent_id", "documents", "SET NULL"},
+	{"history_entries", "user_id", "users", "SET NULL"},
+	{"peer_review_rounds", "project_id", "projects", "CASCADE"},
+	{"peer_review_rounds", "sprint_id", "sprints", "CASCADE"},
+	{"peer_reviews", "round_id", "peer_review_rounds", "CASCADE"},
+	{"peer_reviews", "reviewer_id", "users", "CASCADE"},
+	{"peer_reviews", "reviewee_id", "users", "CASCADE"},
+	{"peer_review_scores", "review_id", "peer_reviews", "CASCADE"},
+}
+
+// addForeignKeys creates the constraints on Postgres and MySQL. SQLite can't
+// add constraints to existing tables and doesn't enforce them by default, so
+// it is left as it is. Rows that already reference missing parents make this
+// fail until they are cleaned up.
+func addForeignKeys(tx *gorm.DB) error {
+	if tx.Dialector.Name() == DriverSQLite {
+		return nil
+	}
+	for _, key := range foreignKeys0004 {
+		if tx.Migrator().HasConstraint(key.Table, key.name()) {
+			continue
+		}
+		statement := fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (id)",
+			key.Table, key.name(), key.Column, key.References)
+		if key.OnDelete != "" {
+			statement += " ON DELETE " + key.OnDelete
+		}
+		if err := tx.Exec(statement).Error; err != nil {
+			return fmt.Errorf("%s: %w", key.name(), err)
+		}
+	}
+	return nil
+}
+
+func dropForeignKeys(tx *gorm.DB) error {
+	if tx.Dialector.Name() == DriverSQLite {
+		return nil
+	}
+	drop := "ALTER TABLE %s DROP CONSTRAINT %s"
+	if tx.Dialector.Name() == DriverMySQL {
+		drop = "ALTER TABLE %s DROP FOREIGN KEY %s"
+	}
+	for i := len(foreignKeys0004) - 1; i >= 0; i-- {
+		key := foreignKeys0004[i]
+		if !tx.Migrator().HasConstraint(key.Table, key.name()) {
+			continue
+		}
+		if err := tx.Exec(fmt.Sprintf(drop, key.Table, key.name())).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration is one versioned schema change. Down undoes exactly what Up did.
+// Each runs in a transaction, though MySQL commits DDL statements on its own.
+type Migration struct {
+	Version string // applied in ascending order
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+	// Adopts reports, before Up runs, that the database already has what Up
+	// builds. Rolling back an adopted migration needs force, since Down would
+	// drop data that Up never created. Optional.
+	Adopts func(tx *gorm.DB) bool
+}
+
+func (m Migration) String() string {
+	return m.Version + "_" + m.Name
+}
+
+// SchemaMigration records a migration applied to the database.
+type SchemaMigration struct {
+	Version   string `gorm:"primaryKey;size:64"`
+	Name      string
+	Adopted   bool // the schema existed before the migration ran
+	AppliedAt time.Time
+}
+
+// MigrationState is a migration and when it was applied, nil while pending.
+type MigrationState struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// appliedMigrations reads the migrations table, creating it on first use.
+func appliedMigrations(db *gorm.DB) (map[string]SchemaMigration, error) {
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, err
+	}
+	var rows []SchemaMigration
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[string]SchemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+func findMigration(version string) (Migration, bool) {
+	for _, m := range Migrations {
+		if m.Version == version {
+			return m, true
+		}
+	}
+	return Migration{}, false
+}
+
+// Status lists every known migration with the time it was applied.
+func Status(db *gorm.DB) ([]MigrationState, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	states := make([]MigrationState, 0, len(Migrations))
+	for _, m := range Migrations {
+		state := MigrationState{Migration: m}
+		if row, ok := applied[m.Version]; ok {
+			state.AppliedAt = &row.AppliedAt
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// Pending returns the migrations not applied yet, in order.
+func Pending(db *gorm.DB) ([]Migration, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	pending := []Migration{}
+	for _, m := range Migrations {
+		if _, ok := applied[m.Version]; !ok {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// Migrate applies the pending migrations and returns them. It stops at the
+// first failure, keeping the ones applied before it.
+func Migrate(db *gorm.DB) ([]Migration, error) {
+	pending, err := Pending(db)
+	if err != nil {
+		return nil, err
+	}
+	done := []Migration{}
+	for _, m := range pending {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			adopted := m.Adopts != nil && m.Adopts(tx)
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{Version: m.Version, Name: m.Name, Adopted: adopted, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %s: %w", m, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// Rollback reverts the last steps applied migrations, newest first, and returns
+// them. It stops at a migration that adopted an existing schema unless force is set.
+func Rollback(db *gorm.DB, steps int, force bool) ([]Migration, error) {
+	if _, err := appliedMigrations(db); err != nil {
+		return nil, err
+	}
+	var rows []SchemaMigration
+	if err := db.Order("version desc").Limit(steps).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	done := []Migration{}
+	for _, row := range rows {
+		m, ok := findMigration(row.Version)
+		if !ok {
+			return done, fmt.Errorf("migration %s_%s is applied but unknown to this build", row.Version, row.Name)
+		}
+		if row.Adopted && !force {
+			return done, fmt.Errorf("migration %s adopted an existing schema and reverting it would drop that data; use --force to revert it anyway", m)
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{}, "version = ?", m.Version).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("rollback %s: %w", m, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"slices"
+
+	"Wrk_Api/internal/search"
+
+	"gorm.io/gorm"
+)
+
+// Migrations lists every schema change in the order it applies. Append new
+// ones with the next version and never edit one that has shipped.
+//
+// Each migration works on structs of its own (see schema_0001.go) rather than
+// the models, so what it builds never changes with them. After 0001 every
+// change is an explicit step through the Migrator (AddColumn, CreateIndex,
+// CreateTable, ...) with a Down that undoes exactly that step, guarded with
+// HasColumn/HasIndex/HasTable where a database may already have it.
+var Migrations = []Migration{
+	{Version: "0001", Name: "initial_schema", Up: createInitialSchema, Down: dropInitialSchema, Adopts: hasInitialSchema},
+	{Version: "0002", Name: "document_version_index", Up: addDocumentVersionIndex, Down: dropDocumentVersionIndex},
+	{Version: "0003", Name: "search_index", Up: search.Install, Down: search.Uninstall},
+	{Version: "0004", Name: "foreign_keys", Up: addForeignKeys, Down: dropForeignKeys},
+}
+
+// Databases created by AutoMigrate before versioned migrations already have
+// these tables; AutoMigrate only adds what they lack.
+func createInitialSchema(tx *gorm.DB) error {
+	return tx.AutoMigrate(initialTables()...)
+}
+
+// hasInitialSchema reports whether the tables of 0001 were created before it,
+// by AutoMigrate.
+func hasInitialSchema(tx *gorm.DB) bool {
+	return tx.Migrator().HasTable(&user0001{})
+}
+
+func dropInitialSchema(tx *gorm.DB) error {
+	tables := initialTables()
+	slices.Reverse(tables)
+	return tx.Migrator().DropTable(tables...)
+}
+
+// documentVersions is the documents table as 0002 sees it: one number per
+// version of a document.
+type documentVersions struct {
+	Version  int     `gorm:"uniqueIndex:idx_document_version,priority:2"`
+	ParentID *string `gorm:"size:64;uniqueIndex:idx_document_version,priority:1"`
+}
+
+func (documentVersions) TableName() string { return "documents" }
+
+func addDocumentVersionIndex(tx *gorm.DB) error {
+	if tx.Migrator().HasIndex(&documentVersions{}, "idx_document_version") {
+		return nil
+	}
+	return tx.Migrator().CreateIndex(&documentVersions{}, "idx_document_version")
+}
+
+func dropDocumentVersionIndex(tx *gorm.DB) error {
+	return tx.Migrator().DropIndex(&documentVersions{}, "idx_document_version")
+}
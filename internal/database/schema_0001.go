@@ -0,0 +1,453 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// The tables as migration 0001 created them. These are copies, not the live
+// models, so later model changes need a migration of their own instead of
+// quietly changing what 0001 builds. Relations are left out; the foreign
+// keys come from 0004 (see foreign_keys_0004.go).
+
+type user0001 struct {
+	ID        string  `gorm:"primaryKey;size:64"`
+	Email     string  `gorm:"size:255;uniqueIndex;not null"`
+	Name      string  `gorm:"not null"`
+	Password  string  `gorm:"not null"`
+	Role      string  `gorm:"default:'TEAM_DEVELOPER'"`
+	Avatar    *string `gorm:"type:text"`
+	Active    bool    `gorm:"default:true"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+type session0001 struct {
+	ID               string `gorm:"primaryKey;size:64"`
+	UserID           string `gorm:"size:64;index"`
+	RefreshTokenHash string `gorm:"size:64;uniqueIndex;not null"`
+	UserAgent        string
+	IP               string
+	ExpiresAt        time.Time
+	RevokedAt        *time.Time
+	LastUsedAt       time.Time
+	CreatedAt        time.Time
+}
+
+type project0001 struct {
+	ID            string `gorm:"primaryKey;size:64"`
+	Name          string `gorm:"not null"`
+	Description   *string
+	Status        string `gorm:"default:'ACTIVE'"`
+	StartDate     *time.Time
+	EndDate       *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	StorageQuota  *int64
+	MaxFileSize   *int64
+	AllowedTypes  *string
+	RetroTemplate string `gorm:"default:'CLASSIC'"`
+	OwnerID       string `gorm:"size:64"`
+}
+
+type projectMember0001 struct {
+	ID        string    `gorm:"primaryKey;size:64"`
+	ProjectID string    `gorm:"size:64;index:idx_project_user,unique"`
+	UserID    string    `gorm:"size:64;index:idx_project_user,unique"`
+	Role      string    `gorm:"not null"`
+	JoinedAt  time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+type sprint0001 struct {
+	ID          string `gorm:"primaryKey;size:64"`
+	ProjectID   string `gorm:"size:64;index"`
+	Name        string `gorm:"not null"`
+	Description *string
+	StartDate   time.Time
+	EndDate     time.Time
+	Status      string `gorm:"default:'PLANNING'"`
+	StartedAt   *time.Time
+	CompletedAt *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type sprintReport0001 struct {
+	ID                 string `gorm:"primaryKey;size:64"`
+	SprintID           string `gorm:"size:64;uniqueIndex"`
+	CommittedStories   int
+	CommittedPoints    int
+	CommittedTasks     int
+	CompletedStories   int
+	CompletedPoints    int
+	CompletedTasks     int
+	CarriedOverStories int
+	CarriedOverPoints  int
+	CarriedOverTasks   int
+	CarryOver          *string
+	NextSprintID       *string `gorm:"size:64"`
+	StartedAt          time.Time
+	CompletedAt        *time.Time
+}
+
+type sprintScopeChange0001 struct {
+	ID          string `gorm:"primaryKey;size:64"`
+	SprintID    string `gorm:"size:64;index"`
+	UserStoryID string `gorm:"size:64;index"`
+	Type        string
+	Delta       int
+	UserID      *string `gorm:"size:64"`
+	CreatedAt   time.Time
+}
+
+type epic0001 struct {
+	ID          string `gorm:"primaryKey;size:64"`
+	ProjectID   string `gorm:"size:64;index"`
+	Title       string `gorm:"not null"`
+	Description *string
+	Color       *string
+	Status      string `gorm:"default:'OPEN'"`
+	TargetDate  *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type userStory0001 struct {
+	ID          string `gorm:"primaryKey;size:64"`
+	ProjectID   string `gorm:"size:64;index"`
+	Title       string `gorm:"not null"`
+	Description string `gorm:"not null"`
+	Acceptance  *string
+	Priority    string `gorm:"default:'MEDIUM'"`
+	StoryPoints *int
+	Status      string `gorm:"default:'BACKLOG'"`
+	Rank        string `gorm:"index"`
+	Blocked     bool
+	CompletedAt *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	AssigneeID  *string `gorm:"size:64"`
+	SprintID    *string `gorm:"size:64"`
+	EpicID      *string `gorm:"size:64;index"`
+}
+
+type task0001 struct {
+	ID                string  `gorm:"primaryKey;size:64"`
+	ProjectID         string  `gorm:"size:64;index"`
+	UserStoryID       *string `gorm:"size:64;index"`
+	SprintID          *string `gorm:"size:64;index"`
+	Title             string  `gorm:"not null"`
+	Description       *string
+	Priority          string `gorm:"default:'MEDIUM'"`
+	Status            string `gorm:"default:'TODO'"`
+	Deadline          *time.Time
+	Blocked           bool
+	BoardRank         string `gorm:"index"`
+	OriginalEstimate  *int
+	RemainingEstimate *int
+	RetroItemID       *string `gorm:"size:64;index"`
+	CompletedAt       *time.Time
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	AssigneeID        *string `gorm:"size:64"`
+}
+
+type dependency0001 struct {
+	ID          string  `gorm:"primaryKey;size:64"`
+	ProjectID   string  `gorm:"size:64;index"`
+	EntityType  string  `gorm:"size:32;uniqueIndex:idx_dependency_link"`
+	BlockerID   string  `gorm:"size:64;uniqueIndex:idx_dependency_link;index"`
+	BlockedID   string  `gorm:"size:64;uniqueIndex:idx_dependency_link;index"`
+	CreatedByID *string `gorm:"size:64"`
+	CreatedAt   time.Time
+}
+
+type worklog0001 struct {
+	ID        string  `gorm:"primaryKey;size:64"`
+	TaskID    string  `gorm:"size:64;index"`
+	ProjectID string  `gorm:"size:64;index"`
+	SprintID  *string `gorm:"size:64;index"`
+	UserID    string  `gorm:"size:64;index"`
+	Minutes   int
+	Date      time.Time `gorm:"index"`
+	Note      *string
+	StartedAt *time.Time
+	EndedAt   *time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type comment0001 struct {
+	ID         string  `gorm:"primaryKey;size:64"`
+	ProjectID  string  `gorm:"size:64;index"`
+	EntityType string  `gorm:"index:idx_comment_entity"`
+	EntityID   string  `gorm:"index:idx_comment_entity"`
+	ParentID   *string `gorm:"size:64;index"`
+	AuthorID   string  `gorm:"size:64;index"`
+	Content    string  `gorm:"type:text;not null"`
+	Deleted    bool
+	EditedAt   *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+type boardColumn0001 struct {
+	ID        string `gorm:"primaryKey;size:64"`
+	ProjectID string `gorm:"size:64;uniqueIndex:idx_board_column"`
+	Status    string `gorm:"size:64;uniqueIndex:idx_board_column"`
+	Name      string
+	Position  int
+	WipLimit  *int
+	WipPolicy string `gorm:"default:'BLOCK'"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type rubric0001 struct {
+	ID          string  `gorm:"primaryKey;size:64"`
+	ProjectID   *string `gorm:"size:64;index"`
+	Name        string  `gorm:"not null"`
+	Description *string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type criteria0001 struct {
+	ID          string `gorm:"primaryKey;size:64"`
+	RubricID    string `gorm:"size:64;index"`
+	Name        string `gorm:"not null"`
+	Description *string
+	MaxScore    int `gorm:"default:100"`
+	Weight      int `gorm:"default:1"`
+}
+
+type evaluation0001 struct {
+	ID          string  `gorm:"primaryKey;size:64"`
+	ProjectID   string  `gorm:"size:64;index"`
+	TaskID      *string `gorm:"size:64;index"`
+	SprintID    *string `gorm:"size:64;index"`
+	EvaluatorID string  `gorm:"size:64;index"`
+	RubricID    *string `gorm:"size:64;index"`
+	Status      string  `gorm:"default:'DRAFT'"`
+	Feedback    *string
+	Score       *int
+	SubmittedAt *time.Time
+	PublishedAt *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type evaluationCriteria0001 struct {
+	ID           string `gorm:"primaryKey;size:64"`
+	EvaluationID string `gorm:"size:64;index:idx_eval_criteria,unique"`
+	CriteriaID   string `gorm:"size:64;index:idx_eval_criteria,unique"`
+	Score        int    `gorm:"default:0"`
+	Comment      *string
+}
+
+type chat0001 struct {
+	ID        string  `gorm:"primaryKey;size:64"`
+	ProjectID *string `gorm:"size:64;index"`
+	Title     *string
+	Type      string `gorm:"default:'PROJECT'"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type chatParticipant0001 struct {
+	ChatID string `gorm:"primaryKey;size:64"`
+	UserID string `gorm:"primaryKey;size:64"`
+}
+
+type message0001 struct {
+	ID        string `gorm:"primaryKey;size:64"`
+	ChatID    string `gorm:"size:64;index"`
+	UserID    string `gorm:"size:64"`
+	Content   string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type notification0001 struct {
+	ID        string `gorm:"primaryKey;size:64"`
+	UserID    string `gorm:"size:64;index"`
+	Title     string
+	Message   string
+	Type      string
+	Read      bool `gorm:"default:false"`
+	CreatedAt time.Time
+}
+
+type retroSession0001 struct {
+	ID            string `gorm:"primaryKey;size:64"`
+	ProjectID     string `gorm:"size:64;index"`
+	SprintID      string `gorm:"size:64;uniqueIndex"`
+	Title         string
+	Template      string
+	Phase         string  `gorm:"default:'COLLECT'"`
+	VoteBudget    int     `gorm:"default:3"`
+	FacilitatorID *string `gorm:"size:64"`
+	ClosedAt      *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+type retroGroup0001 struct {
+	ID        string `gorm:"primaryKey;size:64"`
+	SessionID string `gorm:"size:64;index"`
+	Title     string
+	CreatedAt time.Time
+}
+
+type retrospectiveItem0001 struct {
+	ID        string  `gorm:"primaryKey;size:64"`
+	SprintID  string  `gorm:"size:64;index"`
+	SessionID *string `gorm:"size:64;index"`
+	GroupID   *string `gorm:"size:64;index"`
+	Type      string
+	Content   string
+	UserID    string `gorm:"size:64"`
+	Anonymous bool
+	CreatedAt time.Time
+}
+
+type retroVote0001 struct {
+	ID         string `gorm:"primaryKey;size:64"`
+	SessionID  string `gorm:"size:64;index"`
+	UserID     string `gorm:"index"`
+	TargetType string
+	TargetID   string `gorm:"index"`
+	CreatedAt  time.Time
+}
+
+type document0001 struct {
+	ID           string `gorm:"primaryKey;size:64"`
+	ProjectID    string `gorm:"size:64;index"`
+	Name         string
+	URL          string
+	Type         string
+	Size         *int
+	SizeBytes    int64
+	Checksum     string
+	StorageKey   string
+	Version      int     `gorm:"default:1"`
+	ParentID     *string `gorm:"index;size:64"`
+	UploadedByID *string
+	UploadedAt   time.Time
+}
+
+type historyEntry0001 struct {
+	ID         string `gorm:"primaryKey;size:64"`
+	EntityType string `gorm:"index:idx_history_entity"`
+	EntityID   string `gorm:"index:idx_history_entity"`
+	ProjectID  string `gorm:"index"`
+	Field      string `gorm:"index"`
+	OldValue   *string
+	NewValue   *string
+	UserID     *string   `gorm:"size:64"`
+	CreatedAt  time.Time `gorm:"index"`
+}
+
+type peerReviewRound0001 struct {
+	ID          string  `gorm:"primaryKey;size:64"`
+	ProjectID   string  `gorm:"size:64;index"`
+	SprintID    *string `gorm:"size:64;index"`
+	RubricID    string  `gorm:"size:64;index"`
+	CreatedByID string
+	Title       string `gorm:"not null"`
+	Anonymous   bool   `gorm:"default:false"`
+	Status      string `gorm:"default:'OPEN'"`
+	DueDate     *time.Time
+	ClosedAt    *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type peerReview0001 struct {
+	ID         string `gorm:"primaryKey;size:64"`
+	RoundID    string `gorm:"size:64;index:idx_peer_review,unique"`
+	ReviewerID string `gorm:"size:64;index:idx_peer_review,unique"`
+	RevieweeID string `gorm:"size:64;index:idx_peer_review,unique"`
+	Score      int
+	Comment    *string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+type peerReviewScore0001 struct {
+	ID         string `gorm:"primaryKey;size:64"`
+	ReviewID   string `gorm:"size:64;index:idx_peer_review_criteria,unique"`
+	CriteriaID string `gorm:"size:64;index:idx_peer_review_criteria,unique"`
+	Score      int
+}
+
+func (user0001) TableName() string               { return "users" }
+func (session0001) TableName() string            { return "sessions" }
+func (project0001) TableName() string            { return "projects" }
+func (projectMember0001) TableName() string      { return "project_members" }
+func (sprint0001) TableName() string             { return "sprints" }
+func (sprintReport0001) TableName() string       { return "sprint_reports" }
+func (sprintScopeChange0001) TableName() string  { return "sprint_scope_changes" }
+func (epic0001) TableName() string               { return "epics" }
+func (userStory0001) TableName() string          { return "user_stories" }
+func (task0001) TableName() string               { return "tasks" }
+func (dependency0001) TableName() string         { return "dependencies" }
+func (worklog0001) TableName() string            { return "worklogs" }
+func (comment0001) TableName() string            { return "comments" }
+func (boardColumn0001) TableName() string        { return "board_columns" }
+func (rubric0001) TableName() string             { return "rubrics" }
+func (criteria0001) TableName() string           { return "criteria" }
+func (evaluation0001) TableName() string         { return "evaluations" }
+func (evaluationCriteria0001) TableName() string { return "evaluation_criteria" }
+func (chat0001) TableName() string               { return "chats" }
+func (chatParticipant0001) TableName() string    { return "chat_participants" }
+func (message0001) TableName() string            { return "messages" }
+func (notification0001) TableName() string       { return "notifications" }
+func (retroSession0001) TableName() string       { return "retro_sessions" }
+func (retroGroup0001) TableName() string         { return "retro_groups" }
+func (retrospectiveItem0001) TableName() string  { return "retrospective_items" }
+func (retroVote0001) TableName() string          { return "retro_votes" }
+func (document0001) TableName() string           { return "documents" }
+func (historyEntry0001) TableName() string       { return "history_entries" }
+func (peerReviewRound0001) TableName() string    { return "peer_review_rounds" }
+func (peerReview0001) TableName() string         { return "peer_reviews" }
+func (peerReviewScore0001) TableName() string    { return "peer_review_scores" }
+
+// initialTables lists the tables of 0001 in the order they are created.
+func initialTables() []interface{} {
+	return []interface{}{
+		&user0001{},
+		&session0001{},
+		&project0001{},
+		&projectMember0001{},
+		&sprint0001{},
+		&sprintReport0001{},
+		&sprintScopeChange0001{},
+		&epic0001{},
+		&userStory0001{},
+		&task0001{},
+		&dependency0001{},
+		&worklog0001{},
+		&comment0001{},
+		&boardColumn0001{},
+		&rubric0001{},
+		&criteria0001{},
+		&evaluation0001{},
+		&evaluationCriteria0001{},
+		&chat0001{},
+		&chatParticipant0001{},
+		&message0001{},
+		&notification0001{},
+		&retroSession0001{},
+		&retroGroup0001{},
+		&retrospectiveItem0001{},
+		&retroVote0001{},
+		&document0001{},
+		&historyEntry0001{},
+		&peerReviewRound0001{},
+		&peerReview0001{},
+		&peerReviewScore0001{},
+	}
+}
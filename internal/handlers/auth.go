@@ -2,14 +2,12 @@ package handlers
 
 import (
 	"net/http"
-	"time"
 
 	"Wrk_Api/internal/database"
 	"Wrk_Api/internal/models"
 	"Wrk_Api/internal/utils"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -17,7 +15,6 @@ type RegisterRequest struct {
 	Name     string `json:"name" binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=6"`
-	Role     string `json:"role"`
 }
 
 type LoginRequest struct {
@@ -46,20 +43,16 @@ func Register(c *gin.Context) {
 		return
 	}
 
-	// Create user
+	// Create user; only admins give out other roles, through the users API
 	user := models.User{
 		ID:       utils.GenerateCUID(),
 		Name:     req.Name,
 		Email:    req.Email,
 		Password: string(hashedPassword),
-		Role:     req.Role,
+		Role:     "TEAM_DEVELOPER",
 		Active:   true,
 	}
 
-	if user.Role == "" {
-		user.Role = "TEAM_DEVELOPER"
-	}
-
 	if result := database.DB.Create(&user); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al registrar usuario", "details": result.Error.Error()})
 		return
@@ -99,23 +92,17 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	// Generate JWT
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"userId": user.ID,
-		"email":  user.Email,
-		"role":   user.Role,
-		"exp":    time.Now().Add(time.Hour * 24).Unix(),
-	})
-
-	tokenString, err := token.SignedString(utils.GetJWTSecret())
+	tokenString, refreshToken, err := createSession(c, user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al generar token"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Inicio de sesión exitoso",
-		"token":   tokenString,
+		"message":      "Inicio de sesión exitoso",
+		"token":        tokenString,
+		"refreshToken": refreshToken,
+		"expiresIn":    int(utils.AccessTokenTTL.Seconds()),
 		"user": gin.H{
 			"id":    user.ID,
 			"email": user.Email,
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"net/http"
+
+	"Wrk_Api/internal/database"
+	"Wrk_Api/internal/models"
+	"Wrk_Api/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxRankLength triggers a rebalance once repeated moves into the same gap
+// have made ranks this long.
+const maxRankLength = 24
+
+type MoveUserStoryRequest struct {
+	BeforeID *string `json:"beforeId"` // place the story right before this one
+	AfterID  *string `json:"afterId"`  // or right after this one
+}
+
+// rebalanceRanks gives every story of the project a fresh, evenly spaced rank,
+// keeping the current order; stories without a rank go last by creation date.
+func rebalanceRanks(tx *gorm.DB, projectID string) error {
+	var stories []models.UserStory
+	// rank is reserved in MySQL, so queries always qualify it with the table name
+	tx.Where("project_id = ?", projectID).
+		Order("CASE WHEN user_stories.rank = '' OR user_stories.rank IS NULL THEN 1 ELSE 0 END, user_stories.rank asc, created_at asc").
+		Find(&stories)
+
+	for i, rank := range utils.RankSequence(len(stories)) {
+		if err := tx.Model(&models.UserStory{}).Where("id = ?", stories[i].ID).UpdateColumn("rank", rank).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureRanks ranks stories created before ranking existed.
+func ensureRanks(tx *gorm.DB, projectID string) error {
+	var unranked int64
+	tx.Model(&models.UserStory{}).Where("project_id = ? AND (user_stories.rank = '' OR user_stories.rank IS NULL)", projectID).Count(&unranked)
+	if unranked == 0 {
+		return nil
+	}
+	return rebalanceRanks(tx, projectID)
+}
+
+// lastRank returns a rank placing a new story at the bottom of the backlog.
+func lastRank(tx *gorm.DB, projectID string) (string, error) {
+	if err := ensureRanks(tx, projectID); err != nil {
+		return "", err
+	}
+	var last []models.UserStory
+	tx.Where("project_id = ?", projectID).Order("user_stories.rank desc").Limit(1).Find(&last)
+	if len(last) == 0 {
+		return utils.RankBetween("", ""), nil
+	}
+	return utils.RankBetween(last[0].Rank, ""), nil
+}
+
+// neighbourRanks returns the ranks around the requested position, excluding the moved story.
+func neighbourRanks(tx *gorm.DB, story models.UserStory, req MoveUserStoryRequest) (string, string, int) {
+	anchorID := req.AfterID
+	if req.BeforeID != nil {
+		anchorID = req.BeforeID
+	}
+
+	var anchor models.UserStory
+	if err := tx.First(&anchor, "id = ? AND project_id = ?", *anchorID, story.ProjectID).Error; err != nil {
+		return "", "", http.StatusNotFound
+	}
+	if anchor.ID == story.ID {
+		return "", "", http.StatusBadRequest
+	}
+
+	var neighbour []models.UserStory
+	others := tx.Where("project_id = ? AND id <> ?", story.ProjectID, story.ID)
+	if req.BeforeID != nil {
+		others.Where("user_stories.rank < ?", anchor.Rank).Order("user_stories.rank desc").Limit(1).Find(&neighbour)
+		if len(neighbour) == 0 {
+			return "", anchor.Rank, http.StatusOK
+		}
+		return neighbour[0].Rank, anchor.Rank, http.StatusOK
+	}
+	others.Where("user_stories.rank > ?", anchor.Rank).Order("user_stories.rank asc").Limit(1).Find(&neighbour)
+	if len(neighbour) == 0 {
+		return anchor.Rank, "", http.StatusOK
+	}
+	return anchor.Rank, neighbour[0].Rank, http.StatusOK
+}
+
+// POST /api/user-stories/:id/move
+func MoveUserStory(c *gin.Context) {
+	var req MoveUserStoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if (req.BeforeID == nil) == (req.AfterID == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Indica beforeId o afterId"})
+		return
+	}
+
+	var story models.UserStory
+	if result := database.DB.First(&story, "id = ?", c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User story no encontrado"})
+		return
+	}
+
+	status := http.StatusOK
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := ensureRanks(tx, story.ProjectID); err != nil {
+			return err
+		}
+
+		prev, next, code := neighbourRanks(tx, story, req)
+		if code != http.StatusOK {
+			status = code
+			return nil
+		}
+		// Equal neighbours leave no room in between; spread the ranks out first
+		if next != "" && prev >= next {
+			if err := rebalanceRanks(tx, story.ProjectID); err != nil {
+				return err
+			}
+			prev, next, _ = neighbourRanks(tx, story, req)
+		}
+
+		rank := utils.RankBetween(prev, next)
+		if err := tx.Model(&story).UpdateColumn("rank", rank).Error; err != nil {
+			return err
+		}
+		if len(rank) > maxRankLength {
+			return rebalanceRanks(tx, story.ProjectID)
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al mover la historia"})
+		return
+	}
+	switch status {
+	case http.StatusNotFound:
+		c.JSON(status, gin.H{"error": "La historia de referencia no existe en el proyecto"})
+		return
+	case http.StatusBadRequest:
+		c.JSON(status, gin.H{"error": "Una historia no puede moverse respecto a sí misma"})
+		return
+	}
+
+	database.DB.First(&story, "id = ?", story.ID)
+	c.JSON(http.StatusOK, gin.H{"data": story})
+}
+
+// GET /api/projects/:id/backlog
+// Unscheduled, unfinished stories in rank order.
+func GetProjectBacklog(c *gin.Context) {
+	projectID := c.Param("id")
+	if err := ensureRanks(database.DB, projectID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al ordenar el backlog"})
+		return
+	}
+
+	var stories []models.UserStory
+	database.DB.Preload("Assignee").
+		Where("project_id = ? AND sprint_id IS NULL AND status NOT IN ?", projectID, doneStatusList).
+		Order("user_stories.rank asc").Find(&stories)
+	c.JSON(http.StatusOK, gin.H{"data": stories})
+}
@@ -0,0 +1,428 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"Wrk_Api/internal/database"
+	"Wrk_Api/internal/models"
+	"Wrk_Api/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type BoardColumnRequest struct {
+	Status    string `json:"status" binding:"required"`
+	Name      string `json:"name"`
+	WipLimit  *int   `json:"wipLimit"`
+	WipPolicy string `json:"wipPolicy"` // BLOCK (default) or WARN
+}
+
+type UpdateBoardColumnsRequest struct {
+	Columns []BoardColumnRequest `json:"columns" binding:"required,min=1,dive"`
+}
+
+type MoveTaskRequest struct {
+	Status   string  `json:"status" binding:"required"`
+	BeforeID *string `json:"beforeId"` // place the card right before this one
+	AfterID  *string `json:"afterId"`  // or right after it; neither puts it last
+}
+
+type BoardColumnView struct {
+	Status    string        `json:"status"`
+	Name      string        `json:"name"`
+	WipLimit  *int          `json:"wipLimit"`
+	WipPolicy string        `json:"wipPolicy"`
+	Count     int           `json:"count"`
+	OverLimit bool          `json:"overLimit"`
+	Cards     []models.Task `json:"cards"`
+}
+
+// defaultBoardColumns are used until a project configures its own.
+var defaultBoardColumns = []models.BoardColumn{
+	{Status: "TODO", Name: "Por hacer", Position: 0, WipPolicy: models.WipBlock},
+	{Status: "IN_PROGRESS", Name: "En progreso", Position: 1, WipPolicy: models.WipBlock},
+	{Status: "DONE", Name: "Hecho", Position: 2, WipPolicy: models.WipBlock},
+}
+
+func boardColumns(projectID string) []models.BoardColumn {
+	var columns []models.BoardColumn
+	database.DB.Where("project_id = ?", projectID).Order("position asc").Find(&columns)
+	if len(columns) == 0 {
+		return append([]models.BoardColumn{}, defaultBoardColumns...)
+	}
+	return columns
+}
+
+// columnStatuses returns the task statuses shown in a column: its own, plus
+// COMPLETED in a DONE column (and the other way round) unless configured separately.
+func columnStatuses(columns []models.BoardColumn, status string) []string {
+	statuses := []string{status}
+	if !doneStatuses[status] {
+		return statuses
+	}
+	for done := range doneStatuses {
+		configured := false
+		for _, column := range columns {
+			configured = configured || column.Status == done
+		}
+		if !configured {
+			statuses = append(statuses, done)
+		}
+	}
+	return statuses
+}
+
+// boardScope restricts a task query to a board: a sprint, or the whole project.
+func boardScope(query *gorm.DB, projectID string, sprintID *string) *gorm.DB {
+	query = query.Where("project_id = ?", projectID)
+	if sprintID != nil {
+		query = query.Where("sprint_id = ?", *sprintID)
+	}
+	return query
+}
+
+// columnLoad counts the project's tasks in a column for its WIP limit, leaving
+// out excludeID. Limits are set per project, so every board counts the whole
+// project, whatever sprint it shows.
+func columnLoad(tx *gorm.DB, projectID string, statuses []string, excludeID string) int {
+	var count int64
+	boardScope(tx.Model(&models.Task{}), projectID, nil).Where("status IN ? AND id <> ?", statuses, excludeID).Count(&count)
+	return int(count)
+}
+
+// checkWipLimit applies the WIP limit of the column a task enters when its
+// status changes from one status to another. A WARN column returns a warning;
+// a BLOCK column returns the body to reject the change with.
+func checkWipLimit(tx *gorm.DB, task models.Task, from, to string) (string, gin.H) {
+	columns := boardColumns(task.ProjectID)
+	for _, column := range columns {
+		statuses := columnStatuses(columns, column.Status)
+		if !containsString(statuses, to) {
+			continue
+		}
+		if column.WipLimit == nil || containsString(statuses, from) {
+			return "", nil
+		}
+		count := columnLoad(tx, task.ProjectID, statuses, task.ID)
+		if count < *column.WipLimit {
+			return "", nil
+		}
+		message := "La columna " + column.Name + " superaría su límite WIP de " + strconv.Itoa(*column.WipLimit)
+		if column.WipPolicy == models.WipWarn {
+			return message, nil
+		}
+		return "", gin.H{"error": message, "code": "WIP_LIMIT_EXCEEDED", "limit": *column.WipLimit, "count": count}
+	}
+	return "", nil
+}
+
+// ensureTaskRanks appends tasks without a board rank after the ranked ones, oldest first.
+func ensureTaskRanks(tx *gorm.DB, projectID string) error {
+	var unranked []models.Task
+	tx.Where("project_id = ? AND (board_rank = '' OR board_rank IS NULL)", projectID).Order("created_at asc").Find(&unranked)
+	if len(unranked) == 0 {
+		return nil
+	}
+	var last []models.Task
+	tx.Where("project_id = ? AND board_rank <> ''", projectID).Order("board_rank desc").Limit(1).Find(&last)
+	rank := ""
+	if len(last) > 0 {
+		rank = last[0].BoardRank
+	}
+	for _, task := range unranked {
+		rank = utils.RankBetween(rank, "")
+		if err := tx.Model(&models.Task{}).Where("id = ?", task.ID).UpdateColumn("board_rank", rank).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func buildBoard(projectID string, sprintID *string) ([]BoardColumnView, error) {
+	if err := ensureTaskRanks(database.DB, projectID); err != nil {
+		return nil, err
+	}
+	columns := boardColumns(projectID)
+
+	var tasks []models.Task
+	boardScope(database.DB.Preload("Assignee"), projectID, sprintID).Order("board_rank asc").Find(&tasks)
+
+	views := []BoardColumnView{}
+	index := map[string]int{}
+	for _, column := range columns {
+		for _, status := range columnStatuses(columns, column.Status) {
+			index[status] = len(views)
+		}
+		views = append(views, BoardColumnView{
+			Status:    column.Status,
+			Name:      column.Name,
+			WipLimit:  column.WipLimit,
+			WipPolicy: column.WipPolicy,
+			Cards:     []models.Task{},
+		})
+	}
+	for _, task := range tasks {
+		i, ok := index[task.Status]
+		if !ok {
+			// Statuses without a column still show up, at the end of the board
+			i = len(views)
+			index[task.Status] = i
+			views = append(views, BoardColumnView{Status: task.Status, Name: task.Status, WipPolicy: models.WipBlock, Cards: []models.Task{}})
+		}
+		views[i].Cards = append(views[i].Cards, task)
+	}
+	for i := range views {
+		views[i].Count = len(views[i].Cards)
+		if views[i].WipLimit != nil {
+			views[i].OverLimit = columnLoad(database.DB, projectID, columnStatuses(columns, views[i].Status), "") > *views[i].WipLimit
+		}
+	}
+	return views, nil
+}
+
+// GET /api/boards/projects/:projectId?sprintId=
+func GetProjectBoard(c *gin.Context) {
+	var sprintID *string
+	if id := c.Query("sprintId"); id != "" {
+		sprintID = &id
+	}
+	board, err := buildBoard(c.Param("projectId"), sprintID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el tablero"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": board})
+}
+
+// GET /api/boards/sprints/:sprintId
+func GetSprintBoard(c *gin.Context) {
+	var sprint models.Sprint
+	if err := database.DB.First(&sprint, "id = ?", c.Param("sprintId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sprint no encontrado"})
+		return
+	}
+	board, err := buildBoard(sprint.ProjectID, &sprint.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el tablero"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": board})
+}
+
+// GET /api/boards/projects/:projectId/columns
+func GetBoardColumns(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": boardColumns(c.Param("projectId"))})
+}
+
+// PUT /api/boards/projects/:projectId/columns
+// Replaces the column configuration; the order of the list is the board order.
+func UpdateBoardColumns(c *gin.Context) {
+	var req UpdateBoardColumnsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	projectID := c.Param("projectId")
+
+	columns := []models.BoardColumn{}
+	seen := map[string]bool{}
+	for i, col := range req.Columns {
+		if seen[col.Status] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Estado repetido: " + col.Status})
+			return
+		}
+		seen[col.Status] = true
+		if col.WipLimit != nil && *col.WipLimit < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "wipLimit debe ser al menos 1"})
+			return
+		}
+		policy := col.WipPolicy
+		if policy == "" {
+			policy = models.WipBlock
+		}
+		if policy != models.WipBlock && policy != models.WipWarn {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "wipPolicy debe ser BLOCK o WARN"})
+			return
+		}
+		name := col.Name
+		if name == "" {
+			name = col.Status
+		}
+		columns = append(columns, models.BoardColumn{
+			ID:        utils.GenerateCUID(),
+			ProjectID: projectID,
+			Status:    col.Status,
+			Name:      name,
+			Position:  i,
+			WipLimit:  col.WipLimit,
+			WipPolicy: policy,
+		})
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("project_id = ?", projectID).Delete(&models.BoardColumn{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&columns).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al guardar las columnas"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": columns})
+}
+
+// cardNeighbours returns the ranks around the requested position in a column.
+func cardNeighbours(column *gorm.DB, task models.Task, req MoveTaskRequest) (string, string, bool) {
+	var neighbour []models.Task
+	if req.BeforeID == nil && req.AfterID == nil {
+		column.Session(&gorm.Session{}).Order("board_rank desc").Limit(1).Find(&neighbour)
+		if len(neighbour) == 0 {
+			return "", "", true
+		}
+		return neighbour[0].BoardRank, "", true
+	}
+
+	anchorID := req.AfterID
+	if req.BeforeID != nil {
+		anchorID = req.BeforeID
+	}
+	var anchor []models.Task
+	column.Session(&gorm.Session{}).Where("id = ?", *anchorID).Limit(1).Find(&anchor)
+	if len(anchor) == 0 {
+		return "", "", false
+	}
+
+	if req.BeforeID != nil {
+		column.Session(&gorm.Session{}).Where("board_rank < ?", anchor[0].BoardRank).Order("board_rank desc").Limit(1).Find(&neighbour)
+		if len(neighbour) == 0 {
+			return "", anchor[0].BoardRank, true
+		}
+		return neighbour[0].BoardRank, anchor[0].BoardRank, true
+	}
+	column.Session(&gorm.Session{}).Where("board_rank > ?", anchor[0].BoardRank).Order("board_rank asc").Limit(1).Find(&neighbour)
+	if len(neighbour) == 0 {
+		return anchor[0].BoardRank, "", true
+	}
+	return anchor[0].BoardRank, neighbour[0].BoardRank, true
+}
+
+// rebalanceColumn spreads the ranks of a column's cards out again, keeping their order.
+func rebalanceColumn(column *gorm.DB, tx *gorm.DB) error {
+	var cards []models.Task
+	column.Session(&gorm.Session{}).Order("board_rank asc").Find(&cards)
+	for i, rank := range utils.RankSequence(len(cards)) {
+		if err := tx.Model(&models.Task{}).Where("id = ?", cards[i].ID).UpdateColumn("board_rank", rank).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// POST /api/tasks/:id/move
+// Moves a card to a column and position in one step, enforcing the column's WIP limit.
+func MoveTask(c *gin.Context) {
+	var req MoveTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.BeforeID != nil && req.AfterID != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Indica solo beforeId o afterId"})
+		return
+	}
+
+	var task models.Task
+	if result := database.DB.First(&task, "id = ?", c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tarea no encontrada"})
+		return
+	}
+	if (req.BeforeID != nil && *req.BeforeID == task.ID) || (req.AfterID != nil && *req.AfterID == task.ID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Una tarea no puede moverse respecto a sí misma"})
+		return
+	}
+
+	columns := boardColumns(task.ProjectID)
+	var target *models.BoardColumn
+	for i := range columns {
+		if columns[i].Status == req.Status {
+			target = &columns[i]
+		}
+	}
+	if target == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El tablero no tiene una columna " + req.Status})
+		return
+	}
+	statuses := columnStatuses(columns, target.Status)
+
+	var warning string
+	var rejected gin.H
+	anchorMissing := false
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := ensureTaskRanks(tx, task.ProjectID); err != nil {
+			return err
+		}
+		column := boardScope(tx.Model(&models.Task{}), task.ProjectID, task.SprintID).
+			Where("status IN ? AND id <> ?", statuses, task.ID)
+
+		if warning, rejected = checkWipLimit(tx, task, task.Status, req.Status); rejected != nil {
+			return nil
+		}
+
+		prev, next, ok := cardNeighbours(column, task, req)
+		if !ok {
+			anchorMissing = true
+			return nil
+		}
+		// No room between equal neighbours; spread the column out first
+		if next != "" && prev >= next {
+			if err := rebalanceColumn(column, tx); err != nil {
+				return err
+			}
+			prev, next, _ = cardNeighbours(column, task, req)
+		}
+
+		before := taskSnapshot(task)
+		statusChanged := task.Status != req.Status
+		task.BoardRank = utils.RankBetween(prev, next)
+		if statusChanged {
+			setTaskStatus(&task, req.Status)
+		}
+		if err := tx.Save(&task).Error; err != nil {
+			return err
+		}
+		if len(task.BoardRank) > maxRankLength {
+			if err := rebalanceColumn(boardScope(tx.Model(&models.Task{}), task.ProjectID, task.SprintID).Where("status IN ?", statuses), tx); err != nil {
+				return err
+			}
+		}
+		if !statusChanged {
+			return nil
+		}
+		if err := refreshDependents(tx, models.EntityTask, task.ID); err != nil {
+			return err
+		}
+		return recordHistory(tx, c, models.EntityTask, task.ID, task.ProjectID, before, taskSnapshot(task))
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al mover la tarea"})
+		return
+	}
+	if rejected != nil {
+		c.JSON(http.StatusConflict, rejected)
+		return
+	}
+	if anchorMissing {
+		c.JSON(http.StatusNotFound, gin.H{"error": "La tarea de referencia no está en esa columna"})
+		return
+	}
+
+	database.DB.First(&task, "id = ?", task.ID)
+	response := gin.H{"data": task}
+	if warning != "" {
+		response["warning"] = warning
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"Wrk_Api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SQLite has no foreign key constraints and Postgres and MySQL leave out the
+// polymorphic and history columns (see database.foreignKeys0004), so the
+// delete handlers clear the rows that hang off what they delete with these
+// helpers, in the same transaction and before the parent rows go.
+
+// cascadeStep is one delete or update of a cascade.
+type cascadeStep func(tx *gorm.DB) *gorm.DB
+
+// runCascade runs the steps in order and stops at the first error.
+func runCascade(tx *gorm.DB, steps []cascadeStep) error {
+	for _, step := range steps {
+		if err := step(tx).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteEvaluationRows deletes the evaluations matched by where and their criteria scores.
+func deleteEvaluationRows(tx *gorm.DB, where string, args ...interface{}) error {
+	evaluations := tx.Model(&models.Evaluation{}).Select("id").Where(where, args...)
+	if err := tx.Where("evaluation_id IN (?)", evaluations).Delete(&models.EvaluationCriteria{}).Error; err != nil {
+		return err
+	}
+	return tx.Where(where, args...).Delete(&models.Evaluation{}).Error
+}
+
+// deletePeerReviewRows deletes the peer review rounds matched by where with their reviews.
+func deletePeerReviewRows(tx *gorm.DB, where string, args ...interface{}) error {
+	rounds := tx.Model(&models.PeerReviewRound{}).Select("id").Where(where, args...)
+	reviews := tx.Model(&models.PeerReview{}).Select("id").Where("round_id IN (?)", rounds)
+	return runCascade(tx, []cascadeStep{
+		func(tx *gorm.DB) *gorm.DB {
+			return tx.Where("review_id IN (?)", reviews).Delete(&models.PeerReviewScore{})
+		},
+		func(tx *gorm.DB) *gorm.DB { return tx.Where("round_id IN (?)", rounds).Delete(&models.PeerReview{}) },
+		func(tx *gorm.DB) *gorm.DB { return tx.Where(where, args...).Delete(&models.PeerReviewRound{}) },
+	})
+}
+
+// deleteRetroRows deletes the retro sessions matched by where with their groups and votes.
+func deleteRetroRows(tx *gorm.DB, where string, args ...interface{}) error {
+	sessions := tx.Model(&models.RetroSession{}).Select("id").Where(where, args...)
+	return runCascade(tx, []cascadeStep{
+		func(tx *gorm.DB) *gorm.DB { return tx.Where("session_id IN (?)", sessions).Delete(&models.RetroVote{}) },
+		func(tx *gorm.DB) *gorm.DB { return tx.Where("session_id IN (?)", sessions).Delete(&models.RetroGroup{}) },
+		func(tx *gorm.DB) *gorm.DB { return tx.Where(where, args...).Delete(&models.RetroSession{}) },
+	})
+}
+
+// leaveSprints takes the stories and tasks out of the sprints in sprintIDs,
+// recording the change in their history like UpdateUserStory and UpdateTask do.
+func leaveSprints(tx *gorm.DB, c *gin.Context, sprintIDs *gorm.DB) error {
+	var stories []models.UserStory
+	if err := tx.Where("sprint_id IN (?)", sprintIDs).Find(&stories).Error; err != nil {
+		return err
+	}
+	var tasks []models.Task
+	if err := tx.Where("sprint_id IN (?)", sprintIDs).Find(&tasks).Error; err != nil {
+		return err
+	}
+	if err := runCascade(tx, []cascadeStep{
+		func(tx *gorm.DB) *gorm.DB {
+			return tx.Model(&models.UserStory{}).Where("sprint_id IN (?)", sprintIDs).Update("sprint_id", nil)
+		},
+		func(tx *gorm.DB) *gorm.DB {
+			return tx.Model(&models.Task{}).Where("sprint_id IN (?)", sprintIDs).Update("sprint_id", nil)
+		},
+	}); err != nil {
+		return err
+	}
+
+	for _, story := range stories {
+		before := userStorySnapshot(story)
+		story.SprintID = nil
+		if err := recordHistory(tx, c, models.EntityUserStory, story.ID, story.ProjectID, before, userStorySnapshot(story)); err != nil {
+			return err
+		}
+	}
+	for _, task := range tasks {
+		before := taskSnapshot(task)
+		task.SprintID = nil
+		if err := recordHistory(tx, c, models.EntityTask, task.ID, task.ProjectID, before, taskSnapshot(task)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteSprintRows clears what belongs to the sprints in sprintIDs. Stories,
+// tasks and worklogs stay and just leave the sprint.
+func deleteSprintRows(tx *gorm.DB, c *gin.Context, sprintIDs *gorm.DB) error {
+	if err := leaveSprints(tx, c, sprintIDs); err != nil {
+		return err
+	}
+	items := tx.Model(&models.RetrospectiveItem{}).Select("id").Where("sprint_id IN (?)", sprintIDs)
+	if err := runCascade(tx, []cascadeStep{
+		func(tx *gorm.DB) *gorm.DB {
+			return tx.Model(&models.Worklog{}).Where("sprint_id IN (?)", sprintIDs).Update("sprint_id", nil)
+		},
+		func(tx *gorm.DB) *gorm.DB {
+			return tx.Model(&models.Task{}).Where("retro_item_id IN (?)", items).Update("retro_item_id", nil)
+		},
+		func(tx *gorm.DB) *gorm.DB {
+			return tx.Where("sprint_id IN (?)", sprintIDs).Delete(&models.RetrospectiveItem{})
+		},
+		func(tx *gorm.DB) *gorm.DB {
+			return tx.Model(&models.SprintReport{}).Where("next_sprint_id IN (?)", sprintIDs).Update("next_sprint_id", nil)
+		},
+		func(tx *gorm.DB) *gorm.DB { return tx.Where("sprint_id IN (?)", sprintIDs).Delete(&models.SprintReport{}) },
+		func(tx *gorm.DB) *gorm.DB {
+			return tx.Where("sprint_id IN (?)", sprintIDs).Delete(&models.SprintScopeChange{})
+		},
+	}); err != nil {
+		return err
+	}
+	if err := deleteRetroRows(tx, "sprint_id IN (?)", sprintIDs); err != nil {
+		return err
+	}
+	if err := deletePeerReviewRows(tx, "sprint_id IN (?)", sprintIDs); err != nil {
+		return err
+	}
+	return deleteEvaluationRows(tx, "sprint_id IN (?)", sprintIDs)
+}
+
+// deleteTaskRows deletes the work logged on the task and its evaluations.
+func deleteTaskRows(tx *gorm.DB, taskID string) error {
+	if err := tx.Where("task_id = ?", taskID).Delete(&models.Worklog{}).Error; err != nil {
+		return err
+	}
+	return deleteEvaluationRows(tx, "task_id = ?", taskID)
+}
+
+// deleteProjectRows deletes everything in the project, leaving the project row itself.
+func deleteProjectRows(tx *gorm.DB, c *gin.Context, projectID string) error {
+	sprints := tx.Model(&models.Sprint{}).Select("id").Where("project_id = ?", projectID)
+	if err := deleteSprintRows(tx, c, sprints); err != nil {
+		return err
+	}
+	if err := deleteRetroRows(tx, "project_id = ?", projectID); err != nil {
+		return err
+	}
+	if err := deletePeerReviewRows(tx, "project_id = ?", projectID); err != nil {
+		return err
+	}
+	if err := deleteEvaluationRows(tx, "project_id = ?", projectID); err != nil {
+		return err
+	}
+
+	chats := tx.Model(&models.Chat{}).Select("id").Where("project_id = ?", projectID)
+	rubrics := tx.Model(&models.Rubric{}).Select("id").Where("project_id = ?", projectID)
+	steps := []cascadeStep{
+		func(tx *gorm.DB) *gorm.DB { return tx.Where("chat_id IN (?)", chats).Delete(&models.Message{}) },
+		func(tx *gorm.DB) *gorm.DB { return tx.Where("chat_id IN (?)", chats).Delete(&models.ChatParticipant{}) },
+		func(tx *gorm.DB) *gorm.DB { return tx.Where("rubric_id IN (?)", rubrics).Delete(&models.Criteria{}) },
+	}
+	// Tables keyed by project_id, children before parents
+	for _, model := range []interface{}{
+		&models.Chat{}, &models.Rubric{}, &models.Worklog{}, &models.Comment{}, &models.Dependency{},
+		&models.HistoryEntry{}, &models.Task{}, &models.UserStory{}, &models.Epic{}, &models.Sprint{},
+		&models.BoardColumn{}, &models.Document{}, &models.ProjectMember{},
+	} {
+		steps = append(steps, func(tx *gorm.DB) *gorm.DB { return tx.Where("project_id = ?", projectID).Delete(model) })
+	}
+	return runCascade(tx, steps)
+}
@@ -5,7 +5,9 @@ import (
 	"time"
 
 	"Wrk_Api/internal/database"
+	"Wrk_Api/internal/middleware"
 	"Wrk_Api/internal/models"
+	"Wrk_Api/internal/realtime"
 	"Wrk_Api/internal/utils"
 
 	"github.com/gin-gonic/gin"
@@ -20,6 +22,48 @@ type CreateDMRequest struct {
 	TargetUserID string `json:"targetUserId" binding:"required"`
 }
 
+type UpdateMessageRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
+// chatTopic returns the realtime topic clients follow for a chat.
+func chatTopic(chat models.Chat) string {
+	if chat.ProjectID != nil {
+		return realtime.ProjectChatTopic(*chat.ProjectID)
+	}
+	return realtime.ChatTopic(chat.ID)
+}
+
+// publishChatEvent pushes a persisted chat change to connected WebSocket clients.
+func publishChatEvent(chat models.Chat, eventType string, data interface{}) {
+	realtime.Default.Publish(chatTopic(chat), realtime.Event{Type: eventType, Data: data})
+}
+
+var messageList = listSpec{
+	Table: "messages",
+	Filters: map[string]listFilter{
+		"userId":      {Column: "user_id"},
+		"createdFrom": {Column: "created_at", Kind: filterFrom},
+		"createdTo":   {Column: "created_at", Kind: filterTo},
+	},
+	Sorts: map[string]string{
+		"createdAt": "created_at",
+	},
+	// Newest first, so the first page is the latest messages and further
+	// pages scroll back in time
+	DefaultSort: "-createdAt",
+}
+
+// chatMessages answers with a page of the chat's messages.
+func chatMessages(c *gin.Context, chatID string) {
+	var messages []models.Message
+	page, ok := listPage(c, database.DB.Preload("User").Where("chat_id = ?", chatID), messageList, &messages)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
 // GET /:projectId/messages
 func GetProjectMessages(c *gin.Context) {
 	projectID := c.Param("projectId")
@@ -28,7 +72,7 @@ func GetProjectMessages(c *gin.Context) {
 	// For now, mirroring previous logic but with stricter ID checks if needed.
 
 	var chat models.Chat
-	err := database.DB.Preload("Messages.User").First(&chat, "project_id = ?", projectID).Error
+	err := database.DB.First(&chat, "project_id = ?", projectID).Error
 
 	if err != nil {
 		// Create if not exists
@@ -43,7 +87,7 @@ func GetProjectMessages(c *gin.Context) {
 		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": chat.Messages})
+	chatMessages(c, chat.ID)
 }
 
 // POST /:projectId/messages
@@ -88,6 +132,7 @@ func SendProjectMessage(c *gin.Context) {
 
 	// Re-fetch to include user
 	database.DB.Preload("User").First(&message, "id = ?", message.ID)
+	publishChatEvent(chat, "message.created", message)
 	c.JSON(http.StatusCreated, gin.H{"data": message})
 }
 
@@ -152,6 +197,12 @@ func CreateOrGetDirectChat(c *gin.Context) {
 	}
 
 	tx.Commit()
+
+	// Let connected clients of both users follow the new conversation
+	for _, participant := range []string{currentUserID, req.TargetUserID} {
+		realtime.Default.Publish(realtime.UserTopic(participant), realtime.Event{Type: "chat.joined", Data: gin.H{"chatId": chatID}})
+	}
+
 	c.JSON(http.StatusOK, gin.H{"data": chat})
 }
 
@@ -195,9 +246,7 @@ func GetConversationMessages(c *gin.Context) {
 		return
 	}
 
-	var messages []models.Message
-	database.DB.Preload("User").Where("chat_id = ?", chatID).Order("created_at asc").Find(&messages)
-	c.JSON(http.StatusOK, gin.H{"data": messages})
+	chatMessages(c, chatID)
 }
 
 // POST /conversation/:chatId/messages
@@ -240,9 +289,13 @@ func SendConversationMessage(c *gin.Context) {
 		return
 	}
 
+	database.DB.Preload("User").First(&message, "id = ?", message.ID)
+
 	// Notifications for DM
 	var chat models.Chat
 	if database.DB.Preload("Participants").First(&chat, "id = ?", chatID).Error == nil {
+		publishChatEvent(chat, "message.created", message)
+
 		if chat.Type == "DIRECT" {
 			// Find sender name for message
 			var sender models.User
@@ -250,20 +303,70 @@ func SendConversationMessage(c *gin.Context) {
 
 			for _, p := range chat.Participants {
 				if p.UserID != currentUserID {
-					notif := models.Notification{
-						ID:        utils.GenerateCUID(),
-						UserID:    p.UserID,
-						Title:     "Nuevo Mensaje Directo",
-						Message:   sender.Name + " te ha enviado un mensaje",
-						Type:      "MESSAGE",
-						CreatedAt: time.Now(),
-					}
-					database.DB.Create(&notif)
+					notify(p.UserID, "Nuevo Mensaje Directo", sender.Name+" te ha enviado un mensaje", "MESSAGE")
 				}
 			}
 		}
 	}
 
-	database.DB.Preload("User").First(&message, "id = ?", message.ID)
 	c.JSON(http.StatusCreated, gin.H{"data": message})
 }
+
+// PUT /messages/:messageId
+func UpdateMessage(c *gin.Context) {
+	messageID := c.Param("messageId")
+	userID, _ := c.Get("userID")
+
+	var req UpdateMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var message models.Message
+	if err := database.DB.Preload("Chat").First(&message, "id = ?", messageID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Mensaje no encontrado"})
+		return
+	}
+
+	if message.UserID != userID.(string) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Solo el autor puede editar el mensaje"})
+		return
+	}
+
+	message.Content = req.Content
+	if err := database.DB.Save(&message).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al editar mensaje"})
+		return
+	}
+
+	chat := message.Chat
+	database.DB.Preload("User").First(&message, "id = ?", message.ID)
+	publishChatEvent(chat, "message.updated", message)
+	c.JSON(http.StatusOK, gin.H{"data": message})
+}
+
+// DELETE /messages/:messageId
+func DeleteMessage(c *gin.Context) {
+	messageID := c.Param("messageId")
+	userID, _ := c.Get("userID")
+
+	var message models.Message
+	if err := database.DB.Preload("Chat").First(&message, "id = ?", messageID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Mensaje no encontrado"})
+		return
+	}
+
+	if message.UserID != userID.(string) && !middleware.IsAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Solo el autor puede eliminar el mensaje"})
+		return
+	}
+
+	if err := database.DB.Delete(&models.Message{}, "id = ?", messageID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar mensaje"})
+		return
+	}
+
+	publishChatEvent(message.Chat, "message.deleted", gin.H{"id": message.ID, "chatId": message.ChatID})
+	c.JSON(http.StatusOK, gin.H{"message": "Mensaje eliminado"})
+}
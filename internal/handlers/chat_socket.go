@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"Wrk_Api/internal/database"
+	"Wrk_Api/internal/models"
+	"Wrk_Api/internal/realtime"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	socketWriteWait  = 10 * time.Second
+	socketPongWait   = 60 * time.Second
+	socketPingPeriod = (socketPongWait * 9) / 10
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// CORS is already open to every origin and the JWT authenticates the client
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// chatTopicsForUser lists the topics of every chat the user takes part in.
+func chatTopicsForUser(userID string) []string {
+	topics := []string{realtime.UserTopic(userID)}
+
+	var chatIDs []string
+	database.DB.Model(&models.ChatParticipant{}).Where("user_id = ?", userID).Pluck("chat_id", &chatIDs)
+	for _, id := range chatIDs {
+		topics = append(topics, realtime.ChatTopic(id))
+	}
+
+	var projectIDs []string
+	database.DB.Model(&models.ProjectMember{}).Where("user_id = ?", userID).Pluck("project_id", &projectIDs)
+	var owned []string
+	database.DB.Model(&models.Project{}).Where("owner_id = ?", userID).Pluck("id", &owned)
+	for _, id := range append(projectIDs, owned...) {
+		topics = append(topics, realtime.ProjectChatTopic(id))
+	}
+	return topics
+}
+
+// GET /chat/ws
+// Pushes message.created, message.updated and message.deleted events for the
+// project and direct chats of the authenticated user.
+func ChatSocket(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader already replied with an HTTP error
+		return
+	}
+	defer conn.Close()
+
+	topics := append(chatTopicsForUser(userID.(string)), realtime.SessionTopic(c.GetString("sessionID")))
+	sub := realtime.Default.Subscribe(topics...)
+	defer sub.Close()
+
+	// Reader: the client only sends control frames, but reading is required to process them
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		conn.SetReadLimit(512)
+		conn.SetReadDeadline(time.Now().Add(socketPongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(socketPongWait))
+		})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(socketPingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event := <-sub.C:
+			// Membership changes adjust what this connection follows
+			if data, ok := event.Data.(gin.H); ok {
+				switch event.Type {
+				case "chat.joined":
+					sub.Add(realtime.ChatTopic(data["chatId"].(string)))
+				case "project.joined":
+					sub.Add(realtime.ProjectChatTopic(data["projectId"].(string)))
+				case "project.left":
+					sub.Remove(realtime.ProjectChatTopic(data["projectId"].(string)))
+				}
+			}
+
+			conn.SetWriteDeadline(time.Now().Add(socketWriteWait))
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(socketWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-sub.Done:
+			// The session was revoked
+			conn.SetWriteDeadline(time.Now().Add(socketWriteWait))
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "session revoked"))
+			return
+		case <-done:
+			return
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"Wrk_Api/internal/database"
+	"Wrk_Api/internal/middleware"
+	"Wrk_Api/internal/models"
+	"Wrk_Api/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CreateCommentRequest struct {
+	Content  string  `json:"content" binding:"required"` // markdown
+	ParentID *string `json:"parentId"`
+}
+
+type UpdateCommentRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
+// projectPeople returns the owner and members of a project, the people who can be mentioned.
+func projectPeople(projectID string) []models.User {
+	var project models.Project
+	database.DB.Preload("Owner").First(&project, "id = ?", projectID)
+	var members []models.ProjectMember
+	database.DB.Preload("User").Where("project_id = ?", projectID).Find(&members)
+
+	people := []models.User{}
+	if project.Owner.ID != "" {
+		people = append(people, project.Owner)
+	}
+	for _, m := range members {
+		if m.UserID != project.OwnerID && m.User.ID != "" {
+			people = append(people, m.User)
+		}
+	}
+	return people
+}
+
+// resolveMentions maps the @handles of a comment to project people.
+// Handles matching more than one person are ignored.
+func resolveMentions(projectID, content string) []models.User {
+	handles := utils.ParseMentions(content)
+	if len(handles) == 0 {
+		return nil
+	}
+
+	byHandle := map[string][]models.User{}
+	for _, user := range projectPeople(projectID) {
+		for _, handle := range utils.MentionHandles(user.Name, user.Email) {
+			if len(byHandle[handle]) == 0 || byHandle[handle][0].ID != user.ID {
+				byHandle[handle] = append(byHandle[handle], user)
+			}
+		}
+	}
+
+	mentioned := []models.User{}
+	seen := map[string]bool{}
+	for _, handle := range handles {
+		if users := byHandle[handle]; len(users) == 1 && !seen[users[0].ID] {
+			seen[users[0].ID] = true
+			mentioned = append(mentioned, users[0])
+		}
+	}
+	return mentioned
+}
+
+// notifyMentions notifies people mentioned in content but not in previous.
+func notifyMentions(comment models.Comment, previous string, authorName, entityTitle string) {
+	already := map[string]bool{comment.AuthorID: true}
+	for _, user := range resolveMentions(comment.ProjectID, previous) {
+		already[user.ID] = true
+	}
+	for _, user := range resolveMentions(comment.ProjectID, comment.Content) {
+		if !already[user.ID] {
+			notify(user.ID, "Te mencionaron en un comentario", authorName+" te mencionó en \""+entityTitle+"\"", "MENTION")
+		}
+	}
+}
+
+func listComments(c *gin.Context, entityType string) {
+	var comments []models.Comment
+	database.DB.Preload("Author").
+		Preload("Replies", func(db *gorm.DB) *gorm.DB { return db.Order("created_at asc") }).
+		Preload("Replies.Author").
+		Where("entity_type = ? AND entity_id = ? AND parent_id IS NULL", entityType, c.Param("id")).
+		Order("created_at asc").Find(&comments)
+
+	for i := range comments {
+		hideDeleted(&comments[i])
+		for j := range comments[i].Replies {
+			hideDeleted(&comments[i].Replies[j])
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": comments})
+}
+
+// hideDeleted blanks a deleted comment that stays only to hold its replies.
+func hideDeleted(comment *models.Comment) {
+	if comment.Deleted {
+		comment.Content = ""
+		comment.Author = models.User{}
+	}
+}
+
+func createComment(c *gin.Context, entityType string) {
+	var req CreateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if strings.TrimSpace(req.Content) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El comentario no puede estar vacío"})
+		return
+	}
+
+	nodes := loadEntityNodes(database.DB, entityType, []string{c.Param("id")})
+	if len(nodes) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Elemento no encontrado"})
+		return
+	}
+	entity := nodes[0]
+
+	comment := models.Comment{
+		ID:         utils.GenerateCUID(),
+		ProjectID:  entity.ProjectID,
+		EntityType: entityType,
+		EntityID:   entity.ID,
+		AuthorID:   c.GetString("userID"),
+		Content:    req.Content,
+	}
+	if req.ParentID != nil && *req.ParentID != "" {
+		var parent models.Comment
+		if err := database.DB.First(&parent, "id = ? AND entity_type = ? AND entity_id = ?", *req.ParentID, entityType, entity.ID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El comentario padre no pertenece a este elemento"})
+			return
+		}
+		// Threads are one level deep: replying to a reply joins its thread
+		rootID := parent.ID
+		if parent.ParentID != nil {
+			rootID = *parent.ParentID
+		}
+		comment.ParentID = &rootID
+	}
+
+	if result := database.DB.Create(&comment); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear el comentario"})
+		return
+	}
+	database.DB.Preload("Author").First(&comment, "id = ?", comment.ID)
+	notifyMentions(comment, "", comment.Author.Name, entity.Title)
+
+	c.JSON(http.StatusCreated, gin.H{"data": comment, "mentions": resolveMentions(comment.ProjectID, comment.Content)})
+}
+
+// PUT /api/comments/:id
+// Only the author edits a comment; newly mentioned people are notified.
+func UpdateComment(c *gin.Context) {
+	var req UpdateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if strings.TrimSpace(req.Content) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El comentario no puede estar vacío"})
+		return
+	}
+
+	var comment models.Comment
+	if err := database.DB.Preload("Author").First(&comment, "id = ? AND deleted = ?", c.Param("id"), false).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comentario no encontrado"})
+		return
+	}
+	if comment.AuthorID != c.GetString("userID") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Solo el autor puede editar el comentario"})
+		return
+	}
+
+	previous := comment.Content
+	now := time.Now()
+	comment.Content = req.Content
+	comment.EditedAt = &now
+	if result := database.DB.Model(&comment).Updates(map[string]interface{}{"content": comment.Content, "edited_at": now}); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar el comentario"})
+		return
+	}
+
+	if nodes := loadEntityNodes(database.DB, comment.EntityType, []string{comment.EntityID}); len(nodes) > 0 {
+		notifyMentions(comment, previous, comment.Author.Name, nodes[0].Title)
+	}
+	c.JSON(http.StatusOK, gin.H{"data": comment})
+}
+
+// DELETE /api/comments/:id
+// Authors delete their comments and project managers moderate any. A comment
+// with replies is blanked instead so the thread survives.
+func DeleteComment(c *gin.Context) {
+	var comment models.Comment
+	if err := database.DB.First(&comment, "id = ? AND deleted = ?", c.Param("id"), false).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comentario no encontrado"})
+		return
+	}
+	if comment.AuthorID != c.GetString("userID") && !middleware.Can(c, comment.ProjectID, middleware.PermProjectManage) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Solo el autor puede eliminar el comentario"})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var replies int64
+		tx.Model(&models.Comment{}).Where("parent_id = ?", comment.ID).Count(&replies)
+		if replies > 0 {
+			return tx.Model(&comment).Updates(map[string]interface{}{"deleted": true, "content": ""}).Error
+		}
+		if err := tx.Delete(&comment).Error; err != nil {
+			return err
+		}
+		if comment.ParentID == nil {
+			return nil
+		}
+
+		// A blanked parent whose last reply is gone has nothing left to show.
+		// MySQL can't delete from a table its subquery reads, so look it up first.
+		var orphans []string
+		tx.Model(&models.Comment{}).
+			Where("id = ? AND deleted = ? AND NOT EXISTS (SELECT 1 FROM comments r WHERE r.parent_id = comments.id)", *comment.ParentID, true).
+			Pluck("id", &orphans)
+		if len(orphans) == 0 {
+			return nil
+		}
+		return tx.Where("id IN ?", orphans).Delete(&models.Comment{}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar el comentario"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Comentario eliminado"})
+}
+
+// GET /api/tasks/:id/comments
+func GetTaskComments(c *gin.Context) { listComments(c, models.EntityTask) }
+
+// POST /api/tasks/:id/comments
+func CreateTaskComment(c *gin.Context) { createComment(c, models.EntityTask) }
+
+// GET /api/user-stories/:id/comments
+func GetUserStoryComments(c *gin.Context) { listComments(c, models.EntityUserStory) }
+
+// POST /api/user-stories/:id/comments
+func CreateUserStoryComment(c *gin.Context) { createComment(c, models.EntityUserStory) }
@@ -0,0 +1,375 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"Wrk_Api/internal/database"
+	"Wrk_Api/internal/models"
+	"Wrk_Api/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AddDependencyRequest struct {
+	BlockerID string `json:"blockerId" binding:"required"` // the task or story that must be done first
+}
+
+// entityTables maps an entity type to the table holding its rows.
+var entityTables = map[string]string{
+	models.EntityTask:      "tasks",
+	models.EntityUserStory: "user_stories",
+}
+
+var doneStatusList = []string{"DONE", "COMPLETED"}
+
+// entityNode is the part of a task or story the dependency and comment handlers need.
+type entityNode struct {
+	ID        string `json:"id"`
+	ProjectID string `json:"projectId"`
+	Title     string `json:"title"`
+	Status    string `json:"status"`
+	Blocked   bool   `json:"blocked"`
+}
+
+func loadEntityNodes(tx *gorm.DB, entityType string, ids []string) []entityNode {
+	nodes := []entityNode{}
+	if len(ids) > 0 {
+		tx.Table(entityTables[entityType]).Select("id, project_id, title, status, blocked").Where("id IN ?", ids).Scan(&nodes)
+	}
+	return nodes
+}
+
+// refreshBlocked recomputes the Blocked flag of the given tasks or stories.
+func refreshBlocked(tx *gorm.DB, entityType string, ids []string) error {
+	table := entityTables[entityType]
+	for _, id := range ids {
+		var pending int64
+		tx.Table("dependencies").
+			Joins("JOIN "+table+" ON "+table+".id = dependencies.blocker_id").
+			Where("dependencies.entity_type = ? AND dependencies.blocked_id = ? AND "+table+".status NOT IN ?", entityType, id, doneStatusList).
+			Count(&pending)
+		if err := tx.Table(table).Where("id = ?", id).UpdateColumn("blocked", pending > 0).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refreshDependents updates everything blocked by id after its status changed.
+func refreshDependents(tx *gorm.DB, entityType, id string) error {
+	var blocked []string
+	tx.Model(&models.Dependency{}).Where("entity_type = ? AND blocker_id = ?", entityType, id).Pluck("blocked_id", &blocked)
+	return refreshBlocked(tx, entityType, blocked)
+}
+
+// removeDependencies drops every link and comment of a deleted task or story.
+func removeDependencies(tx *gorm.DB, entityType, id string) error {
+	if err := tx.Where("entity_type = ? AND entity_id = ?", entityType, id).Delete(&models.Comment{}).Error; err != nil {
+		return err
+	}
+	var blocked []string
+	tx.Model(&models.Dependency{}).Where("entity_type = ? AND blocker_id = ?", entityType, id).Pluck("blocked_id", &blocked)
+	if err := tx.Where("entity_type = ? AND (blocker_id = ? OR blocked_id = ?)", entityType, id, id).Delete(&models.Dependency{}).Error; err != nil {
+		return err
+	}
+	return refreshBlocked(tx, entityType, blocked)
+}
+
+// createsCycle reports whether blockerID already depends, directly or not, on blockedID.
+func createsCycle(tx *gorm.DB, entityType, projectID, blockerID, blockedID string) bool {
+	var links []models.Dependency
+	tx.Where("entity_type = ? AND project_id = ?", entityType, projectID).Find(&links)
+	next := map[string][]string{}
+	for _, link := range links {
+		next[link.BlockerID] = append(next[link.BlockerID], link.BlockedID)
+	}
+
+	seen := map[string]bool{blockedID: true}
+	queue := []string{blockedID}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == blockerID {
+			return true
+		}
+		for _, id := range next[current] {
+			if !seen[id] {
+				seen[id] = true
+				queue = append(queue, id)
+			}
+		}
+	}
+	return false
+}
+
+func addDependency(c *gin.Context, entityType string) {
+	var req AddDependencyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	blockedID := c.Param("id")
+	if req.BlockerID == blockedID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Un elemento no puede depender de sí mismo"})
+		return
+	}
+
+	nodes := loadEntityNodes(database.DB, entityType, []string{blockedID, req.BlockerID})
+	if len(nodes) != 2 || nodes[0].ProjectID != nodes[1].ProjectID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ambos elementos deben existir en el mismo proyecto"})
+		return
+	}
+	projectID := nodes[0].ProjectID
+
+	dependency := models.Dependency{
+		ID:         utils.GenerateCUID(),
+		ProjectID:  projectID,
+		EntityType: entityType,
+		BlockerID:  req.BlockerID,
+		BlockedID:  blockedID,
+	}
+	if userID := c.GetString("userID"); userID != "" {
+		dependency.CreatedByID = &userID
+	}
+
+	status := http.StatusCreated
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		tx.Model(&models.Dependency{}).Where("entity_type = ? AND blocker_id = ? AND blocked_id = ?", entityType, req.BlockerID, blockedID).Count(&existing)
+		if existing > 0 {
+			status = http.StatusConflict
+			return nil
+		}
+		if createsCycle(tx, entityType, projectID, req.BlockerID, blockedID) {
+			status = http.StatusUnprocessableEntity
+			return nil
+		}
+		if err := tx.Create(&dependency).Error; err != nil {
+			return err
+		}
+		return refreshBlocked(tx, entityType, []string{blockedID})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear la dependencia"})
+		return
+	}
+	switch status {
+	case http.StatusConflict:
+		c.JSON(status, gin.H{"error": "La dependencia ya existe"})
+		return
+	case http.StatusUnprocessableEntity:
+		c.JSON(status, gin.H{"error": "La dependencia crearía un ciclo"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": dependency})
+}
+
+func removeDependency(c *gin.Context, entityType string) {
+	blockedID := c.Param("id")
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("entity_type = ? AND blocker_id = ? AND blocked_id = ?", entityType, c.Param("blockerId"), blockedID).Delete(&models.Dependency{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return refreshBlocked(tx, entityType, []string{blockedID})
+	})
+	if err == gorm.ErrRecordNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dependencia no encontrada"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar la dependencia"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Dependencia eliminada"})
+}
+
+func getDependencies(c *gin.Context, entityType string) {
+	id := c.Param("id")
+	var blockerIDs, blockedIDs []string
+	database.DB.Model(&models.Dependency{}).Where("entity_type = ? AND blocked_id = ?", entityType, id).Pluck("blocker_id", &blockerIDs)
+	database.DB.Model(&models.Dependency{}).Where("entity_type = ? AND blocker_id = ?", entityType, id).Pluck("blocked_id", &blockedIDs)
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"blockedBy": loadEntityNodes(database.DB, entityType, blockerIDs),
+		"blocks":    loadEntityNodes(database.DB, entityType, blockedIDs),
+	}})
+}
+
+// POST /api/tasks/:id/blocked-by
+func AddTaskDependency(c *gin.Context) { addDependency(c, models.EntityTask) }
+
+// DELETE /api/tasks/:id/blocked-by/:blockerId
+func RemoveTaskDependency(c *gin.Context) { removeDependency(c, models.EntityTask) }
+
+// GET /api/tasks/:id/dependencies
+func GetTaskDependencies(c *gin.Context) { getDependencies(c, models.EntityTask) }
+
+// POST /api/user-stories/:id/blocked-by
+func AddUserStoryDependency(c *gin.Context) { addDependency(c, models.EntityUserStory) }
+
+// DELETE /api/user-stories/:id/blocked-by/:blockerId
+func RemoveUserStoryDependency(c *gin.Context) { removeDependency(c, models.EntityUserStory) }
+
+// GET /api/user-stories/:id/dependencies
+func GetUserStoryDependencies(c *gin.Context) { getDependencies(c, models.EntityUserStory) }
+
+// GET /api/sprints/:id/dependencies
+// Task dependency graph of the sprint with its critical path. Deadlines stand in
+// for durations: a task runs from the moment its last predecessor is due (or
+// the sprint start) until its own deadline.
+func GetSprintDependencyGraph(c *gin.Context) {
+	var sprint models.Sprint
+	if err := database.DB.First(&sprint, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sprint no encontrado"})
+		return
+	}
+
+	var sprintTaskIDs []string
+	database.DB.Model(&models.Task{}).Where("sprint_id = ?", sprint.ID).Pluck("id", &sprintTaskIDs)
+	var links []models.Dependency
+	if len(sprintTaskIDs) > 0 {
+		database.DB.Where("entity_type = ? AND (blocker_id IN ? OR blocked_id IN ?)", models.EntityTask, sprintTaskIDs, sprintTaskIDs).Find(&links)
+	}
+
+	// Tasks of other sprints linked to this one are shown as external nodes
+	ids := append([]string{}, sprintTaskIDs...)
+	for _, link := range links {
+		ids = append(ids, link.BlockerID, link.BlockedID)
+	}
+	var tasks []models.Task
+	if len(ids) > 0 {
+		database.DB.Where("id IN ?", ids).Order("created_at asc").Find(&tasks)
+	}
+
+	type Node struct {
+		ID             string     `json:"id"`
+		Title          string     `json:"title"`
+		Status         string     `json:"status"`
+		Blocked        bool       `json:"blocked"`
+		Deadline       *time.Time `json:"deadline"`
+		External       bool       `json:"external"`
+		EarliestFinish *time.Time `json:"earliestFinish"`
+		Critical       bool       `json:"critical"`
+	}
+	type Edge struct {
+		From string `json:"from"` // blocker
+		To   string `json:"to"`   // blocked
+	}
+	type Conflict struct {
+		TaskID    string `json:"taskId"`
+		BlockerID string `json:"blockerId"`
+		Message   string `json:"message"`
+	}
+
+	nodes := []*Node{}
+	byID := map[string]*Node{}
+	for _, task := range tasks {
+		node := &Node{
+			ID:       task.ID,
+			Title:    task.Title,
+			Status:   task.Status,
+			Blocked:  task.Blocked,
+			Deadline: task.Deadline,
+			External: task.SprintID == nil || *task.SprintID != sprint.ID,
+		}
+		nodes = append(nodes, node)
+		byID[task.ID] = node
+	}
+
+	edges := []Edge{}
+	predecessors := map[string][]string{}
+	successors := map[string][]string{}
+	indegree := map[string]int{}
+	for _, link := range links {
+		edges = append(edges, Edge{From: link.BlockerID, To: link.BlockedID})
+		predecessors[link.BlockedID] = append(predecessors[link.BlockedID], link.BlockerID)
+		successors[link.BlockerID] = append(successors[link.BlockerID], link.BlockedID)
+		indegree[link.BlockedID]++
+	}
+
+	// Work in topological order; the graph is acyclic because cycles are rejected on insert
+	order := []string{}
+	queue := []string{}
+	for _, node := range nodes {
+		if indegree[node.ID] == 0 {
+			queue = append(queue, node.ID)
+		}
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		order = append(order, id)
+		for _, next := range successors[id] {
+			if indegree[next]--; indegree[next] == 0 {
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	start := sprint.StartDate
+	if start.IsZero() {
+		start = time.Now()
+	}
+	finish := map[string]time.Time{}
+	via := map[string]string{} // predecessor that determines when a task can start
+	conflicts := []Conflict{}
+	for _, id := range order {
+		node := byID[id]
+		ready := start
+		for _, prev := range predecessors[id] {
+			if finish[prev].After(ready) || (finish[prev].Equal(ready) && via[id] == "") {
+				ready = finish[prev]
+				via[id] = prev
+			}
+		}
+		end := ready
+		if node.Deadline != nil && node.Deadline.After(ready) {
+			end = *node.Deadline
+		} else if node.Deadline != nil && via[id] != "" && node.Deadline.Before(ready) {
+			conflicts = append(conflicts, Conflict{
+				TaskID:    id,
+				BlockerID: via[id],
+				Message:   "La fecha límite es anterior a la de una tarea de la que depende",
+			})
+		}
+		finish[id] = end
+		endCopy := end
+		node.EarliestFinish = &endCopy
+	}
+
+	// The critical path ends at the task finishing last and walks back through
+	// the predecessors that pushed each start date
+	criticalPath := []string{}
+	last := ""
+	for _, id := range order {
+		// On ties the later task in the chain wins, so the path is as long as possible
+		if last == "" || !finish[id].Before(finish[last]) {
+			last = id
+		}
+	}
+	for id := last; id != ""; id = via[id] {
+		criticalPath = append([]string{id}, criticalPath...)
+		byID[id].Critical = true
+	}
+
+	var projectedFinish *time.Time
+	if last != "" {
+		end := finish[last]
+		projectedFinish = &end
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"sprintId":        sprint.ID,
+		"nodes":           nodes,
+		"edges":           edges,
+		"criticalPath":    criticalPath,
+		"projectedFinish": projectedFinish,
+		"conflicts":       conflicts,
+	}})
+}
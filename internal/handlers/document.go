@@ -1,15 +1,26 @@
 package handlers
 
 import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"Wrk_Api/internal/database"
 	"Wrk_Api/internal/models"
+	"Wrk_Api/internal/storage"
 	"Wrk_Api/internal/utils"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UploadDocumentRequest struct {
@@ -17,18 +28,232 @@ type UploadDocumentRequest struct {
 	Name      string `form:"name" binding:"required"`
 }
 
+// rootID returns the ID shared by every version of a document.
+func rootID(doc models.Document) string {
+	if doc.ParentID != nil {
+		return *doc.ParentID
+	}
+	return doc.ID
+}
+
+// sniffContentType detects the MIME type from the first bytes, falling back
+// to the file extension when the content is not recognized.
+func sniffContentType(head []byte, filename string) string {
+	contentType := http.DetectContentType(head)
+	if contentType == "application/octet-stream" {
+		if byExt := mime.TypeByExtension(filepath.Ext(filename)); byExt != "" {
+			contentType = byExt
+		}
+	}
+	return contentType
+}
+
+type UpdateStorageLimitsRequest struct {
+	StorageQuota *int64  `json:"storageQuota"`
+	MaxFileSize  *int64  `json:"maxFileSize"`
+	AllowedTypes *string `json:"allowedTypes"`
+}
+
+// Upload error codes returned alongside the message
+const (
+	ErrCodeFileTooLarge    = "FILE_TOO_LARGE"
+	ErrCodeQuotaExceeded   = "QUOTA_EXCEEDED"
+	ErrCodeUnsupportedType = "UNSUPPORTED_MEDIA_TYPE"
+)
+
+type storageLimits struct {
+	Quota        int64    `json:"quota"`
+	MaxFileSize  int64    `json:"maxFileSize"`
+	AllowedTypes []string `json:"allowedTypes"`
+}
+
+// projectLimits resolves the project's limits, falling back to the server defaults.
+func projectLimits(project models.Project) storageLimits {
+	limits := storageLimits{
+		Quota:        storage.DefaultQuota,
+		MaxFileSize:  storage.DefaultMaxFileSize,
+		AllowedTypes: storage.DefaultAllowedTypes,
+	}
+	if project.StorageQuota != nil {
+		limits.Quota = *project.StorageQuota
+	}
+	if project.MaxFileSize != nil {
+		limits.MaxFileSize = *project.MaxFileSize
+	}
+	if project.AllowedTypes != nil {
+		limits.AllowedTypes = storage.ParseTypes(*project.AllowedTypes)
+	}
+	if limits.AllowedTypes == nil {
+		limits.AllowedTypes = []string{}
+	}
+	return limits
+}
+
+// projectStorageUsage sums the size of every stored version in bytes.
+// Documents from before sizes were kept in bytes only have Size in KB.
+func projectStorageUsage(db *gorm.DB, projectID string) int64 {
+	var used int64
+	db.Model(&models.Document{}).
+		Select("COALESCE(SUM(CASE WHEN size_bytes > 0 THEN size_bytes ELSE COALESCE(size, 0) * 1024 END), 0)").
+		Where("project_id = ?", projectID).Scan(&used)
+	return used
+}
+
+// GET /api/projects/:id/storage
+func GetProjectStorage(c *gin.Context) {
+	var project models.Project
+	if err := database.DB.First(&project, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Proyecto no encontrado"})
+		return
+	}
+
+	var documents int64
+	database.DB.Model(&models.Document{}).Where("project_id = ?", project.ID).Count(&documents)
+
+	limits := projectLimits(project)
+	used := projectStorageUsage(database.DB, project.ID)
+	var available *int64
+	if limits.Quota > 0 {
+		remaining := limits.Quota - used
+		if remaining < 0 {
+			remaining = 0
+		}
+		available = &remaining
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"used":      used,
+		"available": available,
+		"documents": documents,
+		"limits":    limits,
+	}})
+}
+
+// PUT /api/projects/:id/storage
+// Null fields are left unchanged; 0 removes a size limit and an empty type list allows every type.
+func UpdateProjectStorage(c *gin.Context) {
+	var req UpdateStorageLimitsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if (req.StorageQuota != nil && *req.StorageQuota < 0) || (req.MaxFileSize != nil && *req.MaxFileSize < 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Los límites no pueden ser negativos"})
+		return
+	}
+
+	var project models.Project
+	if err := database.DB.First(&project, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Proyecto no encontrado"})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.StorageQuota != nil {
+		updates["storage_quota"] = *req.StorageQuota
+	}
+	if req.MaxFileSize != nil {
+		updates["max_file_size"] = *req.MaxFileSize
+	}
+	if req.AllowedTypes != nil {
+		updates["allowed_types"] = strings.Join(storage.ParseTypes(*req.AllowedTypes), ",")
+	}
+	if len(updates) > 0 {
+		database.DB.Model(&project).Updates(updates)
+	}
+
+	database.DB.First(&project, "id = ?", project.ID)
+	c.JSON(http.StatusOK, gin.H{"data": projectLimits(project)})
+}
+
+var documentList = listSpec{
+	Table: "documents",
+	Filters: map[string]listFilter{
+		"type":         {Column: "type"},
+		"uploadedById": {Column: "uploaded_by_id"},
+		"uploadedFrom": {Column: "uploaded_at", Kind: filterFrom},
+		"uploadedTo":   {Column: "uploaded_at", Kind: filterTo},
+	},
+	Sorts: map[string]string{
+		"name":       "name",
+		"uploadedAt": "uploaded_at",
+		"size":       "size_bytes",
+	},
+	DefaultSort: "name",
+}
+
+// GET /api/documents/:id (project ID)
+// Lists the latest version of each document.
 func GetProjectDocuments(c *gin.Context) {
-	projectID := c.Param("projectId")
 	var docs []models.Document
-	// Get latest versions (where parent_id is null usually, or handle versioning logic)
-	// For simplicity, returning all
-	if result := database.DB.Where("project_id = ?", projectID).Find(&docs); result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener documentos"})
+	query := database.DB.Where("project_id = ?", c.Param("id")).
+		Where("version = (SELECT MAX(v.version) FROM documents v WHERE COALESCE(v.parent_id, v.id) = COALESCE(documents.parent_id, documents.id))")
+	page, ok := listPage(c, query, documentList, &docs)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// GET /api/documents/:id/versions
+func GetDocumentVersions(c *gin.Context) {
+	var doc models.Document
+	if err := database.DB.First(&doc, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Documento no encontrado"})
+		return
+	}
+
+	root := rootID(doc)
+	var versions []models.Document
+	database.DB.Where("id = ? OR parent_id = ?", root, root).Order("version desc").Find(&versions)
+	c.JSON(http.StatusOK, gin.H{"data": versions})
+}
+
+// multipartOverhead leaves room for the form fields and part headers around the file.
+const multipartOverhead = 1 << 20
+
+// uploadAttempts bounds how often an upload retries after losing a version number to another.
+const uploadAttempts = 3
+
+// largestFileSize is the biggest file any project accepts, or zero when some
+// project has no limit.
+func largestFileSize() int64 {
+	var unlimited int64
+	database.DB.Model(&models.Project{}).Where("max_file_size = ?", 0).Count(&unlimited)
+	if storage.DefaultMaxFileSize == 0 || unlimited > 0 {
+		return 0
+	}
+	largest := storage.DefaultMaxFileSize
+	var override int64
+	database.DB.Model(&models.Project{}).Select("COALESCE(MAX(max_file_size), 0)").Scan(&override)
+	if override > largest {
+		largest = override
+	}
+	return largest
+}
+
+// LimitUploadSize caps the upload body before the form is parsed to find its
+// project. The project isn't known yet, so the cap is the largest file any
+// project accepts; UploadDocument then applies the project's own limit.
+func LimitUploadSize(c *gin.Context) {
+	limit := largestFileSize()
+	if limit == 0 {
+		c.Next()
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit+multipartOverhead)
+	var tooLarge *http.MaxBytesError
+	if _, err := c.MultipartForm(); errors.As(err, &tooLarge) {
+		c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": fmt.Sprintf("El archivo supera el tamaño máximo de %d bytes", limit),
+			"code":  ErrCodeFileTooLarge,
+		})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"data": docs})
+	c.Next()
 }
 
+// POST /api/documents
 func UploadDocument(c *gin.Context) {
 	// Multipart form
 	file, err := c.FormFile("file")
@@ -42,36 +267,201 @@ func UploadDocument(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Project ID required"})
 		return
 	}
-	
-	// Mock upload (save to /tmp or just DB record)
-	// In real app: Save to S3 or disk
-	url := fmt.Sprintf("/uploads/%s_%s", utils.GenerateCUID(), file.Filename)
-	size := int(file.Size / 1024)
+
+	var project models.Project
+	if err := database.DB.First(&project, "id = ?", projectID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Proyecto no encontrado"})
+		return
+	}
+	limits := projectLimits(project)
+	if limits.MaxFileSize > 0 && file.Size > limits.MaxFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": fmt.Sprintf("El archivo supera el tamaño máximo de %d bytes", limits.MaxFileSize),
+			"code":  ErrCodeFileTooLarge,
+		})
+		return
+	}
+	if limits.Quota > 0 && projectStorageUsage(database.DB, projectID)+file.Size > limits.Quota {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": "El proyecto no tiene espacio suficiente para este archivo",
+			"code":  ErrCodeQuotaExceeded,
+		})
+		return
+	}
+
+	name := c.PostForm("name")
+	if name == "" {
+		name = filepath.Base(file.Filename)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No se pudo leer el archivo"})
+		return
+	}
+	defer src.Close()
+
+	reader := bufio.NewReaderSize(src, 512)
+	head, _ := reader.Peek(512)
+	contentType := sniffContentType(head, name)
+	if !storage.TypeAllowed(contentType, limits.AllowedTypes) {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"error": fmt.Sprintf("El tipo de archivo %s no está permitido en este proyecto", contentType),
+			"code":  ErrCodeUnsupportedType,
+		})
+		return
+	}
 
 	doc := models.Document{
 		ID:         utils.GenerateCUID(),
 		ProjectID:  projectID,
-		Name:       file.Filename,
-		URL:        url,
-		Type:       "FILE", // Detect MIME type in real app
-		Size:       &size,
+		Name:       name,
+		Type:       contentType,
+		SizeBytes:  file.Size,
 		Version:    1,
 		UploadedAt: time.Now(),
 	}
+	doc.URL = "/api/documents/" + doc.ID + "/download"
+	doc.StorageKey = projectID + "/" + doc.ID
+	size := int(file.Size / 1024)
+	doc.Size = &size
+	if userID := c.GetString("userID"); userID != "" {
+		doc.UploadedByID = &userID
+	}
 
-	if result := database.DB.Create(&doc).Error; result != nil {
+	// Stream to storage while hashing
+	hash := sha256.New()
+	if err := storage.Default.Put(c.Request.Context(), doc.StorageKey, io.TeeReader(reader, hash), file.Size, contentType); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al guardar el archivo"})
+		return
+	}
+	doc.Checksum = hex.EncodeToString(hash.Sum(nil))
+
+	quotaExceeded := false
+	saveVersion := func(tx *gorm.DB) error {
+		// Check the quota again with the project locked, since other uploads
+		// may have finished while this one was streaming
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&project, "id = ?", projectID).Error; err != nil {
+			return err
+		}
+		if limits.Quota > 0 && projectStorageUsage(tx, projectID)+file.Size > limits.Quota {
+			quotaExceeded = true
+			return nil
+		}
+
+		// Uploading a name that already exists adds a new version
+		doc.ParentID, doc.Version = nil, 1
+		var latest []models.Document
+		tx.Where("project_id = ? AND name = ?", projectID, name).Order("version desc").Limit(1).Find(&latest)
+		if len(latest) > 0 {
+			root := rootID(latest[0])
+			doc.ParentID = &root
+			doc.Version = latest[0].Version + 1
+		}
+		return tx.Create(&doc).Error
+	}
+	// A concurrent upload may take the same version number first; the unique
+	// index on (parent, version) rejects it and the next attempt picks another
+	for attempt := 1; ; attempt++ {
+		err = database.DB.Transaction(saveVersion)
+		if !errors.Is(err, gorm.ErrDuplicatedKey) || attempt == uploadAttempts {
+			break
+		}
+	}
+	if err != nil {
+		storage.Default.Delete(c.Request.Context(), doc.StorageKey)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error saving document metadata"})
 		return
 	}
+	if quotaExceeded {
+		storage.Default.Delete(c.Request.Context(), doc.StorageKey)
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": "El proyecto no tiene espacio suficiente para este archivo",
+			"code":  ErrCodeQuotaExceeded,
+		})
+		return
+	}
 
 	c.JSON(http.StatusCreated, gin.H{"data": doc})
 }
 
+// GET /api/documents/:id/download
+func DownloadDocument(c *gin.Context) {
+	var doc models.Document
+	if err := database.DB.First(&doc, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Documento no encontrado"})
+		return
+	}
+	if doc.StorageKey == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "El archivo no está disponible"})
+		return
+	}
+
+	etag := `"` + doc.Checksum + `"`
+	if doc.Checksum != "" && c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	content, err := storage.Default.Get(c.Request.Context(), doc.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "El archivo no está disponible"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al leer el archivo"})
+		return
+	}
+	defer content.Close()
+
+	c.Header("ETag", etag)
+	c.Header("X-Checksum-SHA256", doc.Checksum)
+	c.DataFromReader(http.StatusOK, doc.SizeBytes, doc.Type, content, map[string]string{
+		"Content-Disposition": mime.FormatMediaType("attachment", map[string]string{"filename": downloadName(doc)}),
+	})
+}
+
+// downloadName appends the version to the file name for older versions.
+func downloadName(doc models.Document) string {
+	if doc.ParentID == nil {
+		return doc.Name
+	}
+	ext := filepath.Ext(doc.Name)
+	return fmt.Sprintf("%s (v%d)%s", strings.TrimSuffix(doc.Name, ext), doc.Version, ext)
+}
+
+// DELETE /api/documents/:id
+// Removes one version; deleting the first version promotes the next one.
 func DeleteDocument(c *gin.Context) {
 	id := c.Param("id")
-	if result := database.DB.Delete(&models.Document{}, "id = ?", id); result.Error != nil {
+	var doc models.Document
+	if err := database.DB.First(&doc, "id = ?", id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Documento no encontrado"})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if doc.ParentID == nil {
+			var next []models.Document
+			tx.Where("parent_id = ?", doc.ID).Order("version asc").Limit(1).Find(&next)
+			if len(next) > 0 {
+				if err := tx.Model(&models.Document{}).Where("parent_id = ? AND id <> ?", doc.ID, next[0].ID).Update("parent_id", next[0].ID).Error; err != nil {
+					return err
+				}
+				if err := tx.Model(&next[0]).Update("parent_id", nil).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return tx.Delete(&models.Document{}, "id = ?", id).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting document"})
 		return
 	}
+
+	if doc.StorageKey != "" {
+		storage.Default.Delete(c.Request.Context(), doc.StorageKey)
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "Document deleted"})
 }
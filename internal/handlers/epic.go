@@ -0,0 +1,337 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"Wrk_Api/internal/database"
+	"Wrk_Api/internal/models"
+	"Wrk_Api/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CreateEpicRequest struct {
+	Title       string  `json:"title" binding:"required"`
+	Description *string `json:"description"`
+	Color       *string `json:"color"`
+	ProjectID   string  `json:"projectId" binding:"required"`
+	TargetDate  *string `json:"targetDate"`
+}
+
+type UpdateEpicRequest struct {
+	Title       string  `json:"title"`
+	Description *string `json:"description"`
+	Color       *string `json:"color"`
+	Status      string  `json:"status"`
+	TargetDate  *string `json:"targetDate"`
+}
+
+// EpicProgress rolls up the stories of an epic.
+type EpicProgress struct {
+	TotalStories int     `json:"totalStories"`
+	DoneStories  int     `json:"doneStories"`
+	TotalPoints  int     `json:"totalPoints"`
+	DonePoints   int     `json:"donePoints"`
+	Percent      float64 `json:"percent"` // by story points, or by story count when nothing is estimated
+}
+
+func epicProgress(stories []models.UserStory) EpicProgress {
+	var p EpicProgress
+	for _, story := range stories {
+		points := 0
+		if story.StoryPoints != nil {
+			points = *story.StoryPoints
+		}
+		p.TotalStories++
+		p.TotalPoints += points
+		if doneStatuses[story.Status] {
+			p.DoneStories++
+			p.DonePoints += points
+		}
+	}
+	if p.TotalPoints > 0 {
+		p.Percent = round(float64(p.DonePoints) * 100 / float64(p.TotalPoints))
+	} else if p.TotalStories > 0 {
+		p.Percent = round(float64(p.DoneStories) * 100 / float64(p.TotalStories))
+	}
+	return p
+}
+
+// epicInProject reports whether the epic exists and belongs to the project.
+func epicInProject(epicID, projectID string) bool {
+	var count int64
+	database.DB.Model(&models.Epic{}).Where("id = ? AND project_id = ?", epicID, projectID).Count(&count)
+	return count > 0
+}
+
+func parseTargetDate(raw *string) (*time.Time, bool) {
+	if raw == nil || *raw == "" {
+		return nil, true
+	}
+	date, err := time.Parse(time.RFC3339, *raw)
+	if err != nil {
+		return nil, false
+	}
+	return &date, true
+}
+
+// GET /api/epics/project/:projectId
+func GetProjectEpics(c *gin.Context) {
+	var epics []models.Epic
+	if result := database.DB.Preload("UserStories").Where("project_id = ?", c.Param("projectId")).Order("created_at asc").Find(&epics); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener épicas"})
+		return
+	}
+
+	type EpicSummary struct {
+		models.Epic
+		Progress EpicProgress `json:"progress"`
+	}
+	summaries := []EpicSummary{}
+	for _, epic := range epics {
+		summaries = append(summaries, EpicSummary{Epic: epic, Progress: epicProgress(epic.UserStories)})
+	}
+	c.JSON(http.StatusOK, gin.H{"data": summaries})
+}
+
+// GET /api/epics/:id
+func GetEpic(c *gin.Context) {
+	var epic models.Epic
+	err := database.DB.Preload("UserStories", func(db *gorm.DB) *gorm.DB {
+		return db.Order("user_stories.rank asc")
+	}).Preload("UserStories.Assignee").First(&epic, "id = ?", c.Param("id")).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Épica no encontrada"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": epic, "progress": epicProgress(epic.UserStories)})
+}
+
+// POST /api/epics
+func CreateEpic(c *gin.Context) {
+	var req CreateEpicRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	targetDate, ok := parseTargetDate(req.TargetDate)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "targetDate debe estar en formato RFC3339"})
+		return
+	}
+
+	epic := models.Epic{
+		ID:          utils.GenerateCUID(),
+		ProjectID:   req.ProjectID,
+		Title:       req.Title,
+		Description: req.Description,
+		Color:       req.Color,
+		Status:      models.EpicOpen,
+		TargetDate:  targetDate,
+	}
+	if result := database.DB.Create(&epic); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear la épica"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": epic})
+}
+
+// PUT /api/epics/:id
+func UpdateEpic(c *gin.Context) {
+	var req UpdateEpicRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var epic models.Epic
+	if result := database.DB.First(&epic, "id = ?", c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Épica no encontrada"})
+		return
+	}
+
+	if req.Title != "" {
+		epic.Title = req.Title
+	}
+	if req.Description != nil {
+		epic.Description = req.Description
+	}
+	if req.Color != nil {
+		epic.Color = req.Color
+	}
+	if req.Status != "" {
+		if req.Status != models.EpicOpen && req.Status != models.EpicDone {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Estado de épica inválido"})
+			return
+		}
+		epic.Status = req.Status
+	}
+	if req.TargetDate != nil {
+		targetDate, ok := parseTargetDate(req.TargetDate)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "targetDate debe estar en formato RFC3339"})
+			return
+		}
+		epic.TargetDate = targetDate
+	}
+
+	if result := database.DB.Save(&epic); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar la épica"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": epic})
+}
+
+// DELETE /api/epics/:id
+// The stories stay in the backlog, just without an epic.
+func DeleteEpic(c *gin.Context) {
+	id := c.Param("id")
+	database.DB.Model(&models.UserStory{}).Where("epic_id = ?", id).Update("epic_id", nil)
+	if result := database.DB.Delete(&models.Epic{}, "id = ?", id); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar la épica"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Épica eliminada"})
+}
+
+// epicSpan is a period a story spent in an epic; To is nil while it's still there.
+type epicSpan struct {
+	From time.Time
+	To   *time.Time
+}
+
+// epicSpans rebuilds when each story joined and left the epic from the epicId
+// history. Stories that joined before history was kept count from creation.
+func epicSpans(epic models.Epic) (map[string]models.UserStory, map[string][]epicSpan) {
+	var entries []models.HistoryEntry
+	database.DB.Where("entity_type = ? AND field = ? AND (old_value = ? OR new_value = ?)", models.EntityUserStory, "epicId", epic.ID, epic.ID).
+		Order("created_at asc").Find(&entries)
+
+	stories := make(map[string]models.UserStory, len(epic.UserStories))
+	for _, story := range epic.UserStories {
+		stories[story.ID] = story
+	}
+	var former []string
+	for _, e := range entries {
+		if _, ok := stories[e.EntityID]; !ok {
+			former = append(former, e.EntityID)
+		}
+	}
+	if len(former) > 0 {
+		var others []models.UserStory
+		database.DB.Where("id IN ?", former).Find(&others)
+		for _, story := range others {
+			stories[story.ID] = story
+		}
+	}
+
+	spans := make(map[string][]epicSpan)
+	for _, e := range entries {
+		story, ok := stories[e.EntityID]
+		if !ok {
+			continue
+		}
+		if e.OldValue != nil && *e.OldValue == epic.ID {
+			if len(spans[story.ID]) == 0 {
+				spans[story.ID] = []epicSpan{{From: story.CreatedAt}}
+			}
+			if last := &spans[story.ID][len(spans[story.ID])-1]; last.To == nil {
+				left := e.CreatedAt
+				last.To = &left
+			}
+		}
+		if e.NewValue != nil && *e.NewValue == epic.ID {
+			spans[story.ID] = append(spans[story.ID], epicSpan{From: e.CreatedAt})
+		}
+	}
+	for _, story := range epic.UserStories {
+		if len(spans[story.ID]) == 0 {
+			spans[story.ID] = []epicSpan{{From: story.CreatedAt}}
+		}
+	}
+	return stories, spans
+}
+
+// inEpic reports whether a story was in the epic at the given instant.
+func inEpic(spans []epicSpan, at time.Time) bool {
+	for _, span := range spans {
+		if span.From.Before(at) && (span.To == nil || !span.To.Before(at)) {
+			return true
+		}
+	}
+	return false
+}
+
+// GET /api/metrics/epics/:epicId/burnup
+// Daily scope (points of the stories in the epic at that date) against points done.
+func GetEpicBurnup(c *gin.Context) {
+	var epic models.Epic
+	if err := database.DB.Preload("UserStories").First(&epic, "id = ?", c.Param("epicId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Épica no encontrada"})
+		return
+	}
+
+	type DataPoint struct {
+		Date  string `json:"date"`
+		Scope int    `json:"scope"`
+		Done  *int   `json:"done"` // nil for days still to come
+	}
+
+	stories, spans := epicSpans(epic)
+	start := epic.CreatedAt
+	for _, storySpans := range spans {
+		if storySpans[0].From.Before(start) {
+			start = storySpans[0].From
+		}
+	}
+	now := time.Now()
+	end := now
+	if epic.TargetDate != nil && epic.TargetDate.After(end) {
+		end = *epic.TargetDate
+	}
+	// Keep the series to a bounded number of days around today
+	if earliest := now.AddDate(0, 0, -maxFlowDays); start.Before(earliest) {
+		start = earliest
+	}
+	if latest := now.AddDate(0, 0, maxFlowDays); end.After(latest) {
+		end = latest
+	}
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+
+	series := []DataPoint{}
+	for date := start; !date.After(end); date = date.AddDate(0, 0, 1) {
+		dayEnd := date.AddDate(0, 0, 1)
+		point := DataPoint{Date: date.Format("2006-01-02")}
+		// Days after today carry the current scope up to the target date
+		future := date.After(now)
+		done := 0
+		for id, story := range stories {
+			if story.StoryPoints == nil {
+				continue
+			}
+			if future && (story.EpicID == nil || *story.EpicID != epic.ID) {
+				continue
+			}
+			if !future && !inEpic(spans[id], dayEnd) {
+				continue
+			}
+			point.Scope += *story.StoryPoints
+			if story.CompletedAt != nil && story.CompletedAt.Before(dayEnd) {
+				done += *story.StoryPoints
+			}
+		}
+		if !future {
+			point.Done = &done
+		}
+		series = append(series, point)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"epicId":   epic.ID,
+		"title":    epic.Title,
+		"progress": epicProgress(epic.UserStories),
+		"series":   series,
+	})
+}
@@ -1,11 +1,15 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
 	"sort"
 	"time"
 
 	"Wrk_Api/internal/database"
+	"Wrk_Api/internal/middleware"
 	"Wrk_Api/internal/models"
 	"Wrk_Api/internal/utils"
 
@@ -17,39 +21,220 @@ type CreateGenericEvaluationRequest struct {
 	ProjectID      string          `json:"projectId" binding:"required"`
 	TaskID         *string         `json:"taskId"`
 	SprintID       *string         `json:"sprintId"`
-	EvaluatorID    string          `json:"evaluatorId" binding:"required"`
+	RubricID       *string         `json:"rubricId"`
 	Feedback       string          `json:"feedback"`
-	Score          int             `json:"score"`
-	CriteriaScores []CriteriaScore `json:"criteriaScores" binding:"required"`
+	Score          *int            `json:"score"`
+	MaxScore       *int            `json:"maxScore"` // required with a manual score
+	CriteriaScores []CriteriaScore `json:"criteriaScores"`
+	Status         string          `json:"status"` // DRAFT (default) or SUBMITTED
 }
 
+// UpdateEvaluationRequest changes only the fields present in the body.
 type UpdateEvaluationRequest struct {
-	Feedback       string          `json:"feedback"`
-	Score          int             `json:"score"`
-	CriteriaScores []CriteriaScore `json:"criteriaScores" binding:"required"`
+	Feedback       *string         `json:"feedback"`
+	RubricID       *string         `json:"rubricId"`
+	Score          *int            `json:"score"`
+	MaxScore       *int            `json:"maxScore"` // required with a manual score
+	CriteriaScores []CriteriaScore `json:"criteriaScores"` // absent keeps the stored scores
+}
+
+// publishedStatuses are the evaluation statuses students can see.
+var publishedStatuses = []string{models.EvaluationPublished, models.EvaluationCompleted}
+
+// gradeCriteria validates the criterion scores against the rubric and returns the
+// rubric ID with the weighted grade normalized to 0-100. When no rubric is given
+// it is taken from the scored criteria. Drafts may leave criteria unscored, in
+// which case no grade is returned.
+func gradeCriteria(projectID string, rubricID *string, scores []CriteriaScore, draft bool) (string, *int, error) {
+	if rubricID == nil || *rubricID == "" {
+		var first models.Criteria
+		if database.DB.First(&first, "id = ?", scores[0].CriteriaID).Error != nil {
+			return "", nil, fmt.Errorf("el criterio %s no existe", scores[0].CriteriaID)
+		}
+		rubricID = &first.RubricID
+	}
+
+	var rubric models.Rubric
+	if err := database.DB.Preload("Criteria").First(&rubric, "id = ?", *rubricID).Error; err != nil {
+		return "", nil, errors.New("rúbrica no encontrada")
+	}
+	if rubric.ProjectID != nil && *rubric.ProjectID != projectID {
+		return "", nil, errors.New("la rúbrica no pertenece al proyecto")
+	}
+	if len(rubric.Criteria) == 0 {
+		return "", nil, errors.New("la rúbrica no tiene criterios")
+	}
+
+	criteria := make(map[string]models.Criteria, len(rubric.Criteria))
+	for _, crit := range rubric.Criteria {
+		criteria[crit.ID] = crit
+	}
+
+	scored := make(map[string]int, len(scores))
+	for _, cs := range scores {
+		crit, ok := criteria[cs.CriteriaID]
+		if !ok {
+			return "", nil, fmt.Errorf("el criterio %s no pertenece a la rúbrica", cs.CriteriaID)
+		}
+		if _, dup := scored[cs.CriteriaID]; dup {
+			return "", nil, fmt.Errorf("el criterio \"%s\" está calificado más de una vez", crit.Name)
+		}
+		if cs.Score < 0 || cs.Score > crit.MaxScore {
+			return "", nil, fmt.Errorf("el puntaje de \"%s\" debe estar entre 0 y %d", crit.Name, crit.MaxScore)
+		}
+		scored[cs.CriteriaID] = cs.Score
+	}
+
+	// Each criterion contributes score/MaxScore scaled by its weight
+	var weighted, totalWeight float64
+	for _, crit := range rubric.Criteria {
+		score, ok := scored[crit.ID]
+		if !ok {
+			if draft {
+				return rubric.ID, nil, nil
+			}
+			return "", nil, fmt.Errorf("falta calificar el criterio \"%s\"", crit.Name)
+		}
+		if crit.MaxScore <= 0 {
+			continue
+		}
+		weighted += float64(crit.Weight) * float64(score) / float64(crit.MaxScore)
+		totalWeight += float64(crit.Weight)
+	}
+	if totalWeight == 0 {
+		return "", nil, errors.New("la rúbrica no tiene pesos válidos")
+	}
+
+	grade := int(math.Round(weighted / totalWeight * 100))
+	return rubric.ID, &grade, nil
+}
+
+// fullMarks is the scale stored scores use; manual scores are converted to it.
+var fullMarks = 100
+
+// resolveScore computes the evaluation score from the rubric when criteria are
+// scored; otherwise it accepts a manual score out of maxScore, which has to be
+// given explicitly, and scales it to 0-100. Drafts may be saved without a
+// complete score.
+func resolveScore(projectID string, rubricID *string, score, maxScore *int, scores []CriteriaScore, draft bool) (*string, *int, error) {
+	if len(scores) > 0 {
+		id, grade, err := gradeCriteria(projectID, rubricID, scores, draft)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &id, grade, nil
+	}
+	if rubricID != nil && *rubricID != "" {
+		if draft {
+			return rubricID, nil, nil
+		}
+		return nil, nil, errors.New("se requieren los puntajes de los criterios de la rúbrica")
+	}
+	if score == nil {
+		if draft {
+			return nil, nil, nil
+		}
+		return nil, nil, errors.New("se requiere una rúbrica o un puntaje")
+	}
+	if maxScore == nil || *maxScore <= 0 {
+		return nil, nil, errors.New("un puntaje sin rúbrica requiere maxScore")
+	}
+	if *score < 0 || *score > *maxScore {
+		return nil, nil, fmt.Errorf("el puntaje debe estar entre 0 y %d", *maxScore)
+	}
+	grade := int(math.Round(float64(*score) * 100 / float64(*maxScore)))
+	return nil, &grade, nil
+}
+
+// initialStatus validates the status requested when creating an evaluation.
+func initialStatus(status string) (string, error) {
+	switch status {
+	case "", models.EvaluationDraft:
+		return models.EvaluationDraft, nil
+	case models.EvaluationSubmitted:
+		return models.EvaluationSubmitted, nil
+	}
+	return "", errors.New("una evaluación solo puede crearse como DRAFT o SUBMITTED")
+}
+
+// canSeeUnpublished reports whether the caller evaluates in the project and can
+// therefore see drafts and submitted evaluations.
+func canSeeUnpublished(c *gin.Context, projectID string) bool {
+	return middleware.Can(c, projectID, middleware.PermEvaluate)
+}
+
+// saveCriteriaScores replaces the criterion scores of an evaluation.
+func saveCriteriaScores(tx *gorm.DB, evaluationID string, scores []CriteriaScore) error {
+	if err := tx.Delete(&models.EvaluationCriteria{}, "evaluation_id = ?", evaluationID).Error; err != nil {
+		return err
+	}
+	for _, cs := range scores {
+		ec := models.EvaluationCriteria{
+			ID:           utils.GenerateCUID(),
+			EvaluationID: evaluationID,
+			CriteriaID:   cs.CriteriaID,
+			Score:        cs.Score,
+		}
+		if cs.Comment != "" {
+			ec.Comment = &cs.Comment
+		}
+		if err := tx.Create(&ec).Error; err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func GetEvaluation(c *gin.Context) {
 	id := c.Param("id")
 	var eval models.Evaluation
-	if result := database.DB.Preload("Criteria.Criteria").Preload("Evaluator").First(&eval, "id = ?", id); result.Error != nil {
+	if result := database.DB.Preload("Criteria.Criteria").Preload("Evaluator").Preload("Rubric.Criteria").First(&eval, "id = ?", id); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Evaluación no encontrada"})
+		return
+	}
+	if !isPublished(eval.Status) && !canSeeUnpublished(c, eval.ProjectID) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Evaluación no encontrada"})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"data": eval})
 }
 
+func isPublished(status string) bool {
+	return status == models.EvaluationPublished || status == models.EvaluationCompleted
+}
+
+// visibleEvaluations hides unpublished evaluations from callers who don't evaluate in the project.
+func visibleEvaluations(c *gin.Context, query *gorm.DB) *gorm.DB {
+	projectID := c.GetString("projectID")
+	if canSeeUnpublished(c, projectID) {
+		return query
+	}
+	return query.Where("evaluations.status IN ?", publishedStatuses)
+}
+
+// visibleEvaluationsScope is visibleEvaluations for preloads that may span
+// projects: unpublished evaluations are kept only where the caller evaluates.
+func visibleEvaluationsScope(c *gin.Context) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if middleware.IsAdmin(c) {
+			return db
+		}
+		graded := middleware.ProjectsWith(c.GetString("userID"), middleware.PermEvaluate)
+		return db.Where("(evaluations.status IN ? OR evaluations.project_id IN ?)", publishedStatuses, graded)
+	}
+}
+
 func GetTaskEvaluations(c *gin.Context) {
 	taskID := c.Param("taskId")
 	var evals []models.Evaluation
-	database.DB.Preload("Evaluator").Preload("Criteria").Where("task_id = ?", taskID).Order("created_at desc").Find(&evals)
+	visibleEvaluations(c, database.DB.Preload("Evaluator").Preload("Criteria")).Where("task_id = ?", taskID).Order("created_at desc").Find(&evals)
 	c.JSON(http.StatusOK, gin.H{"data": evals})
 }
 
 func GetSprintEvaluations(c *gin.Context) {
 	sprintID := c.Param("sprintId")
 	var evals []models.Evaluation
-	database.DB.Preload("Evaluator").Preload("Criteria").Where("sprint_id = ?", sprintID).Order("created_at desc").Find(&evals)
+	visibleEvaluations(c, database.DB.Preload("Evaluator").Preload("Criteria")).Where("sprint_id = ?", sprintID).Order("created_at desc").Find(&evals)
 	c.JSON(http.StatusOK, gin.H{"data": evals})
 }
 
@@ -57,7 +242,7 @@ func GetProjectEvaluations(c *gin.Context) {
 	projectID := c.Param("projectId")
 	var evals []models.Evaluation
 	// General evaluations (no task, no sprint)
-	database.DB.Preload("Evaluator").Preload("Criteria").
+	visibleEvaluations(c, database.DB.Preload("Evaluator").Preload("Criteria")).
 		Where("project_id = ? AND task_id IS NULL AND sprint_id IS NULL", projectID).
 		Order("created_at desc").Find(&evals)
 	c.JSON(http.StatusOK, gin.H{"data": evals})
@@ -66,9 +251,16 @@ func GetProjectEvaluations(c *gin.Context) {
 func GetStudentEvaluations(c *gin.Context) {
 	studentID := c.Param("studentId")
 
+	// Others only see the student's evaluations in the projects where they evaluate
+	scope := func(db *gorm.DB) *gorm.DB { return db }
+	if userID := c.GetString("userID"); userID != studentID && !middleware.IsAdmin(c) {
+		graded := middleware.ProjectsWith(userID, middleware.PermEvaluate)
+		scope = func(db *gorm.DB) *gorm.DB { return db.Where("evaluations.project_id IN ?", graded) }
+	}
+
 	// 1. Task Evaluations (assigned to student)
 	var taskEvals []models.Evaluation
-	database.DB.Joins("JOIN tasks ON tasks.id = evaluations.task_id").
+	database.DB.Scopes(scope).Joins("JOIN tasks ON tasks.id = evaluations.task_id").
 		Where("tasks.assignee_id = ?", studentID).
 		Preload("Project").Preload("Task").Preload("Sprint").Preload("Evaluator").
 		Find(&taskEvals)
@@ -79,18 +271,56 @@ func GetStudentEvaluations(c *gin.Context) {
 
 	var teamEvals []models.Evaluation
 	if len(projectIDs) > 0 {
-		database.DB.Where("project_id IN ? AND task_id IS NULL", projectIDs).
+		database.DB.Scopes(scope).Where("project_id IN ? AND task_id IS NULL", projectIDs).
 			Preload("Project").Preload("Sprint").Preload("Evaluator").
 			Find(&teamEvals)
 	}
 
-	// Merge and Sort
-	allEvals := append(taskEvals, teamEvals...)
+	// Merge, keeping drafts only for those who evaluate in the project
+	allEvals := []models.Evaluation{}
+	for _, eval := range append(taskEvals, teamEvals...) {
+		if isPublished(eval.Status) || canSeeUnpublished(c, eval.ProjectID) {
+			allEvals = append(allEvals, eval)
+		}
+	}
 	sort.Slice(allEvals, func(i, j int) bool {
 		return allEvals[i].CreatedAt.After(allEvals[j].CreatedAt)
 	})
 
-	c.JSON(http.StatusOK, gin.H{"data": allEvals})
+	// Peer and self review results are returned alongside so they can be combined with the grades
+	c.JSON(http.StatusOK, gin.H{"data": allEvals, "peerReviews": studentPeerResults(c, studentID)})
+}
+
+// checkEvaluationTarget makes sure the task and sprint being evaluated are in
+// the project the evaluator was authorized for, and that the student graded
+// on the task, its assignee, is a member of it.
+func checkEvaluationTarget(projectID string, taskID, sprintID *string) error {
+	if taskID != nil {
+		var task models.Task
+		if database.DB.First(&task, "id = ?", *taskID).Error != nil {
+			return errors.New("tarea no encontrada")
+		}
+		if task.ProjectID != projectID {
+			return errors.New("la tarea no pertenece al proyecto")
+		}
+		if task.AssigneeID != nil {
+			var count int64
+			database.DB.Model(&models.ProjectMember{}).Where("project_id = ? AND user_id = ?", projectID, *task.AssigneeID).Count(&count)
+			if count == 0 {
+				return errors.New("el estudiante asignado a la tarea no es miembro del proyecto")
+			}
+		}
+	}
+	if sprintID != nil {
+		var sprint models.Sprint
+		if database.DB.First(&sprint, "id = ?", *sprintID).Error != nil {
+			return errors.New("sprint no encontrado")
+		}
+		if sprint.ProjectID != projectID {
+			return errors.New("el sprint no pertenece al proyecto")
+		}
+	}
+	return nil
 }
 
 func CreateEvaluation(c *gin.Context) {
@@ -100,37 +330,44 @@ func CreateEvaluation(c *gin.Context) {
 		return
 	}
 
-	err := database.DB.Transaction(func(tx *gorm.DB) error {
-		eval := models.Evaluation{
-			ID:          utils.GenerateCUID(),
-			ProjectID:   req.ProjectID,
-			TaskID:      req.TaskID,
-			SprintID:    req.SprintID,
-			EvaluatorID: req.EvaluatorID,
-			Feedback:    &req.Feedback,
-			Score:       &req.Score,
-			Status:      "COMPLETED",
-			CreatedAt:   time.Now(),
-		}
+	if err := checkEvaluationTarget(req.ProjectID, req.TaskID, req.SprintID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
+	status, err := initialStatus(req.Status)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rubricID, score, err := resolveScore(req.ProjectID, req.RubricID, req.Score, req.MaxScore, req.CriteriaScores, status == models.EvaluationDraft)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	eval := models.Evaluation{
+		ID:          utils.GenerateCUID(),
+		ProjectID:   req.ProjectID,
+		TaskID:      req.TaskID,
+		SprintID:    req.SprintID,
+		EvaluatorID: c.GetString("userID"),
+		RubricID:    rubricID,
+		Feedback:    &req.Feedback,
+		Score:       score,
+		Status:      status,
+		CreatedAt:   time.Now(),
+	}
+	if status == models.EvaluationSubmitted {
+		eval.SubmittedAt = &eval.CreatedAt
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Create(&eval).Error; err != nil {
 			return err
 		}
-
-		for _, cs := range req.CriteriaScores {
-			ec := models.EvaluationCriteria{
-				ID:           utils.GenerateCUID(),
-				EvaluationID: eval.ID,
-				CriteriaID:   cs.CriteriaID,
-				Score:        cs.Score,
-			}
-			if err := tx.Create(&ec).Error; err != nil {
-				return err
-			}
-		}
-		
-		// Return with ID
-		return nil
+		return saveCriteriaScores(tx, eval.ID, req.CriteriaScores)
 	})
 
 	if err != nil {
@@ -138,7 +375,7 @@ func CreateEvaluation(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "Evaluación creada"})
+	c.JSON(http.StatusCreated, gin.H{"message": "Evaluación creada", "data": gin.H{"id": eval.ID, "score": eval.Score, "status": eval.Status}})
 }
 
 func UpdateEvaluation(c *gin.Context) {
@@ -149,32 +386,59 @@ func UpdateEvaluation(c *gin.Context) {
 		return
 	}
 
-	err := database.DB.Transaction(func(tx *gorm.DB) error {
-		// Update Evaluation
-		if err := tx.Model(&models.Evaluation{}).Where("id = ?", id).Updates(map[string]interface{}{
-			"feedback": req.Feedback,
-			"score":    req.Score,
-		}).Error; err != nil {
-			return err
-		}
+	var eval models.Evaluation
+	if err := database.DB.Preload("Criteria").First(&eval, "id = ?", id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Evaluación no encontrada"})
+		return
+	}
 
-		// Replace Criteria
-		if err := tx.Delete(&models.EvaluationCriteria{}, "evaluation_id = ?", id).Error; err != nil {
-			return err
+	if eval.Status != models.EvaluationDraft {
+		c.JSON(http.StatusConflict, gin.H{"error": "Solo se pueden editar evaluaciones en borrador; reábrela primero"})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.Feedback != nil {
+		updates["feedback"] = *req.Feedback
+	}
+
+	// The score is recomputed only when something it depends on changes
+	score := eval.Score
+	if req.RubricID != nil || req.Score != nil || req.CriteriaScores != nil {
+		rubricID := req.RubricID
+		if rubricID == nil {
+			rubricID = eval.RubricID
+		}
+		manual, maxScore := req.Score, req.MaxScore
+		if manual == nil {
+			// The stored score is already on the 0-100 scale
+			manual, maxScore = eval.Score, &fullMarks
+		}
+		scores := req.CriteriaScores
+		if scores == nil {
+			scores = scoresOf(eval.Criteria)
 		}
+		var err error
+		rubricID, score, err = resolveScore(eval.ProjectID, rubricID, manual, maxScore, scores, true)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updates["score"] = score
+		updates["rubric_id"] = rubricID
+	}
 
-		for _, cs := range req.CriteriaScores {
-			ec := models.EvaluationCriteria{
-				ID:           utils.GenerateCUID(),
-				EvaluationID: id,
-				CriteriaID:   cs.CriteriaID,
-				Score:        cs.Score,
-			}
-			if err := tx.Create(&ec).Error; err != nil {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if len(updates) > 0 {
+			if err := tx.Model(&models.Evaluation{}).Where("id = ?", id).Updates(updates).Error; err != nil {
 				return err
 			}
 		}
-		return nil
+		if req.CriteriaScores == nil {
+			return nil
+		}
+		// Replace Criteria
+		return saveCriteriaScores(tx, id, req.CriteriaScores)
 	})
 
 	if err != nil {
@@ -182,5 +446,112 @@ func UpdateEvaluation(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Evaluación actualizada"})
+	c.JSON(http.StatusOK, gin.H{"message": "Evaluación actualizada", "data": gin.H{"id": id, "score": score}})
+}
+
+// scoresOf converts stored criterion scores back into request form.
+func scoresOf(criteria []models.EvaluationCriteria) []CriteriaScore {
+	scores := make([]CriteriaScore, 0, len(criteria))
+	for _, ec := range criteria {
+		scores = append(scores, CriteriaScore{CriteriaID: ec.CriteriaID, Score: ec.Score})
+	}
+	return scores
+}
+
+// notifyPublishedEvaluation tells the evaluated students their result is available.
+func notifyPublishedEvaluation(eval models.Evaluation) {
+	if eval.TaskID != nil {
+		var task models.Task
+		if database.DB.First(&task, "id = ?", *eval.TaskID).Error == nil && task.AssigneeID != nil {
+			notify(*task.AssigneeID, "Tarea Evaluada", "Tu tarea \""+task.Title+"\" ha sido evaluada", "EVALUATION_COMPLETED")
+		}
+		return
+	}
+
+	var project models.Project
+	database.DB.First(&project, "id = ?", eval.ProjectID)
+	var members []models.ProjectMember
+	database.DB.Where("project_id = ? AND role <> ? AND user_id <> ?", eval.ProjectID, middleware.RoleEvaluator, eval.EvaluatorID).Find(&members)
+	for _, m := range members {
+		notify(m.UserID, "Nueva Evaluación", "Se ha publicado una evaluación del proyecto \""+project.Name+"\"", "EVALUATION_COMPLETED")
+	}
+}
+
+// transitionEvaluation moves an evaluation between lifecycle states.
+func transitionEvaluation(c *gin.Context, from []string, to string) (*models.Evaluation, bool) {
+	var eval models.Evaluation
+	if err := database.DB.Preload("Criteria").First(&eval, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Evaluación no encontrada"})
+		return nil, false
+	}
+
+	allowed := false
+	for _, status := range from {
+		allowed = allowed || eval.Status == status
+	}
+	if !allowed {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("No se puede pasar de %s a %s", eval.Status, to)})
+		return nil, false
+	}
+
+	updates := map[string]interface{}{"status": to}
+	now := time.Now()
+	switch to {
+	case models.EvaluationSubmitted:
+		// Submitting requires a complete score
+		_, score, err := resolveScore(eval.ProjectID, eval.RubricID, eval.Score, &fullMarks, scoresOf(eval.Criteria), false)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return nil, false
+		}
+		updates["score"] = score
+		updates["submitted_at"] = now
+	case models.EvaluationPublished:
+		updates["published_at"] = now
+	case models.EvaluationDraft:
+		updates["submitted_at"] = nil
+		updates["published_at"] = nil
+	}
+
+	// Only applies if nobody moved the evaluation since it was read, so two
+	// concurrent transitions can't both pass the check above
+	result := database.DB.Model(&models.Evaluation{}).Where("id = ? AND status = ?", eval.ID, eval.Status).Updates(updates)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar evaluación"})
+		return nil, false
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "La evaluación cambió de estado mientras tanto; vuelve a intentarlo"})
+		return nil, false
+	}
+	database.DB.First(&eval, "id = ?", eval.ID)
+	return &eval, true
+}
+
+// POST /api/evaluations/:id/submit
+func SubmitEvaluation(c *gin.Context) {
+	eval, ok := transitionEvaluation(c, []string{models.EvaluationDraft}, models.EvaluationSubmitted)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": eval})
+}
+
+// POST /api/evaluations/:id/publish
+func PublishEvaluation(c *gin.Context) {
+	eval, ok := transitionEvaluation(c, []string{models.EvaluationSubmitted}, models.EvaluationPublished)
+	if !ok {
+		return
+	}
+	notifyPublishedEvaluation(*eval)
+	c.JSON(http.StatusOK, gin.H{"data": eval})
+}
+
+// POST /api/evaluations/:id/reopen
+func ReopenEvaluation(c *gin.Context) {
+	eval, ok := transitionEvaluation(c, []string{models.EvaluationSubmitted, models.EvaluationPublished, models.EvaluationCompleted}, models.EvaluationDraft)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": eval})
 }
@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"Wrk_Api/internal/database"
+	"Wrk_Api/internal/middleware"
+	"Wrk_Api/internal/models"
+	"Wrk_Api/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// gradeWeights sets how much each evaluation category counts towards the final grade.
+type gradeWeights struct {
+	Task    float64 `json:"task"`
+	Sprint  float64 `json:"sprint"`
+	General float64 `json:"general"`
+}
+
+type GradebookRow struct {
+	ProjectID    string              `json:"projectId"`
+	ProjectName  string              `json:"projectName"`
+	StudentID    string              `json:"studentId"`
+	StudentName  string              `json:"studentName"`
+	StudentEmail string              `json:"studentEmail"`
+	Task         *float64            `json:"task"`
+	Sprint       *float64            `json:"sprint"`
+	General      *float64            `json:"general"`
+	Criteria     map[string]*float64 `json:"criteria"` // average percentage per criterion name
+	Final        *float64            `json:"final"`
+	Evaluations  int                 `json:"evaluations"`
+}
+
+// parseGradeWeights reads taskWeight, sprintWeight and generalWeight from the query.
+func parseGradeWeights(c *gin.Context) (gradeWeights, error) {
+	weights := gradeWeights{Task: 50, Sprint: 30, General: 20}
+	for param, target := range map[string]*float64{
+		"taskWeight":    &weights.Task,
+		"sprintWeight":  &weights.Sprint,
+		"generalWeight": &weights.General,
+	} {
+		raw := c.Query(param)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil || value < 0 {
+			return weights, fmt.Errorf("%s debe ser un número positivo", param)
+		}
+		*target = value
+	}
+	if weights.Task+weights.Sprint+weights.General == 0 {
+		return weights, errors.New("al menos un peso debe ser mayor que cero")
+	}
+	return weights, nil
+}
+
+type gradeSums struct {
+	total float64
+	count int
+}
+
+func (g *gradeSums) add(value float64) {
+	g.total += value
+	g.count++
+}
+
+func (g gradeSums) average() *float64 {
+	if g.count == 0 {
+		return nil
+	}
+	avg := round(g.total / float64(g.count))
+	return &avg
+}
+
+// projectGradebook aggregates the published evaluations of a project per team
+// member. When studentID is set only that student's row is returned.
+func projectGradebook(project models.Project, weights gradeWeights, studentID string) ([]string, []GradebookRow) {
+	var evals []models.Evaluation
+	database.DB.Preload("Task").Preload("Criteria.Criteria").
+		Where("project_id = ? AND status IN ? AND score IS NOT NULL", project.ID, publishedStatuses).
+		Order("created_at asc").Find(&evals)
+
+	type studentSums struct {
+		task, sprint, general gradeSums
+		criteria              map[string]*gradeSums
+		evaluations           int
+	}
+	sums := map[string]*studentSums{}
+	var students []models.ProjectMember
+	for _, m := range teamMembers(project.ID) {
+		if studentID == "" || m.UserID == studentID {
+			students = append(students, m)
+			sums[m.UserID] = &studentSums{criteria: map[string]*gradeSums{}}
+		}
+	}
+
+	criteriaNames := []string{}
+	for _, eval := range evals {
+		// Task evaluations count for the assignee; sprint and general ones for the whole team
+		targets := []string{}
+		if eval.TaskID != nil {
+			if eval.Task != nil && eval.Task.AssigneeID != nil && sums[*eval.Task.AssigneeID] != nil {
+				targets = append(targets, *eval.Task.AssigneeID)
+			}
+		} else {
+			for _, m := range students {
+				targets = append(targets, m.UserID)
+			}
+		}
+
+		for _, id := range targets {
+			s := sums[id]
+			s.evaluations++
+			switch {
+			case eval.TaskID != nil:
+				s.task.add(float64(*eval.Score))
+			case eval.SprintID != nil:
+				s.sprint.add(float64(*eval.Score))
+			default:
+				s.general.add(float64(*eval.Score))
+			}
+			for _, ec := range eval.Criteria {
+				if ec.Criteria.MaxScore <= 0 {
+					continue
+				}
+				name := ec.Criteria.Name
+				if s.criteria[name] == nil {
+					s.criteria[name] = &gradeSums{}
+				}
+				s.criteria[name].add(float64(ec.Score) / float64(ec.Criteria.MaxScore) * 100)
+			}
+		}
+
+		for _, ec := range eval.Criteria {
+			if !containsString(criteriaNames, ec.Criteria.Name) {
+				criteriaNames = append(criteriaNames, ec.Criteria.Name)
+			}
+		}
+	}
+
+	rows := []GradebookRow{}
+	for _, m := range students {
+		s := sums[m.UserID]
+		row := GradebookRow{
+			ProjectID:    project.ID,
+			ProjectName:  project.Name,
+			StudentID:    m.UserID,
+			StudentName:  m.User.Name,
+			StudentEmail: m.User.Email,
+			Task:         s.task.average(),
+			Sprint:       s.sprint.average(),
+			General:      s.general.average(),
+			Criteria:     map[string]*float64{},
+			Evaluations:  s.evaluations,
+		}
+		for name, sum := range s.criteria {
+			row.Criteria[name] = sum.average()
+		}
+
+		// Categories without grades are left out and the remaining weights renormalized
+		var weighted, totalWeight float64
+		for _, part := range []struct {
+			avg    *float64
+			weight float64
+		}{{row.Task, weights.Task}, {row.Sprint, weights.Sprint}, {row.General, weights.General}} {
+			if part.avg != nil && part.weight > 0 {
+				weighted += *part.avg * part.weight
+				totalWeight += part.weight
+			}
+		}
+		if totalWeight > 0 {
+			final := round(weighted / totalWeight)
+			row.Final = &final
+		}
+		rows = append(rows, row)
+	}
+	return criteriaNames, rows
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func formatGrade(value *float64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*value, 'f', -1, 64)
+}
+
+// gradebookTable lays the gradebook out as spreadsheet rows with a header.
+func gradebookTable(criteriaNames []string, rows []GradebookRow) [][]string {
+	header := []string{"Proyecto", "Estudiante", "Email", "Tareas", "Sprints", "General"}
+	for _, name := range criteriaNames {
+		header = append(header, name+" (%)")
+	}
+	header = append(header, "Nota Final")
+
+	table := [][]string{header}
+	for _, row := range rows {
+		line := []string{row.ProjectName, row.StudentName, row.StudentEmail, formatGrade(row.Task), formatGrade(row.Sprint), formatGrade(row.General)}
+		for _, name := range criteriaNames {
+			line = append(line, formatGrade(row.Criteria[name]))
+		}
+		table = append(table, append(line, formatGrade(row.Final)))
+	}
+	return table
+}
+
+// writeGradebook responds in the format requested by ?format= (json, csv or xlsx).
+func writeGradebook(c *gin.Context, filename string, weights gradeWeights, criteriaNames []string, rows []GradebookRow) {
+	switch c.DefaultQuery("format", "json") {
+	case "json":
+		c.JSON(http.StatusOK, gin.H{"data": gin.H{"weights": weights, "criteria": criteriaNames, "rows": rows}})
+	case "csv":
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		w.WriteAll(utils.CSVSafe(gradebookTable(criteriaNames, rows)))
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.csv\"", filename))
+		c.Data(http.StatusOK, "text/csv", buf.Bytes())
+	case "xlsx":
+		var buf bytes.Buffer
+		if err := utils.WriteXLSX(&buf, "Calificaciones", gradebookTable(criteriaNames, rows)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al generar el archivo"})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.xlsx\"", filename))
+		c.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", buf.Bytes())
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Formato no soportado; usa json, csv o xlsx"})
+	}
+}
+
+// GET /api/metrics/projects/:projectId/gradebook
+func GetProjectGradebook(c *gin.Context) {
+	weights, err := parseGradeWeights(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var project models.Project
+	if err := database.DB.First(&project, "id = ?", c.Param("projectId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Proyecto no encontrado"})
+		return
+	}
+
+	criteriaNames, rows := projectGradebook(project, weights, "")
+	writeGradebook(c, "gradebook-"+project.ID, weights, criteriaNames, rows)
+}
+
+// GET /api/metrics/students/:studentId/gradebook
+// One row per project of the student; instructors only get the projects they evaluate.
+func GetStudentGradebook(c *gin.Context) {
+	weights, err := parseGradeWeights(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	studentID := c.Param("studentId")
+	var projects []models.Project
+	database.DB.Where("owner_id = ? OR id IN (?)", studentID,
+		database.DB.Model(&models.ProjectMember{}).Select("project_id").Where("user_id = ?", studentID)).
+		Order("name asc").Find(&projects)
+
+	criteriaNames := []string{}
+	rows := []GradebookRow{}
+	for _, project := range projects {
+		if c.GetString("userID") != studentID && !middleware.Can(c, project.ID, middleware.PermEvaluate) {
+			continue
+		}
+		names, projectRows := projectGradebook(project, weights, studentID)
+		for _, name := range names {
+			if !containsString(criteriaNames, name) {
+				criteriaNames = append(criteriaNames, name)
+			}
+		}
+		rows = append(rows, projectRows...)
+	}
+
+	writeGradebook(c, "gradebook-"+studentID, weights, criteriaNames, rows)
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"Wrk_Api/internal/database"
+	"Wrk_Api/internal/models"
+	"Wrk_Api/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// snapshot maps the tracked fields of an entity to their string value (nil when unset).
+type snapshot map[string]*string
+
+func strValue(s string) *string {
+	return &s
+}
+
+func optionalValue(s *string) *string {
+	if s == nil || *s == "" {
+		return nil
+	}
+	return strValue(*s)
+}
+
+func timeValue(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	return strValue(t.UTC().Format(time.RFC3339))
+}
+
+func intValue(i *int) *string {
+	if i == nil {
+		return nil
+	}
+	return strValue(strconv.Itoa(*i))
+}
+
+func taskSnapshot(t models.Task) snapshot {
+	return snapshot{
+		"title":             strValue(t.Title),
+		"description":       optionalValue(t.Description),
+		"priority":          strValue(t.Priority),
+		"status":            strValue(t.Status),
+		"assigneeId":        optionalValue(t.AssigneeID),
+		"sprintId":          optionalValue(t.SprintID),
+		"userStoryId":       optionalValue(t.UserStoryID),
+		"deadline":          timeValue(t.Deadline),
+		"originalEstimate":  intValue(t.OriginalEstimate),
+		"remainingEstimate": intValue(t.RemainingEstimate),
+	}
+}
+
+func userStorySnapshot(s models.UserStory) snapshot {
+	return snapshot{
+		"title":       strValue(s.Title),
+		"description": strValue(s.Description),
+		"acceptance":  optionalValue(s.Acceptance),
+		"priority":    strValue(s.Priority),
+		"storyPoints": intValue(s.StoryPoints),
+		"status":      strValue(s.Status),
+		"assigneeId":  optionalValue(s.AssigneeID),
+		"sprintId":    optionalValue(s.SprintID),
+		"epicId":      optionalValue(s.EpicID),
+	}
+}
+
+func sameValue(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// recordHistory stores one entry per field that differs between before and after.
+// A nil before records the initial values of a newly created entity.
+func recordHistory(tx *gorm.DB, c *gin.Context, entityType, entityID, projectID string, before, after snapshot) error {
+	var userID *string
+	if id, ok := c.Get("userID"); ok {
+		if s, ok := id.(string); ok {
+			userID = &s
+		}
+	}
+
+	fields := make([]string, 0, len(after))
+	for field := range after {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	now := time.Now()
+	for _, field := range fields {
+		var old *string
+		if before != nil {
+			old = before[field]
+		}
+		if before != nil && sameValue(old, after[field]) {
+			continue
+		}
+		if before == nil && after[field] == nil {
+			continue
+		}
+
+		entry := models.HistoryEntry{
+			ID:         utils.GenerateCUID(),
+			EntityType: entityType,
+			EntityID:   entityID,
+			ProjectID:  projectID,
+			Field:      field,
+			OldValue:   old,
+			NewValue:   after[field],
+			UserID:     userID,
+			CreatedAt:  now,
+		}
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func historyResponse(entries []models.HistoryEntry) []gin.H {
+	response := []gin.H{}
+	for _, e := range entries {
+		var user gin.H
+		if e.User != nil {
+			user = gin.H{"id": e.User.ID, "name": e.User.Name}
+		}
+		response = append(response, gin.H{
+			"id":        e.ID,
+			"field":     e.Field,
+			"oldValue":  e.OldValue,
+			"newValue":  e.NewValue,
+			"user":      user,
+			"createdAt": e.CreatedAt,
+		})
+	}
+	return response
+}
+
+func getHistory(c *gin.Context, entityType string) {
+	var entries []models.HistoryEntry
+	if result := database.DB.Preload("User").
+		Where("entity_type = ? AND entity_id = ?", entityType, c.Param("id")).
+		Order("created_at asc, field asc").Find(&entries); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener historial"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": historyResponse(entries)})
+}
+
+// GET /api/tasks/:id/history
+func GetTaskHistory(c *gin.Context) {
+	getHistory(c, models.EntityTask)
+}
+
+// GET /api/user-stories/:id/history
+func GetUserStoryHistory(c *gin.Context) {
+	getHistory(c, models.EntityUserStory)
+}
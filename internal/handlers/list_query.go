@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"Wrk_Api/internal/database"
+	"Wrk_Api/internal/middleware"
+	"Wrk_Api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+// priorityOrder sorts priorities by weight instead of alphabetically.
+const priorityOrder = "CASE priority WHEN 'LOW' THEN 1 WHEN 'MEDIUM' THEN 2 WHEN 'HIGH' THEN 3 ELSE 0 END"
+
+type filterKind int
+
+const (
+	filterEquals filterKind = iota // comma-separated values match any of them; "null" matches unset
+	filterBool
+	filterFrom // inclusive lower bound of a date
+	filterTo   // upper bound of a date; a bare day includes the whole day
+)
+
+type listFilter struct {
+	Column string
+	Kind   filterKind
+}
+
+// listSpec declares how a list endpoint is filtered and sorted. Sort
+// expressions must never be NULL and only use columns of Table, so they can be
+// evaluated on the cursor row as well.
+type listSpec struct {
+	Table       string
+	Filters     map[string]listFilter // query param -> column
+	Sorts       map[string]string     // sort key -> SQL expression
+	DefaultSort string                // e.g. "-createdAt"
+}
+
+type sortTerm struct {
+	Expr string
+	Desc bool
+}
+
+func encodeCursor(id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(id))
+}
+
+func decodeCursor(cursor string) (string, bool) {
+	id, err := base64.RawURLEncoding.DecodeString(cursor)
+	return string(id), err == nil && len(id) > 0
+}
+
+// parseBound reads a date range bound as RFC3339 or a bare YYYY-MM-DD day.
+func parseBound(value string, kind filterKind) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, true
+	}
+	day, err := time.Parse(dayLayout, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if kind == filterTo {
+		return day.AddDate(0, 0, 1).Add(-time.Nanosecond), true
+	}
+	return day, true
+}
+
+// applyFilters narrows query with the filters present in the request.
+func applyFilters(c *gin.Context, query *gorm.DB, spec listSpec) (*gorm.DB, error) {
+	for param, filter := range spec.Filters {
+		value := strings.TrimSpace(c.Query(param))
+		if value == "" {
+			continue
+		}
+		switch filter.Kind {
+		case filterEquals:
+			values := []string{}
+			matchNull := false
+			for _, v := range strings.Split(value, ",") {
+				if v = strings.TrimSpace(v); v == "null" {
+					matchNull = true
+				} else if v != "" {
+					values = append(values, v)
+				}
+			}
+			switch {
+			case matchNull && len(values) > 0:
+				query = query.Where("("+filter.Column+" IN ? OR "+filter.Column+" IS NULL)", values)
+			case matchNull:
+				query = query.Where(filter.Column + " IS NULL")
+			default:
+				query = query.Where(filter.Column+" IN ?", values)
+			}
+		case filterBool:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s debe ser true o false", param)
+			}
+			query = query.Where(filter.Column+" = ?", b)
+		case filterFrom, filterTo:
+			bound, ok := parseBound(value, filter.Kind)
+			if !ok {
+				return nil, fmt.Errorf("%s debe ser una fecha YYYY-MM-DD o RFC3339", param)
+			}
+			if filter.Kind == filterFrom {
+				query = query.Where(filter.Column+" >= ?", bound)
+			} else {
+				query = query.Where(filter.Column+" <= ?", bound)
+			}
+		}
+	}
+	return query, nil
+}
+
+// parseSort reads ?sort=key,-key into sort terms.
+func parseSort(c *gin.Context, spec listSpec) ([]sortTerm, error) {
+	terms := []sortTerm{}
+	for _, key := range strings.Split(c.DefaultQuery("sort", spec.DefaultSort), ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		desc := strings.HasPrefix(key, "-")
+		expr, ok := spec.Sorts[strings.TrimPrefix(key, "-")]
+		if !ok {
+			keys := make([]string, 0, len(spec.Sorts))
+			for k := range spec.Sorts {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			return nil, fmt.Errorf("sort admite: %s", strings.Join(keys, ", "))
+		}
+		terms = append(terms, sortTerm{Expr: expr, Desc: desc})
+	}
+	return terms, nil
+}
+
+// afterCursor keeps the rows that sort after the cursor row. Its sort values
+// are read with subqueries, so the cursor itself only carries the row ID.
+func afterCursor(query *gorm.DB, table string, terms []sortTerm, cursorID string) *gorm.DB {
+	terms = append(terms, sortTerm{Expr: "id"})
+	clauses := []string{}
+	args := []interface{}{}
+	for i, term := range terms {
+		parts := []string{}
+		for _, prev := range terms[:i] {
+			parts = append(parts, fmt.Sprintf("%s = (SELECT %s FROM %s WHERE id = ?)", prev.Expr, prev.Expr, table))
+			args = append(args, cursorID)
+		}
+		op := ">"
+		if term.Desc {
+			op = "<"
+		}
+		parts = append(parts, fmt.Sprintf("%s %s (SELECT %s FROM %s WHERE id = ?)", term.Expr, op, term.Expr, table))
+		args = append(args, cursorID)
+		clauses = append(clauses, "("+strings.Join(parts, " AND ")+")")
+	}
+	return query.Where("("+strings.Join(clauses, " OR ")+")", args...)
+}
+
+// listPage loads one page of query into dest following the request's filters,
+// ?sort, ?limit and ?cursor, and returns the {data, nextCursor, total}
+// envelope. On bad parameters it answers itself and returns false.
+func listPage[T any](c *gin.Context, query *gorm.DB, spec listSpec, dest *[]T) (gin.H, bool) {
+	limit := defaultPageSize
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit debe ser un entero positivo"})
+			return nil, false
+		}
+		limit = min(n, maxPageSize)
+	}
+	query, err := applyFilters(c, query, spec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	terms, err := parseSort(c, spec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Model(new(T)).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la lista"})
+		return nil, false
+	}
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		cursorID, ok := decodeCursor(cursor)
+		var found int64
+		if ok {
+			database.DB.Table(spec.Table).Where("id = ?", cursorID).Count(&found)
+		}
+		if found == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cursor no válido"})
+			return nil, false
+		}
+		query = afterCursor(query, spec.Table, terms, cursorID)
+	}
+	for _, term := range terms {
+		if term.Desc {
+			query = query.Order(term.Expr + " DESC")
+		} else {
+			query = query.Order(term.Expr + " ASC")
+		}
+	}
+
+	// One extra row tells whether another page follows
+	if err := query.Order("id ASC").Limit(limit + 1).Find(dest).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la lista"})
+		return nil, false
+	}
+	var nextCursor *string
+	if len(*dest) > limit {
+		*dest = (*dest)[:limit]
+		next := encodeCursor(reflect.ValueOf((*dest)[limit-1]).FieldByName("ID").String())
+		nextCursor = &next
+	}
+	return gin.H{"data": *dest, "nextCursor": nextCursor, "total": total}, true
+}
+
+// visibleProjectIDs returns the projects the caller owns or is a member of.
+// all is true for admins, who see every project.
+func visibleProjectIDs(c *gin.Context) (ids []string, all bool) {
+	if middleware.IsAdmin(c) {
+		return nil, true
+	}
+	userID := c.GetString("userID")
+	ids = []string{}
+	database.DB.Model(&models.Project{}).
+		Where("owner_id = ? OR id IN (?)", userID, database.DB.Model(&models.ProjectMember{}).Select("project_id").Where("user_id = ?", userID)).
+		Pluck("id", &ids)
+	return ids, false
+}
+
+// inVisibleProjects keeps the rows whose project column points to a project the caller can see.
+func inVisibleProjects(c *gin.Context, query *gorm.DB, column string) *gorm.DB {
+	ids, all := visibleProjectIDs(c)
+	if all {
+		return query
+	}
+	return query.Where(column+" IN ?", ids)
+}
+
+// includes reports which optional relations the request asked for with ?include=a,b.
+func includes(c *gin.Context) map[string]bool {
+	included := map[string]bool{}
+	for _, name := range strings.Split(c.Query("include"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			included[name] = true
+		}
+	}
+	return included
+}
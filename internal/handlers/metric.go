@@ -29,6 +29,43 @@ func GetSprintBurndown(c *gin.Context) {
 		}
 	}
 
+	// Scope changes after the start let us rebuild the scope at any date
+	// instead of projecting today's total back over the whole sprint.
+	var changes []models.SprintScopeChange
+	database.DB.Preload("UserStory").Where("sprint_id = ?", sprintID).Order("created_at asc").Find(&changes)
+
+	scopeAt := func(date time.Time) int {
+		scope := totalPoints
+		for _, change := range changes {
+			if change.CreatedAt.After(date) {
+				scope -= change.Delta
+			}
+		}
+		return scope
+	}
+	initialScope := totalPoints
+	for _, change := range changes {
+		initialScope -= change.Delta
+	}
+
+	type ScopeEvent struct {
+		Date        string `json:"date"`
+		Type        string `json:"type"`
+		UserStoryID string `json:"userStoryId"`
+		Title       string `json:"title"`
+		Delta       int    `json:"delta"`
+	}
+	events := []ScopeEvent{}
+	for _, change := range changes {
+		events = append(events, ScopeEvent{
+			Date:        change.CreatedAt.Format(time.RFC3339),
+			Type:        change.Type,
+			UserStoryID: change.UserStoryID,
+			Title:       change.UserStory.Title,
+			Delta:       change.Delta,
+		})
+	}
+
 	// Simple Ideal vs Actual calculation logic
 	// In a real app, we need to track *when* each story was completed daily.
 	// We can use CompletedAt field.
@@ -38,19 +75,20 @@ func GetSprintBurndown(c *gin.Context) {
 		Date   string  `json:"date"`
 		Ideal  float64 `json:"ideal"`
 		Actual *int    `json:"actual"`
+		Scope  *int    `json:"scope"`
 	}
 	
 	series := []DataPoint{}
 	
 	if !sprint.StartDate.IsZero() && !sprint.EndDate.IsZero() {
 		days := int(sprint.EndDate.Sub(sprint.StartDate).Hours() / 24)
-		idealDec := float64(totalPoints) / float64(days)
+		idealDec := float64(initialScope) / float64(days)
 		
 		for i := 0; i <= days; i++ {
 			date := sprint.StartDate.Add(time.Hour * 24 * time.Duration(i))
-			ideal := math.Max(0, float64(totalPoints)-(idealDec*float64(i)))
+			ideal := math.Max(0, float64(initialScope)-(idealDec*float64(i)))
 			
-			// Actual calculation: Total - (Sum of points completed <= date)
+			// Actual calculation: Scope - (Sum of points completed <= date)
 			burned := 0
 			for _, story := range sprint.UserStories {
 				if story.CompletedAt != nil && !story.CompletedAt.After(date) {
@@ -60,10 +98,12 @@ func GetSprintBurndown(c *gin.Context) {
 				}
 			}
 			
-			var actual *int
+			var actual, scope *int
 			if date.Before(time.Now().Add(time.Hour * 24)) {
-				rem := totalPoints - burned
+				total := scopeAt(date)
+				rem := total - burned
 				actual = &rem
+				scope = &total
 			}
 			
 			series = append(series, DataPoint{
@@ -71,11 +111,12 @@ func GetSprintBurndown(c *gin.Context) {
 				Date:   date.Format("2006-01-02"),
 				Ideal:  ideal,
 				Actual: actual,
+				Scope:  scope,
 			})
 		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": gin.H{"totalPoints": totalPoints, "series": series}})
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"totalPoints": totalPoints, "initialScope": initialScope, "scopeChanges": events, "series": series}})
 }
 
 // Velocity Logic
@@ -119,8 +160,9 @@ func GetProjectContribution(c *gin.Context) {
 	projectID := c.Param("projectId")
 	
 	type Contrib struct {
-		User  models.User `json:"user"`
-		Count int         `json:"count"`
+		User    models.User `json:"user"`
+		Count   int         `json:"count"`
+		Minutes int         `json:"minutes"` // time logged on the project
 	}
 	
 	// Count completed tasks by assignee
@@ -148,6 +190,28 @@ func GetProjectContribution(c *gin.Context) {
 			results = append(results, Contrib{User: u, Count: count})
 		}
 	}
+
+	// Add logged effort, including people who worked on tasks they didn't finish
+	var logged []struct {
+		UserID  string
+		Minutes int
+	}
+	database.DB.Table("worklogs").Select("user_id, SUM(minutes) AS minutes").
+		Where("project_id = ?", projectID).Group("user_id").Scan(&logged)
+	for _, entry := range logged {
+		found := false
+		for i := range results {
+			if results[i].User.ID == entry.UserID {
+				results[i].Minutes = entry.Minutes
+				found = true
+			}
+		}
+		if !found {
+			var u models.User
+			database.DB.First(&u, "id = ?", entry.UserID)
+			results = append(results, Contrib{User: u, Minutes: entry.Minutes})
+		}
+	}
 	
 	// Sort desc
 	sort.Slice(results, func(i, j int) bool {
@@ -0,0 +1,365 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"Wrk_Api/internal/database"
+	"Wrk_Api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Flow metrics are computed from the status transitions stored in the task
+// history, so a task reopened and closed again is measured on its last completion.
+
+const dayLayout = "2006-01-02"
+
+var doneStatuses = map[string]bool{"DONE": true, "COMPLETED": true}
+
+// statusOrder sorts the cumulative flow bands from backlog to done.
+var statusOrder = map[string]int{"BACKLOG": 0, "TODO": 1, "PENDING": 2, "IN_PROGRESS": 3, "REVIEW": 4, "IN_REVIEW": 4, "BLOCKED": 5, "DONE": 9, "COMPLETED": 9}
+
+type statusTransition struct {
+	Status string
+	At     time.Time
+}
+
+type flowScope struct {
+	Tasks       []models.Task
+	Transitions map[string][]statusTransition
+	Start       time.Time
+	End         time.Time
+}
+
+// loadTransitions returns the status changes of each task in chronological order.
+// Tasks created before history tracking get a single transition at creation time.
+func loadTransitions(tasks []models.Task) map[string][]statusTransition {
+	ids := make([]string, 0, len(tasks))
+	for _, t := range tasks {
+		ids = append(ids, t.ID)
+	}
+
+	var entries []models.HistoryEntry
+	if len(ids) > 0 {
+		database.DB.Where("entity_type = ? AND field = ? AND entity_id IN ?", models.EntityTask, "status", ids).
+			Order("created_at asc").Find(&entries)
+	}
+
+	transitions := make(map[string][]statusTransition)
+	for _, e := range entries {
+		if e.NewValue != nil {
+			transitions[e.EntityID] = append(transitions[e.EntityID], statusTransition{Status: *e.NewValue, At: e.CreatedAt})
+		}
+	}
+	for _, t := range tasks {
+		if len(transitions[t.ID]) == 0 {
+			transitions[t.ID] = []statusTransition{{Status: t.Status, At: t.CreatedAt}}
+		}
+	}
+	return transitions
+}
+
+// maxFlowDays bounds the range of the flow metrics, which walk it day by day.
+const maxFlowDays = 731
+
+// parseDay parses a YYYY-MM-DD query value, returning fallback when it's empty.
+func parseDay(value string, fallback time.Time) (time.Time, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	return time.Parse(dayLayout, value)
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// loadFlowScope resolves the tasks and date range of a project or sprint request.
+func loadFlowScope(c *gin.Context) (*flowScope, bool) {
+	scope := &flowScope{End: time.Now()}
+	query := database.DB.Model(&models.Task{})
+
+	if sprintID := c.Param("sprintId"); sprintID != "" {
+		var sprint models.Sprint
+		if err := database.DB.First(&sprint, "id = ?", sprintID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Sprint no encontrado"})
+			return nil, false
+		}
+		query = query.Where("sprint_id = ?", sprintID)
+		scope.Start = sprint.StartDate
+		if !sprint.EndDate.IsZero() && sprint.EndDate.Before(scope.End) {
+			scope.End = sprint.EndDate
+		}
+	} else {
+		var project models.Project
+		if err := database.DB.First(&project, "id = ?", c.Param("projectId")).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Proyecto no encontrado"})
+			return nil, false
+		}
+		query = query.Where("project_id = ?", project.ID)
+		if project.StartDate != nil {
+			scope.Start = *project.StartDate
+		}
+	}
+
+	if err := query.Find(&scope.Tasks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener tareas"})
+		return nil, false
+	}
+	scope.Transitions = loadTransitions(scope.Tasks)
+
+	// Without explicit dates, start with the oldest task
+	if scope.Start.IsZero() {
+		scope.Start = scope.End
+		for _, t := range scope.Tasks {
+			if first := scope.Transitions[t.ID][0].At; first.Before(scope.Start) {
+				scope.Start = first
+			}
+		}
+	}
+
+	to, err := parseDay(c.Query("to"), scope.End)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to debe tener el formato YYYY-MM-DD"})
+		return nil, false
+	}
+	// Without a from date, long-lived projects show the most recent range up to to
+	if c.Query("from") == "" && to.Sub(scope.Start) > maxFlowDays*24*time.Hour {
+		scope.Start = to.AddDate(0, 0, -maxFlowDays)
+	}
+
+	from, err := parseDay(c.Query("from"), scope.Start)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from debe tener el formato YYYY-MM-DD"})
+		return nil, false
+	}
+	scope.Start, scope.End = truncateDay(from), truncateDay(to)
+	if c.Query("from") == "" && c.Query("to") != "" && scope.End.Before(scope.Start) {
+		// A to before the first activity leaves just that day
+		scope.Start = scope.End
+	}
+	if c.Query("from") != "" && scope.End.Before(scope.Start) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from no puede ser posterior a to"})
+		return nil, false
+	}
+	if scope.End.After(scope.Start.AddDate(0, 0, maxFlowDays)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("El rango no puede superar %d días", maxFlowDays)})
+		return nil, false
+	}
+	return scope, true
+}
+
+// completedAt returns when the task last entered a done status, if it is done now.
+func completedAt(transitions []statusTransition) *time.Time {
+	last := transitions[len(transitions)-1]
+	if !doneStatuses[last.Status] {
+		return nil
+	}
+	at := last.At
+	for i := len(transitions) - 2; i >= 0 && doneStatuses[transitions[i].Status]; i-- {
+		at = transitions[i].At
+	}
+	return &at
+}
+
+// startedAt returns when the task first entered IN_PROGRESS.
+func startedAt(transitions []statusTransition) *time.Time {
+	for _, t := range transitions {
+		if t.Status == "IN_PROGRESS" {
+			at := t.At
+			return &at
+		}
+	}
+	return nil
+}
+
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+// durationStats summarizes a set of durations expressed in hours.
+func durationStats(hours []float64) gin.H {
+	sorted := append([]float64{}, hours...)
+	sort.Float64s(sorted)
+	total := 0.0
+	for _, h := range sorted {
+		total += h
+	}
+	average := 0.0
+	if len(sorted) > 0 {
+		average = total / float64(len(sorted))
+	}
+	return gin.H{
+		"count":        len(sorted),
+		"averageHours": round(average),
+		"averageDays":  round(average / 24),
+		"medianHours":  round(percentile(sorted, 0.5)),
+		"p85Hours":     round(percentile(sorted, 0.85)),
+		"maxHours":     round(percentile(sorted, 1)),
+	}
+}
+
+// elapsed measures tasks completed within the scope from the start returned by from.
+func elapsed(scope *flowScope, from func(task models.Task, transitions []statusTransition) *time.Time) gin.H {
+	items := []gin.H{}
+	hours := []float64{}
+	for _, task := range scope.Tasks {
+		transitions := scope.Transitions[task.ID]
+		end := completedAt(transitions)
+		start := from(task, transitions)
+		if end == nil || start == nil || end.Before(*start) {
+			continue
+		}
+		if truncateDay(*end).Before(scope.Start) || truncateDay(*end).After(scope.End) {
+			continue
+		}
+
+		h := end.Sub(*start).Hours()
+		hours = append(hours, h)
+		items = append(items, gin.H{
+			"taskId":      task.ID,
+			"title":       task.Title,
+			"startedAt":   *start,
+			"completedAt": *end,
+			"hours":       round(h),
+			"days":        round(h / 24),
+		})
+	}
+	return gin.H{"summary": durationStats(hours), "tasks": items}
+}
+
+// GET /metrics/projects/:projectId/cycle-time
+// GET /metrics/sprints/:sprintId/cycle-time
+func GetCycleTime(c *gin.Context) {
+	scope, ok := loadFlowScope(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": elapsed(scope, func(_ models.Task, transitions []statusTransition) *time.Time {
+		return startedAt(transitions)
+	})})
+}
+
+// GET /metrics/projects/:projectId/lead-time
+// GET /metrics/sprints/:sprintId/lead-time
+func GetLeadTime(c *gin.Context) {
+	scope, ok := loadFlowScope(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": elapsed(scope, func(task models.Task, _ []statusTransition) *time.Time {
+		return &task.CreatedAt
+	})})
+}
+
+func weekStart(t time.Time) time.Time {
+	day := truncateDay(t)
+	offset := (int(day.Weekday()) + 6) % 7 // Monday based
+	return day.AddDate(0, 0, -offset)
+}
+
+// GET /metrics/projects/:projectId/throughput
+// GET /metrics/sprints/:sprintId/throughput
+func GetThroughput(c *gin.Context) {
+	scope, ok := loadFlowScope(c)
+	if !ok {
+		return
+	}
+
+	counts := make(map[string]int)
+	for _, task := range scope.Tasks {
+		if end := completedAt(scope.Transitions[task.ID]); end != nil {
+			counts[weekStart(*end).Format(dayLayout)]++
+		}
+	}
+
+	series := []gin.H{}
+	for week := weekStart(scope.Start); !week.After(scope.End); week = week.AddDate(0, 0, 7) {
+		year, number := week.ISOWeek()
+		series = append(series, gin.H{
+			"week":      fmt.Sprintf("%d-W%02d", year, number),
+			"weekStart": week.Format(dayLayout),
+			"count":     counts[week.Format(dayLayout)],
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": series})
+}
+
+// statusAt returns the status of a task at the given instant ("" if it didn't exist yet).
+func statusAt(transitions []statusTransition, at time.Time) string {
+	status := ""
+	for _, t := range transitions {
+		if t.At.After(at) {
+			break
+		}
+		status = t.Status
+	}
+	return status
+}
+
+// GET /metrics/projects/:projectId/cumulative-flow
+// GET /metrics/sprints/:sprintId/cumulative-flow
+func GetCumulativeFlow(c *gin.Context) {
+	scope, ok := loadFlowScope(c)
+	if !ok {
+		return
+	}
+
+	seen := make(map[string]bool)
+	for _, transitions := range scope.Transitions {
+		for _, t := range transitions {
+			seen[t.Status] = true
+		}
+	}
+	statuses := make([]string, 0, len(seen))
+	for status := range seen {
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		oi, iKnown := statusOrder[statuses[i]]
+		oj, jKnown := statusOrder[statuses[j]]
+		if !iKnown {
+			oi = 6
+		}
+		if !jKnown {
+			oj = 6
+		}
+		if oi != oj {
+			return oi < oj
+		}
+		return statuses[i] < statuses[j]
+	})
+
+	series := []gin.H{}
+	for day := scope.Start; !day.After(scope.End); day = day.AddDate(0, 0, 1) {
+		endOfDay := day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		counts := make(map[string]int, len(statuses))
+		for _, status := range statuses {
+			counts[status] = 0
+		}
+		for _, task := range scope.Tasks {
+			if status := statusAt(scope.Transitions[task.ID], endOfDay); status != "" {
+				counts[status]++
+			}
+		}
+		series = append(series, gin.H{"date": day.Format(dayLayout), "counts": counts})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"statuses": statuses, "series": series}})
+}
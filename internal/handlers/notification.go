@@ -1,14 +1,41 @@
 package handlers
 
 import (
+	"io"
 	"net/http"
+	"time"
 
 	"Wrk_Api/internal/database"
 	"Wrk_Api/internal/models"
+	"Wrk_Api/internal/realtime"
+	"Wrk_Api/internal/utils"
 
+	"github.com/gin-contrib/sse"
 	"github.com/gin-gonic/gin"
 )
 
+const notificationHeartbeat = 25 * time.Second
+
+// notify persists a notification and streams it to the user's open SSE connections.
+func notify(userID, title, message, notificationType string) {
+	notification := models.Notification{
+		ID:        utils.GenerateCUID(),
+		UserID:    userID,
+		Title:     title,
+		Message:   message,
+		Type:      notificationType,
+		CreatedAt: time.Now(),
+	}
+	if err := database.DB.Create(&notification).Error; err != nil {
+		return
+	}
+	realtime.Default.Publish(realtime.NotificationTopic(userID), realtime.Event{
+		ID:   notification.ID,
+		Type: "notification",
+		Data: notification,
+	})
+}
+
 // GET /api/notifications
 func GetNotifications(c *gin.Context) {
 	userID, exists := c.Get("userID")
@@ -44,3 +71,63 @@ func MarkNotificationRead(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"data": notification})
 }
+
+// GET /api/notifications/stream
+// Server-Sent Events stream of the caller's new notifications. Reconnecting
+// clients send Last-Event-ID (or ?lastEventId=) to receive what they missed.
+func StreamNotifications(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	// Subscribe before replaying so nothing created in between is lost
+	sub := realtime.Default.Subscribe(realtime.NotificationTopic(userID.(string)), realtime.SessionTopic(c.GetString("sessionID")))
+	defer sub.Close()
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("lastEventId")
+	}
+
+	var missed []models.Notification
+	if lastEventID != "" {
+		var last models.Notification
+		if database.DB.Where("id = ? AND user_id = ?", lastEventID, userID).First(&last).Error == nil {
+			database.DB.Where("user_id = ? AND (created_at > ? OR (created_at = ? AND id > ?))", userID, last.CreatedAt, last.CreatedAt, last.ID).
+				Order("created_at asc, id asc").Find(&missed)
+		}
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	sent := make(map[string]bool)
+	for _, n := range missed {
+		c.Render(-1, sse.Event{Id: n.ID, Event: "notification", Data: n})
+		sent[n.ID] = true
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(notificationHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event := <-sub.C:
+			if sent[event.ID] {
+				return true
+			}
+			c.Render(-1, sse.Event{Id: event.ID, Event: event.Type, Data: event.Data})
+			return true
+		case <-heartbeat.C:
+			io.WriteString(w, ": ping\n\n")
+			return true
+		case <-sub.Done:
+			// The session was revoked
+			return false
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
@@ -0,0 +1,459 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"Wrk_Api/internal/database"
+	"Wrk_Api/internal/middleware"
+	"Wrk_Api/internal/models"
+	"Wrk_Api/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CreatePeerReviewRoundRequest struct {
+	ProjectID string  `json:"projectId" binding:"required"`
+	SprintID  *string `json:"sprintId"`
+	RubricID  string  `json:"rubricId" binding:"required"`
+	Title     string  `json:"title" binding:"required"`
+	Anonymous bool    `json:"anonymous"`
+	DueDate   *string `json:"dueDate"`
+}
+
+type SubmitPeerReviewRequest struct {
+	RevieweeID     string          `json:"revieweeId" binding:"required"`
+	CriteriaScores []CriteriaScore `json:"criteriaScores" binding:"required,min=1"`
+	Comment        *string         `json:"comment"`
+}
+
+// teamMembers returns the people taking part in peer reviews: the project
+// owner and the members, except evaluators, who only grade.
+func teamMembers(projectID string) []models.ProjectMember {
+	var all []models.ProjectMember
+	database.DB.Preload("User").Where("project_id = ?", projectID).Order("joined_at asc").Find(&all)
+
+	var project models.Project
+	database.DB.Preload("Owner").First(&project, "id = ?", projectID)
+	ownerTakesPart := project.Owner.ID != ""
+	for _, m := range all {
+		if m.UserID == project.OwnerID {
+			ownerTakesPart = ownerTakesPart && m.Role != middleware.RoleEvaluator
+		}
+	}
+
+	members := []models.ProjectMember{}
+	if ownerTakesPart {
+		members = append(members, models.ProjectMember{ProjectID: projectID, UserID: project.OwnerID, Role: middleware.RoleOwner, User: project.Owner})
+	}
+	for _, m := range all {
+		if m.UserID != project.OwnerID && m.Role != middleware.RoleEvaluator {
+			members = append(members, m)
+		}
+	}
+	return members
+}
+
+func isTeamMember(members []models.ProjectMember, userID string) bool {
+	for _, m := range members {
+		if m.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// POST /api/peer-reviews
+func CreatePeerReviewRound(c *gin.Context) {
+	var req CreatePeerReviewRoundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.SprintID != nil {
+		var count int64
+		database.DB.Model(&models.Sprint{}).Where("id = ? AND project_id = ?", *req.SprintID, req.ProjectID).Count(&count)
+		if count == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El sprint no pertenece al proyecto"})
+			return
+		}
+	}
+
+	var rubric models.Rubric
+	if err := database.DB.Preload("Criteria").First(&rubric, "id = ?", req.RubricID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Rúbrica no encontrada"})
+		return
+	}
+	if rubric.ProjectID != nil && *rubric.ProjectID != req.ProjectID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La rúbrica no pertenece al proyecto"})
+		return
+	}
+	if len(rubric.Criteria) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La rúbrica no tiene criterios"})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	round := models.PeerReviewRound{
+		ID:          utils.GenerateCUID(),
+		ProjectID:   req.ProjectID,
+		SprintID:    req.SprintID,
+		RubricID:    rubric.ID,
+		CreatedByID: userID.(string),
+		Title:       req.Title,
+		Anonymous:   req.Anonymous,
+		Status:      models.PeerRoundOpen,
+		CreatedAt:   time.Now(),
+	}
+	if req.DueDate != nil {
+		dueDate, err := time.Parse(time.RFC3339, *req.DueDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Fecha límite inválida"})
+			return
+		}
+		round.DueDate = &dueDate
+	}
+
+	if result := database.DB.Create(&round); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear la ronda de coevaluación"})
+		return
+	}
+
+	for _, m := range teamMembers(round.ProjectID) {
+		notify(m.UserID, "Coevaluación Abierta", "Evalúa a tu equipo en \""+round.Title+"\"", "PEER_REVIEW")
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": round})
+}
+
+// GET /api/peer-reviews/project/:projectId
+func GetProjectPeerReviewRounds(c *gin.Context) {
+	projectID := c.Param("projectId")
+	var rounds []models.PeerReviewRound
+	database.DB.Preload("Sprint").Where("project_id = ?", projectID).Order("created_at desc").Find(&rounds)
+	c.JSON(http.StatusOK, gin.H{"data": rounds})
+}
+
+// GET /api/peer-reviews/:id
+// Includes the teammates the caller still has to review.
+func GetPeerReviewRound(c *gin.Context) {
+	var round models.PeerReviewRound
+	if err := database.DB.Preload("Rubric.Criteria").Preload("Sprint").First(&round, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ronda no encontrada"})
+		return
+	}
+
+	userID := c.GetString("userID")
+	var reviewed []string
+	database.DB.Model(&models.PeerReview{}).Where("round_id = ? AND reviewer_id = ?", round.ID, userID).Pluck("reviewee_id", &reviewed)
+	done := make(map[string]bool, len(reviewed))
+	for _, id := range reviewed {
+		done[id] = true
+	}
+
+	members := teamMembers(round.ProjectID)
+	pending := []gin.H{}
+	if isTeamMember(members, userID) {
+		for _, m := range members {
+			if !done[m.UserID] {
+				pending = append(pending, gin.H{"id": m.UserID, "name": m.User.Name, "self": m.UserID == userID})
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"round": round, "pending": pending}})
+}
+
+// POST /api/peer-reviews/:id/reviews
+// Creates or replaces the caller's review of a teammate.
+func SubmitPeerReview(c *gin.Context) {
+	var req SubmitPeerReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var round models.PeerReviewRound
+	if err := database.DB.First(&round, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ronda no encontrada"})
+		return
+	}
+	if round.Status != models.PeerRoundOpen || (round.DueDate != nil && time.Now().After(*round.DueDate)) {
+		c.JSON(http.StatusConflict, gin.H{"error": "La ronda de coevaluación está cerrada"})
+		return
+	}
+
+	userID := c.GetString("userID")
+	members := teamMembers(round.ProjectID)
+	if !isTeamMember(members, userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Solo los miembros del equipo pueden coevaluar"})
+		return
+	}
+	if !isTeamMember(members, req.RevieweeID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El evaluado no pertenece al equipo"})
+		return
+	}
+
+	_, score, err := gradeCriteria(round.ProjectID, &round.RubricID, req.CriteriaScores, false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	review := models.PeerReview{
+		ID:         utils.GenerateCUID(),
+		RoundID:    round.ID,
+		ReviewerID: userID,
+		RevieweeID: req.RevieweeID,
+		CreatedAt:  time.Now(),
+	}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var existing []models.PeerReview
+		tx.Where("round_id = ? AND reviewer_id = ? AND reviewee_id = ?", round.ID, userID, req.RevieweeID).Limit(1).Find(&existing)
+		if len(existing) > 0 {
+			review = existing[0]
+		}
+		review.Score = *score
+		review.Comment = req.Comment
+		if err := tx.Save(&review).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("review_id = ?", review.ID).Delete(&models.PeerReviewScore{}).Error; err != nil {
+			return err
+		}
+		for _, cs := range req.CriteriaScores {
+			if err := tx.Create(&models.PeerReviewScore{
+				ID:         utils.GenerateCUID(),
+				ReviewID:   review.ID,
+				CriteriaID: cs.CriteriaID,
+				Score:      cs.Score,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al guardar la coevaluación"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": gin.H{"id": review.ID, "score": review.Score}})
+}
+
+// POST /api/peer-reviews/:id/close
+func ClosePeerReviewRound(c *gin.Context) {
+	var round models.PeerReviewRound
+	if err := database.DB.First(&round, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ronda no encontrada"})
+		return
+	}
+	if round.Status == models.PeerRoundClosed {
+		c.JSON(http.StatusConflict, gin.H{"error": "La ronda ya está cerrada"})
+		return
+	}
+
+	now := time.Now()
+	database.DB.Model(&round).Updates(map[string]interface{}{"status": models.PeerRoundClosed, "closed_at": now})
+
+	for _, m := range teamMembers(round.ProjectID) {
+		notify(m.UserID, "Resultados de Coevaluación", "Ya están disponibles los resultados de \""+round.Title+"\"", "PEER_REVIEW")
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": round})
+}
+
+// GET /api/peer-reviews/:id/progress
+func GetPeerReviewProgress(c *gin.Context) {
+	var round models.PeerReviewRound
+	if err := database.DB.First(&round, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ronda no encontrada"})
+		return
+	}
+
+	type reviewerCount struct {
+		ReviewerID string
+		Count      int
+	}
+	var counts []reviewerCount
+	database.DB.Model(&models.PeerReview{}).Select("reviewer_id, COUNT(*) as count").
+		Where("round_id = ?", round.ID).Group("reviewer_id").Scan(&counts)
+	submitted := make(map[string]int, len(counts))
+	for _, rc := range counts {
+		submitted[rc.ReviewerID] = rc.Count
+	}
+
+	members := teamMembers(round.ProjectID)
+	expected := len(members)
+	progress := []gin.H{}
+	completed := 0
+	for _, m := range members {
+		done := submitted[m.UserID] >= expected
+		if done {
+			completed++
+		}
+		progress = append(progress, gin.H{
+			"userId":    m.UserID,
+			"name":      m.User.Name,
+			"submitted": submitted[m.UserID],
+			"expected":  expected,
+			"completed": done,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"roundId":   round.ID,
+		"status":    round.Status,
+		"members":   progress,
+		"completed": completed,
+		"total":     len(members),
+	}})
+}
+
+// peerResults aggregates the reviews of a round per reviewee. Reviewer identities
+// are left out when the round is anonymous and the caller doesn't evaluate.
+func peerResults(reviewRound models.PeerReviewRound, showReviewers bool, revieweeID string) []gin.H {
+	var criteria []models.Criteria
+	database.DB.Where("rubric_id = ?", reviewRound.RubricID).Order("name asc").Find(&criteria)
+
+	query := database.DB.Preload("Scores").Preload("Reviewer").Preload("Reviewee").Where("round_id = ?", reviewRound.ID)
+	if revieweeID != "" {
+		query = query.Where("reviewee_id = ?", revieweeID)
+	}
+	var reviews []models.PeerReview
+	query.Order("created_at asc").Find(&reviews)
+
+	type aggregate struct {
+		reviewee   models.User
+		peerTotal  int
+		peerCount  int
+		self       *int
+		critTotals map[string]int
+		critCounts map[string]int
+		selfScores map[string]int
+		comments   []gin.H
+	}
+	byReviewee := map[string]*aggregate{}
+	order := []string{}
+	for _, review := range reviews {
+		agg, ok := byReviewee[review.RevieweeID]
+		if !ok {
+			agg = &aggregate{
+				reviewee:   review.Reviewee,
+				critTotals: map[string]int{},
+				critCounts: map[string]int{},
+				selfScores: map[string]int{},
+			}
+			byReviewee[review.RevieweeID] = agg
+			order = append(order, review.RevieweeID)
+		}
+
+		isSelf := review.ReviewerID == review.RevieweeID
+		if isSelf {
+			score := review.Score
+			agg.self = &score
+		} else {
+			agg.peerTotal += review.Score
+			agg.peerCount++
+		}
+		for _, s := range review.Scores {
+			if isSelf {
+				agg.selfScores[s.CriteriaID] = s.Score
+			} else {
+				agg.critTotals[s.CriteriaID] += s.Score
+				agg.critCounts[s.CriteriaID]++
+			}
+		}
+
+		if review.Comment != nil && *review.Comment != "" {
+			comment := gin.H{"comment": *review.Comment, "self": isSelf}
+			if showReviewers || !reviewRound.Anonymous {
+				comment["reviewer"] = gin.H{"id": review.Reviewer.ID, "name": review.Reviewer.Name}
+			}
+			agg.comments = append(agg.comments, comment)
+		}
+	}
+
+	results := []gin.H{}
+	for _, id := range order {
+		agg := byReviewee[id]
+		var peerAverage *float64
+		if agg.peerCount > 0 {
+			avg := round(float64(agg.peerTotal) / float64(agg.peerCount))
+			peerAverage = &avg
+		}
+
+		perCriteria := []gin.H{}
+		for _, crit := range criteria {
+			entry := gin.H{"criteriaId": crit.ID, "name": crit.Name, "maxScore": crit.MaxScore, "peerAverage": nil, "selfScore": nil}
+			if n := agg.critCounts[crit.ID]; n > 0 {
+				entry["peerAverage"] = round(float64(agg.critTotals[crit.ID]) / float64(n))
+			}
+			if s, ok := agg.selfScores[crit.ID]; ok {
+				entry["selfScore"] = s
+			}
+			perCriteria = append(perCriteria, entry)
+		}
+
+		if agg.comments == nil {
+			agg.comments = []gin.H{}
+		}
+		results = append(results, gin.H{
+			"roundId":     reviewRound.ID,
+			"sprintId":    reviewRound.SprintID,
+			"student":     gin.H{"id": agg.reviewee.ID, "name": agg.reviewee.Name},
+			"peerAverage": peerAverage,
+			"peerCount":   agg.peerCount,
+			"selfScore":   agg.self,
+			"criteria":    perCriteria,
+			"comments":    agg.comments,
+		})
+	}
+	return results
+}
+
+// GET /api/peer-reviews/:id/results
+// Evaluators see every student at any time; students only see their own
+// results once the round is closed.
+func GetPeerReviewResults(c *gin.Context) {
+	var round models.PeerReviewRound
+	if err := database.DB.First(&round, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ronda no encontrada"})
+		return
+	}
+
+	if middleware.Can(c, round.ProjectID, middleware.PermEvaluate) {
+		c.JSON(http.StatusOK, gin.H{"data": peerResults(round, true, "")})
+		return
+	}
+	if round.Status != models.PeerRoundClosed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Los resultados estarán disponibles al cerrar la ronda"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": peerResults(round, false, c.GetString("userID"))})
+}
+
+// studentPeerResults gathers a student's peer-review results across their projects,
+// following the same visibility rules as GetPeerReviewResults.
+func studentPeerResults(c *gin.Context, studentID string) []gin.H {
+	var rounds []models.PeerReviewRound
+	database.DB.Where("project_id IN (?) OR project_id IN (?)",
+		database.DB.Model(&models.ProjectMember{}).Select("project_id").Where("user_id = ?", studentID),
+		database.DB.Model(&models.Project{}).Select("id").Where("owner_id = ?", studentID)).
+		Order("created_at desc").Find(&rounds)
+
+	// Others only see the rounds of projects where they evaluate
+	self := c.GetString("userID") == studentID
+	results := []gin.H{}
+	for _, round := range rounds {
+		evaluator := middleware.Can(c, round.ProjectID, middleware.PermEvaluate)
+		if !evaluator && (!self || round.Status != models.PeerRoundClosed) {
+			continue
+		}
+		results = append(results, peerResults(round, evaluator, studentID)...)
+	}
+	return results
+}
@@ -9,9 +9,12 @@ import (
 
 	"Wrk_Api/internal/database"
 	"Wrk_Api/internal/models"
+	"Wrk_Api/internal/realtime"
+	"Wrk_Api/internal/storage"
 	"Wrk_Api/internal/utils"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type CreateProjectRequest struct {
@@ -39,7 +42,7 @@ func GetAllProjects(c *gin.Context) {
 	memberID := c.Query("memberId")
 	var projects []models.Project
 
-	query := database.DB.Preload("Owner").Preload("Members").Preload("Sprints")
+	query := inVisibleProjects(c, database.DB.Preload("Owner").Preload("Members").Preload("Sprints"), "projects.id")
 
 	if memberID != "" {
 		// OR: [ { ownerId: memberId }, { members: { some: { userId: memberId } } } ]
@@ -149,10 +152,21 @@ func UpdateProject(c *gin.Context) {
 
 func DeleteProject(c *gin.Context) {
 	id := c.Param("id")
-	if result := database.DB.Delete(&models.Project{}, "id = ?", id); result.Error != nil {
+	var storageKeys []string
+	database.DB.Model(&models.Document{}).Where("project_id = ? AND storage_key <> ''", id).Pluck("storage_key", &storageKeys)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := deleteProjectRows(tx, c, id); err != nil {
+			return err
+		}
+		return tx.Delete(&models.Project{}, "id = ?", id).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar proyecto"})
 		return
 	}
+	for _, key := range storageKeys {
+		storage.Default.Delete(c.Request.Context(), key)
+	}
 	c.JSON(http.StatusOK, gin.H{"data": gin.H{"message": "Proyecto eliminado"}})
 }
 
@@ -190,15 +204,9 @@ func AddProjectMember(c *gin.Context) {
 	var project models.Project
 	database.DB.First(&project, "id = ?", projectID)
 	
-	notification := models.Notification{
-		ID:        utils.GenerateCUID(),
-		UserID:    req.UserID,
-		Title:     "Nuevo Proyecto Asignado",
-		Message:   "Has sido añadido al proyecto \"" + project.Name + "\" como " + req.Role,
-		Type:      "PROJECT_ASSIGNED",
-		CreatedAt: time.Now(),
-	}
-	database.DB.Create(&notification)
+	notify(req.UserID, "Nuevo Proyecto Asignado", "Has sido añadido al proyecto \""+project.Name+"\" como "+req.Role, "PROJECT_ASSIGNED")
+
+	realtime.Default.Publish(realtime.UserTopic(req.UserID), realtime.Event{Type: "project.joined", Data: gin.H{"projectId": projectID}})
 
 	c.JSON(http.StatusCreated, gin.H{"data": member})
 }
@@ -212,6 +220,8 @@ func RemoveProjectMember(c *gin.Context) {
 		return
 	}
 
+	realtime.Default.Publish(realtime.UserTopic(userID), realtime.Event{Type: "project.left", Data: gin.H{"projectId": projectID}})
+
 	c.JSON(http.StatusOK, gin.H{"message": "Miembro eliminado del proyecto"})
 }
 
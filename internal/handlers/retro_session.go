@@ -0,0 +1,533 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"Wrk_Api/internal/database"
+	"Wrk_Api/internal/models"
+	"Wrk_Api/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CreateRetroSessionRequest struct {
+	SprintID   string `json:"sprintId" binding:"required"`
+	Title      string `json:"title"`
+	Template   string `json:"template"` // defaults to the project's format
+	VoteBudget *int   `json:"voteBudget"`
+}
+
+type CreateSessionItemRequest struct {
+	Type      string `json:"type" binding:"required"` // one of the session template columns
+	Content   string `json:"content" binding:"required"`
+	Anonymous bool   `json:"anonymous"`
+}
+
+type CreateRetroGroupRequest struct {
+	Title   string   `json:"title" binding:"required"`
+	ItemIDs []string `json:"itemIds"`
+}
+
+type GroupItemRequest struct {
+	GroupID *string `json:"groupId"` // null takes the item out of its group
+}
+
+type RetroVoteRequest struct {
+	TargetID string `json:"targetId" binding:"required"` // an ungrouped item or a group
+}
+
+type ConvertActionRequest struct {
+	AssigneeID string  `json:"assigneeId" binding:"required"`
+	Title      string  `json:"title"`
+	SprintID   *string `json:"sprintId"`
+	Deadline   *string `json:"deadline"`
+}
+
+const (
+	retroTargetItem  = "ITEM"
+	retroTargetGroup = "GROUP"
+)
+
+// loadRetroSession loads the session in the :id param and checks it is in one of the phases.
+func loadRetroSession(c *gin.Context, phases ...string) (models.RetroSession, bool) {
+	var session models.RetroSession
+	if err := database.DB.First(&session, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sesión de retrospectiva no encontrada"})
+		return session, false
+	}
+	if len(phases) > 0 && !containsString(phases, session.Phase) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Acción no disponible en la fase " + session.Phase, "phase": session.Phase})
+		return session, false
+	}
+	return session, true
+}
+
+// POST /api/retro-sessions
+func CreateRetroSession(c *gin.Context) {
+	var req CreateRetroSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	budget := 3
+	if req.VoteBudget != nil {
+		if *req.VoteBudget < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "voteBudget debe ser al menos 1"})
+			return
+		}
+		budget = *req.VoteBudget
+	}
+
+	var sprint models.Sprint
+	if err := database.DB.First(&sprint, "id = ?", req.SprintID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sprint no encontrado"})
+		return
+	}
+	var existing int64
+	database.DB.Model(&models.RetroSession{}).Where("sprint_id = ?", sprint.ID).Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "El sprint ya tiene una sesión de retrospectiva"})
+		return
+	}
+
+	template := sprintRetroTemplate(sprint)
+	if req.Template != "" {
+		var ok bool
+		if template, ok = models.FindRetroTemplate(strings.ToUpper(req.Template)); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Plantilla de retrospectiva no válida"})
+			return
+		}
+	}
+
+	title := req.Title
+	if title == "" {
+		title = "Retrospectiva " + sprint.Name
+	}
+	session := models.RetroSession{
+		ID:         utils.GenerateCUID(),
+		ProjectID:  sprint.ProjectID,
+		SprintID:   sprint.ID,
+		Title:      title,
+		Template:   template.Key,
+		Phase:      models.RetroPhaseCollect,
+		VoteBudget: budget,
+	}
+	if userID := c.GetString("userID"); userID != "" {
+		session.FacilitatorID = &userID
+	}
+	if result := database.DB.Create(&session); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear la sesión"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": session})
+}
+
+type RetroBoardItem struct {
+	models.RetrospectiveItem
+	Votes *int `json:"votes"` // hidden until voting ends
+}
+
+type RetroBoardGroup struct {
+	models.RetroGroup
+	Items []RetroBoardItem `json:"items"`
+	Votes *int             `json:"votes"`
+}
+
+// retroBoard assembles a session for the caller. Vote totals stay hidden
+// during the VOTE phase so early dots don't sway the rest.
+func retroBoard(c *gin.Context, session models.RetroSession) gin.H {
+	userID := c.GetString("userID")
+	var items []models.RetrospectiveItem
+	database.DB.Preload("User").Preload("Task").Where("session_id = ?", session.ID).Order("created_at asc").Find(&items)
+	hideAnonymous(c, items)
+	var groups []models.RetroGroup
+	database.DB.Where("session_id = ?", session.ID).Order("created_at asc").Find(&groups)
+	var votes []models.RetroVote
+	database.DB.Where("session_id = ?", session.ID).Find(&votes)
+
+	reveal := session.Phase == models.RetroPhaseDiscuss || session.Phase == models.RetroPhaseClosed
+	totals := map[string]int{}
+	myVotes := map[string]int{}
+	used := 0
+	for _, vote := range votes {
+		totals[vote.TargetID]++
+		if vote.UserID == userID {
+			myVotes[vote.TargetID]++
+			used++
+		}
+	}
+	count := func(id string) *int {
+		if !reveal {
+			return nil
+		}
+		n := totals[id]
+		return &n
+	}
+
+	boardGroups := []RetroBoardGroup{}
+	groupIndex := map[string]int{}
+	for _, group := range groups {
+		groupIndex[group.ID] = len(boardGroups)
+		boardGroups = append(boardGroups, RetroBoardGroup{RetroGroup: group, Items: []RetroBoardItem{}, Votes: count(group.ID)})
+	}
+	ungrouped := []RetroBoardItem{}
+	for _, item := range items {
+		if item.GroupID != nil {
+			if i, ok := groupIndex[*item.GroupID]; ok {
+				boardGroups[i].Items = append(boardGroups[i].Items, RetroBoardItem{RetrospectiveItem: item})
+				continue
+			}
+		}
+		ungrouped = append(ungrouped, RetroBoardItem{RetrospectiveItem: item, Votes: count(item.ID)})
+	}
+
+	// Once revealed, the most voted topics come first
+	if reveal {
+		sort.SliceStable(boardGroups, func(i, j int) bool { return *boardGroups[i].Votes > *boardGroups[j].Votes })
+		sort.SliceStable(ungrouped, func(i, j int) bool { return *ungrouped[i].Votes > *ungrouped[j].Votes })
+	}
+
+	template, _ := models.FindRetroTemplate(session.Template)
+	return gin.H{
+		"session":        session,
+		"columns":        template.Columns,
+		"groups":         boardGroups,
+		"items":          ungrouped,
+		"myVotes":        myVotes,
+		"votesRemaining": session.VoteBudget - used,
+		"votesRevealed":  reveal,
+	}
+}
+
+// GET /api/retro-sessions/:id
+func GetRetroSession(c *gin.Context) {
+	session, ok := loadRetroSession(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": retroBoard(c, session)})
+}
+
+// GET /api/retro-sessions/sprint/:sprintId
+func GetSprintRetroSession(c *gin.Context) {
+	var session models.RetroSession
+	if err := database.DB.First(&session, "sprint_id = ?", c.Param("sprintId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "El sprint no tiene sesión de retrospectiva"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": retroBoard(c, session)})
+}
+
+// POST /api/retro-sessions/:id/advance
+// Moves the session to the next phase: COLLECT -> GROUP -> VOTE -> DISCUSS -> CLOSED.
+func AdvanceRetroSession(c *gin.Context) {
+	session, ok := loadRetroSession(c)
+	if !ok {
+		return
+	}
+	if session.Phase == models.RetroPhaseClosed {
+		c.JSON(http.StatusConflict, gin.H{"error": "La sesión ya está cerrada"})
+		return
+	}
+
+	next := models.RetroPhases[0]
+	for i, phase := range models.RetroPhases {
+		if phase == session.Phase && i+1 < len(models.RetroPhases) {
+			next = models.RetroPhases[i+1]
+		}
+	}
+	updates := map[string]interface{}{"phase": next}
+	if next == models.RetroPhaseClosed {
+		updates["closed_at"] = time.Now()
+	}
+	if result := database.DB.Model(&session).Updates(updates); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al cambiar de fase"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": session})
+}
+
+// POST /api/retro-sessions/:id/items
+// Notes are collected in the COLLECT phase; ACTION items can also be added while discussing.
+func CreateSessionItem(c *gin.Context) {
+	var req CreateSessionItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	phases := []string{models.RetroPhaseCollect}
+	if strings.EqualFold(req.Type, models.RetroActionType) {
+		phases = append(phases, models.RetroPhaseDiscuss)
+	}
+	session, ok := loadRetroSession(c, phases...)
+	if !ok {
+		return
+	}
+	template, _ := models.FindRetroTemplate(session.Template)
+	itemType, ok := checkRetroType(c, template, req.Type)
+	if !ok {
+		return
+	}
+
+	item := models.RetrospectiveItem{
+		ID:        utils.GenerateCUID(),
+		SprintID:  session.SprintID,
+		SessionID: &session.ID,
+		Type:      itemType,
+		Content:   req.Content,
+		UserID:    c.GetString("userID"),
+		Anonymous: req.Anonymous,
+		CreatedAt: time.Now(),
+	}
+	if result := database.DB.Create(&item); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear item"})
+		return
+	}
+	database.DB.Preload("User").First(&item, "id = ?", item.ID)
+	items := []models.RetrospectiveItem{item}
+	hideAnonymous(c, items)
+	c.JSON(http.StatusCreated, gin.H{"data": items[0]})
+}
+
+// sessionItemIDs returns which of ids are items of the session.
+func sessionItemIDs(sessionID string, ids []string) []string {
+	found := []string{}
+	if len(ids) > 0 {
+		database.DB.Model(&models.RetrospectiveItem{}).Where("session_id = ? AND id IN ?", sessionID, ids).Pluck("id", &found)
+	}
+	return found
+}
+
+// POST /api/retro-sessions/:id/groups
+func CreateRetroGroup(c *gin.Context) {
+	var req CreateRetroGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	session, ok := loadRetroSession(c, models.RetroPhaseGroup)
+	if !ok {
+		return
+	}
+	if len(sessionItemIDs(session.ID, req.ItemIDs)) != len(req.ItemIDs) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Todos los items deben pertenecer a la sesión"})
+		return
+	}
+
+	group := models.RetroGroup{ID: utils.GenerateCUID(), SessionID: session.ID, Title: req.Title}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&group).Error; err != nil {
+			return err
+		}
+		if len(req.ItemIDs) == 0 {
+			return nil
+		}
+		return tx.Model(&models.RetrospectiveItem{}).Where("id IN ?", req.ItemIDs).Update("group_id", group.ID).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear el grupo"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": group})
+}
+
+// PUT /api/retro-sessions/:id/items/:itemId/group
+func SetRetroItemGroup(c *gin.Context) {
+	var req GroupItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	session, ok := loadRetroSession(c, models.RetroPhaseGroup)
+	if !ok {
+		return
+	}
+	if len(sessionItemIDs(session.ID, []string{c.Param("itemId")})) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item no encontrado en la sesión"})
+		return
+	}
+	if req.GroupID != nil {
+		var count int64
+		database.DB.Model(&models.RetroGroup{}).Where("id = ? AND session_id = ?", *req.GroupID, session.ID).Count(&count)
+		if count == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Grupo no encontrado en la sesión"})
+			return
+		}
+	}
+
+	if result := database.DB.Model(&models.RetrospectiveItem{}).Where("id = ?", c.Param("itemId")).Update("group_id", req.GroupID); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al agrupar el item"})
+		return
+	}
+	// Groups left empty disappear
+	database.DB.Where("session_id = ? AND NOT EXISTS (SELECT 1 FROM retrospective_items WHERE retrospective_items.group_id = retro_groups.id)", session.ID).
+		Delete(&models.RetroGroup{})
+	c.JSON(http.StatusOK, gin.H{"data": retroBoard(c, session)})
+}
+
+// retroVoteTarget resolves what a dot lands on: a group, or an item outside any group.
+func retroVoteTarget(sessionID, targetID string) (string, bool) {
+	var groups int64
+	database.DB.Model(&models.RetroGroup{}).Where("id = ? AND session_id = ?", targetID, sessionID).Count(&groups)
+	if groups > 0 {
+		return retroTargetGroup, true
+	}
+	var items int64
+	database.DB.Model(&models.RetrospectiveItem{}).Where("id = ? AND session_id = ? AND group_id IS NULL", targetID, sessionID).Count(&items)
+	return retroTargetItem, items > 0
+}
+
+// POST /api/retro-sessions/:id/votes
+// Places one dot; several dots may go to the same target within the budget.
+func CastRetroVote(c *gin.Context) {
+	var req RetroVoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	session, ok := loadRetroSession(c, models.RetroPhaseVote)
+	if !ok {
+		return
+	}
+	targetType, found := retroVoteTarget(session.ID, req.TargetID)
+	if !found {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solo se vota por grupos o por items sin agrupar de la sesión"})
+		return
+	}
+
+	userID := c.GetString("userID")
+	exceeded := false
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var used int64
+		tx.Model(&models.RetroVote{}).Where("session_id = ? AND user_id = ?", session.ID, userID).Count(&used)
+		if int(used) >= session.VoteBudget {
+			exceeded = true
+			return nil
+		}
+		return tx.Create(&models.RetroVote{
+			ID:         utils.GenerateCUID(),
+			SessionID:  session.ID,
+			UserID:     userID,
+			TargetType: targetType,
+			TargetID:   req.TargetID,
+		}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al votar"})
+		return
+	}
+	if exceeded {
+		c.JSON(http.StatusConflict, gin.H{"error": "Ya usaste todos tus votos", "code": "VOTE_BUDGET_EXCEEDED"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": retroBoard(c, session)})
+}
+
+// DELETE /api/retro-sessions/:id/votes/:targetId
+// Takes back one of the caller's dots from the target.
+func RemoveRetroVote(c *gin.Context) {
+	session, ok := loadRetroSession(c, models.RetroPhaseVote)
+	if !ok {
+		return
+	}
+	userID := c.GetString("userID")
+	var vote models.RetroVote
+	if err := database.DB.Where("session_id = ? AND user_id = ? AND target_id = ?", session.ID, userID, c.Param("targetId")).First(&vote).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No tienes votos en ese elemento"})
+		return
+	}
+	database.DB.Delete(&vote)
+	c.JSON(http.StatusOK, gin.H{"data": retroBoard(c, session)})
+}
+
+// POST /api/retro-sessions/:id/items/:itemId/convert
+// Turns an ACTION item into a task of the project assigned to a member.
+func ConvertRetroAction(c *gin.Context) {
+	var req ConvertActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	session, ok := loadRetroSession(c, models.RetroPhaseDiscuss, models.RetroPhaseClosed)
+	if !ok {
+		return
+	}
+
+	var item models.RetrospectiveItem
+	if err := database.DB.Preload("Task").First(&item, "id = ? AND session_id = ?", c.Param("itemId"), session.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item no encontrado en la sesión"})
+		return
+	}
+	if item.Type != models.RetroActionType {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solo los items ACTION se convierten en tareas"})
+		return
+	}
+	if item.Task != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "El item ya se convirtió en tarea", "data": item.Task})
+		return
+	}
+	if !isProjectPerson(session.ProjectID, req.AssigneeID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El responsable debe ser miembro del proyecto"})
+		return
+	}
+	if req.SprintID != nil {
+		var count int64
+		database.DB.Model(&models.Sprint{}).Where("id = ? AND project_id = ?", *req.SprintID, session.ProjectID).Count(&count)
+		if count == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El sprint no pertenece al proyecto"})
+			return
+		}
+	}
+
+	title := req.Title
+	if title == "" {
+		title = item.Content
+	}
+	description := "Acción de la retrospectiva \"" + session.Title + "\": " + item.Content
+	task := models.Task{
+		ID:          utils.GenerateCUID(),
+		ProjectID:   session.ProjectID,
+		SprintID:    req.SprintID,
+		Title:       title,
+		Description: &description,
+		Priority:    "MEDIUM",
+		Status:      "TODO",
+		AssigneeID:  &req.AssigneeID,
+		RetroItemID: &item.ID,
+	}
+	if req.Deadline != nil {
+		deadline, err := time.Parse(time.RFC3339, *req.Deadline)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "deadline debe estar en formato RFC3339"})
+			return
+		}
+		task.Deadline = &deadline
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&task).Error; err != nil {
+			return err
+		}
+		return recordHistory(tx, c, models.EntityTask, task.ID, task.ProjectID, nil, taskSnapshot(task))
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear la tarea"})
+		return
+	}
+	notify(req.AssigneeID, "Nueva Tarea Asignada", "Se te ha asignado la tarea: "+task.Title, "TASK_ASSIGNED")
+	c.JSON(http.StatusCreated, gin.H{"data": task})
+}
+
+// isProjectPerson reports whether the user owns or is a member of the project.
+func isProjectPerson(projectID, userID string) bool {
+	for _, user := range projectPeople(projectID) {
+		if user.ID == userID {
+			return true
+		}
+	}
+	return false
+}
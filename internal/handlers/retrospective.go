@@ -2,20 +2,73 @@ package handlers
 
 import (
 	"net/http"
+	"strings"
 	"time"
 
 	"Wrk_Api/internal/database"
+	"Wrk_Api/internal/middleware"
 	"Wrk_Api/internal/models"
 	"Wrk_Api/internal/utils"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type CreateRetroItemRequest struct {
-	SprintID string `json:"sprintId" binding:"required"`
-	Type     string `json:"type" binding:"required"` // GOOD, BAD, ACTION
-	Content  string `json:"content" binding:"required"`
-	UserID   string `json:"userId" binding:"required"`
+	SprintID  string `json:"sprintId" binding:"required"`
+	Type      string `json:"type" binding:"required"` // one of the template columns
+	Content   string `json:"content" binding:"required"`
+	Anonymous bool   `json:"anonymous"`
+}
+
+type UpdateRetroTemplateRequest struct {
+	Template string `json:"template" binding:"required"`
+}
+
+// sprintRetroTemplate returns the format of a sprint's retrospective: the one
+// its session was opened with, or else the project's current one.
+func sprintRetroTemplate(sprint models.Sprint) models.RetroTemplate {
+	var sessions []models.RetroSession
+	database.DB.Where("sprint_id = ?", sprint.ID).Limit(1).Find(&sessions)
+	if len(sessions) > 0 {
+		if template, ok := models.FindRetroTemplate(sessions[0].Template); ok {
+			return template
+		}
+	}
+	var project models.Project
+	database.DB.First(&project, "id = ?", sprint.ProjectID)
+	template, ok := models.FindRetroTemplate(project.RetroTemplate)
+	if !ok {
+		template, _ = models.FindRetroTemplate("")
+	}
+	return template
+}
+
+// checkRetroType normalizes an item type and checks it is a column of the template.
+func checkRetroType(c *gin.Context, template models.RetroTemplate, itemType string) (string, bool) {
+	itemType = strings.ToUpper(strings.TrimSpace(itemType))
+	if !template.HasType(itemType) {
+		types := []string{}
+		for _, column := range template.Columns {
+			types = append(types, column.Type)
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type debe ser uno de: " + strings.Join(types, ", "), "template": template.Key})
+		return "", false
+	}
+	return itemType, true
+}
+
+// hideAnonymous blanks the author of anonymous items unless the caller is an admin.
+func hideAnonymous(c *gin.Context, items []models.RetrospectiveItem) {
+	if middleware.IsAdmin(c) {
+		return
+	}
+	for i := range items {
+		if items[i].Anonymous {
+			items[i].UserID = ""
+			items[i].User = models.User{}
+		}
+	}
 }
 
 func GetSprintRetrospective(c *gin.Context) {
@@ -26,6 +79,7 @@ func GetSprintRetrospective(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener retrospectiva"})
 		return
 	}
+	hideAnonymous(c, items)
 	c.JSON(http.StatusOK, gin.H{"data": items})
 }
 
@@ -36,12 +90,23 @@ func CreateRetrospectiveItem(c *gin.Context) {
 		return
 	}
 
+	var sprint models.Sprint
+	if err := database.DB.First(&sprint, "id = ?", req.SprintID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sprint no encontrado"})
+		return
+	}
+	itemType, ok := checkRetroType(c, sprintRetroTemplate(sprint), req.Type)
+	if !ok {
+		return
+	}
+
 	item := models.RetrospectiveItem{
 		ID:        utils.GenerateCUID(),
-		SprintID:  req.SprintID,
-		Type:      req.Type,
+		SprintID:  sprint.ID,
+		Type:      itemType,
 		Content:   req.Content,
-		UserID:    req.UserID,
+		UserID:    c.GetString("userID"),
+		Anonymous: req.Anonymous,
 		CreatedAt: time.Now(),
 	}
 
@@ -51,14 +116,72 @@ func CreateRetrospectiveItem(c *gin.Context) {
 	}
 	
 	database.DB.Preload("User").First(&item, "id = ?", item.ID)
-	c.JSON(http.StatusCreated, gin.H{"data": item})
+	items := []models.RetrospectiveItem{item}
+	hideAnonymous(c, items)
+	c.JSON(http.StatusCreated, gin.H{"data": items[0]})
 }
 
+// DELETE /api/retrospectives/:id
+// Only the author or a project manager can delete an item.
 func DeleteRetrospectiveItem(c *gin.Context) {
-	id := c.Param("id")
-	if result := database.DB.Delete(&models.RetrospectiveItem{}, "id = ?", id); result.Error != nil {
+	var item models.RetrospectiveItem
+	if err := database.DB.Preload("Sprint").First(&item, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item no encontrado"})
+		return
+	}
+	if item.UserID != c.GetString("userID") && !middleware.Can(c, item.Sprint.ProjectID, middleware.PermProjectManage) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Solo el autor puede eliminar el item"})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Tasks created from the item stay
+		if err := tx.Model(&models.Task{}).Where("retro_item_id = ?", item.ID).Update("retro_item_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&item).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar item"})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Item eliminado"})
 }
+
+// GET /api/projects/:id/retro-template
+// The project's retrospective format along with every available one.
+func GetProjectRetroTemplate(c *gin.Context) {
+	var project models.Project
+	if err := database.DB.First(&project, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Proyecto no encontrado"})
+		return
+	}
+	template, _ := models.FindRetroTemplate(project.RetroTemplate)
+	c.JSON(http.StatusOK, gin.H{"data": template, "templates": models.RetroTemplates})
+}
+
+// PUT /api/projects/:id/retro-template
+// Sessions already opened keep the format they started with.
+func UpdateProjectRetroTemplate(c *gin.Context) {
+	var req UpdateRetroTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	template, ok := models.FindRetroTemplate(strings.ToUpper(req.Template))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Plantilla de retrospectiva no válida"})
+		return
+	}
+
+	var project models.Project
+	if err := database.DB.First(&project, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Proyecto no encontrado"})
+		return
+	}
+	if result := database.DB.Model(&project).Update("retro_template", template.Key); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar la plantilla"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": template})
+}
@@ -30,7 +30,11 @@ func GetAllRubrics(c *gin.Context) {
 	projectID := c.Query("projectId")
 	var rubrics []models.Rubric
 	
+	// Global rubrics (no project) are shared with everyone
 	query := database.DB.Preload("Criteria")
+	if projectIDs, all := visibleProjectIDs(c); !all {
+		query = query.Where("project_id IS NULL OR project_id IN ?", projectIDs)
+	}
 	if projectID != "" {
 		query = query.Where("project_id = ?", projectID)
 	}
@@ -49,6 +53,12 @@ func GetRubric(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Rúbrica no encontrada"})
 		return
 	}
+	if rubric.ProjectID != nil {
+		if projectIDs, all := visibleProjectIDs(c); !all && !containsString(projectIDs, *rubric.ProjectID) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Rúbrica no encontrada"})
+			return
+		}
+	}
 	c.JSON(http.StatusOK, gin.H{"data": rubric})
 }
 
@@ -111,7 +121,17 @@ func CreateRubric(c *gin.Context) {
 
 func DeleteRubric(c *gin.Context) {
 	id := c.Param("id")
-	if result := database.DB.Delete(&models.Rubric{}, "id = ?", id); result.Error != nil {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Evaluations already made with it keep their scores
+		if err := tx.Model(&models.Evaluation{}).Where("rubric_id = ?", id).Update("rubric_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("rubric_id = ?", id).Delete(&models.Criteria{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Rubric{}, "id = ?", id).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar rúbrica"})
 		return
 	}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"Wrk_Api/internal/database"
+	"Wrk_Api/internal/models"
+	"Wrk_Api/internal/search"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+var searchTypes = []string{search.TypeProject, search.TypeTask, search.TypeUserStory, search.TypeMessage, search.TypeRetroItem, search.TypeDocument}
+
+// searchScope returns the projects and direct chats the caller can search.
+func searchScope(c *gin.Context) search.Scope {
+	projectIDs, all := visibleProjectIDs(c)
+	if all {
+		return search.Scope{All: true}
+	}
+	scope := search.Scope{ProjectIDs: projectIDs, ChatIDs: []string{}}
+	database.DB.Model(&models.ChatParticipant{}).Where("user_id = ?", c.GetString("userID")).Pluck("chat_id", &scope.ChatIDs)
+	return scope
+}
+
+// GET /api/search?q=...&types=TASK,MESSAGE&projectId=...&limit=20
+// Searches what the caller can see; snippets come HTML-escaped with matches in <mark>.
+func Search(c *gin.Context) {
+	text := strings.TrimSpace(c.Query("q"))
+	if len([]rune(text)) < 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La búsqueda necesita al menos 2 caracteres"})
+		return
+	}
+
+	limit := defaultSearchLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit debe ser un entero positivo"})
+			return
+		}
+		limit = min(n, maxSearchLimit)
+	}
+
+	types := []string{}
+	if raw := c.Query("types"); raw != "" {
+		for _, t := range strings.Split(raw, ",") {
+			t = strings.ToUpper(strings.TrimSpace(t))
+			if !containsString(searchTypes, t) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "types admite: " + strings.Join(searchTypes, ", ")})
+				return
+			}
+			types = append(types, t)
+		}
+	}
+
+	scope := searchScope(c)
+	if projectID := c.Query("projectId"); projectID != "" {
+		if !scope.All && !containsString(scope.ProjectIDs, projectID) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Proyecto no encontrado"})
+			return
+		}
+		scope.ProjectIDs = []string{projectID}
+		scope.ChatIDs = []string{}
+	}
+
+	hits, err := search.Default.Search(database.DB, search.Query{Text: text, Types: types, Scope: scope, Limit: limit})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al buscar"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": hits, "engine": search.Default.Name()})
+}
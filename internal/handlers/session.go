@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"Wrk_Api/internal/database"
+	"Wrk_Api/internal/models"
+	"Wrk_Api/internal/realtime"
+	"Wrk_Api/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+// createSession persists a new session for the user and returns the signed
+// access token together with the plaintext refresh token.
+func createSession(c *gin.Context, user models.User) (string, string, error) {
+	refreshToken := utils.GenerateRefreshToken()
+	now := time.Now()
+	session := models.Session{
+		ID:               utils.GenerateCUID(),
+		UserID:           user.ID,
+		RefreshTokenHash: utils.HashToken(refreshToken),
+		UserAgent:        c.Request.UserAgent(),
+		IP:               c.ClientIP(),
+		ExpiresAt:        now.Add(utils.RefreshTokenTTL),
+		LastUsedAt:       now,
+		CreatedAt:        now,
+	}
+	if err := database.DB.Create(&session).Error; err != nil {
+		return "", "", err
+	}
+
+	accessToken, err := utils.GenerateAccessToken(user.ID, user.Email, user.Role, session.ID)
+	if err != nil {
+		return "", "", err
+	}
+	return accessToken, refreshToken, nil
+}
+
+// revokeUserSessions invalidates every active session of the user.
+func revokeUserSessions(userID string) error {
+	var sessionIDs []string
+	database.DB.Model(&models.Session{}).Where("user_id = ? AND revoked_at IS NULL", userID).Pluck("id", &sessionIDs)
+	if err := database.DB.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return err
+	}
+	disconnectSessions(sessionIDs...)
+	return nil
+}
+
+// disconnectSessions closes the WebSocket and SSE connections opened with the sessions.
+func disconnectSessions(sessionIDs ...string) {
+	for _, id := range sessionIDs {
+		realtime.Default.Disconnect(realtime.SessionTopic(id))
+	}
+}
+
+// POST /api/auth/refresh
+func Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Refresh token requerido"})
+		return
+	}
+
+	presented := utils.HashToken(req.RefreshToken)
+	var session models.Session
+	if result := database.DB.Preload("User").Where("refresh_token_hash = ?", presented).First(&session); result.Error != nil || !session.Active() {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Sesión inválida o expirada"})
+		return
+	}
+
+	if !session.User.Active {
+		now := time.Now()
+		session.RevokedAt = &now
+		database.DB.Save(&session)
+		disconnectSessions(session.ID)
+		c.JSON(http.StatusForbidden, gin.H{"error": "Usuario desactivado"})
+		return
+	}
+
+	// Rotate the refresh token so a leaked one can only be used once. The update
+	// only matches while the session still holds the presented token, so of two
+	// concurrent refreshes with it just one gets through.
+	refreshToken := utils.GenerateRefreshToken()
+	now := time.Now()
+	result := database.DB.Model(&models.Session{}).
+		Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", session.ID, presented).
+		Updates(map[string]interface{}{
+			"refresh_token_hash": utils.HashToken(refreshToken),
+			"last_used_at":       now,
+			"expires_at":         now.Add(utils.RefreshTokenTTL),
+		})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al renovar sesión"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Sesión inválida o expirada"})
+		return
+	}
+
+	accessToken, err := utils.GenerateAccessToken(session.User.ID, session.User.Email, session.User.Role, session.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al generar token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":        accessToken,
+		"refreshToken": refreshToken,
+		"expiresIn":    int(utils.AccessTokenTTL.Seconds()),
+	})
+}
+
+// POST /api/auth/logout
+func Logout(c *gin.Context) {
+	sessionID := c.GetString("sessionID")
+	if result := database.DB.Model(&models.Session{}).Where("id = ?", sessionID).Update("revoked_at", time.Now()); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al cerrar sesión"})
+		return
+	}
+	disconnectSessions(sessionID)
+	c.JSON(http.StatusOK, gin.H{"message": "Sesión cerrada"})
+}
+
+// POST /api/auth/logout-all
+func LogoutAll(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	if err := revokeUserSessions(userID.(string)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al cerrar sesiones"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Todas las sesiones cerradas"})
+}
+
+// GET /api/auth/sessions
+func GetSessions(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	currentID := c.GetString("sessionID")
+
+	var sessions []models.Session
+	database.DB.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at desc").Find(&sessions)
+
+	response := []gin.H{}
+	for _, s := range sessions {
+		response = append(response, gin.H{
+			"id":         s.ID,
+			"userAgent":  s.UserAgent,
+			"ip":         s.IP,
+			"createdAt":  s.CreatedAt,
+			"lastUsedAt": s.LastUsedAt,
+			"expiresAt":  s.ExpiresAt,
+			"current":    s.ID == currentID,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
@@ -9,6 +9,8 @@ import (
 	"Wrk_Api/internal/utils"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type CreateSprintRequest struct {
@@ -32,19 +34,57 @@ type AddStoryRequest struct {
 	UserStoryID string `json:"userStoryId" binding:"required"`
 }
 
+type CompleteSprintRequest struct {
+	CarryOver    string  `json:"carryOver"` // BACKLOG (default) or NEXT_SPRINT
+	NextSprintID *string `json:"nextSprintId"`
+}
+
+var sprintList = listSpec{
+	Table: "sprints",
+	Filters: map[string]listFilter{
+		"projectId": {Column: "project_id"},
+		"status":    {Column: "status"},
+		"startFrom": {Column: "start_date", Kind: filterFrom},
+		"startTo":   {Column: "start_date", Kind: filterTo},
+		"endFrom":   {Column: "end_date", Kind: filterFrom},
+		"endTo":     {Column: "end_date", Kind: filterTo},
+	},
+	Sorts: map[string]string{
+		"createdAt": "created_at",
+		"startDate": "start_date",
+		"endDate":   "end_date",
+		"name":      "name",
+		"status":    "status",
+	},
+	DefaultSort: "createdAt",
+}
+
+// GET /api/sprints
+// Paginated; ?include=tasks,userStories,evaluations adds those collections.
 func GetAllSprints(c *gin.Context) {
 	var sprints []models.Sprint
-	if result := database.DB.Preload("Project").Preload("Tasks").Preload("UserStories").Preload("Evaluations").Find(&sprints); result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener sprints", "details": result.Error.Error()})
+	query := inVisibleProjects(c, database.DB.Preload("Project"), "project_id")
+	included := includes(c)
+	for param, relation := range map[string]string{"tasks": "Tasks", "userStories": "UserStories"} {
+		if included[param] {
+			query = query.Preload(relation)
+		}
+	}
+	if included["evaluations"] {
+		query = query.Preload("Evaluations", visibleEvaluationsScope(c))
+	}
+
+	page, ok := listPage(c, query, sprintList, &sprints)
+	if !ok {
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"data": sprints})
+	c.JSON(http.StatusOK, page)
 }
 
 func GetSprint(c *gin.Context) {
 	id := c.Param("id")
 	var sprint models.Sprint
-	if result := database.DB.Preload("Project").Preload("Tasks").Preload("UserStories").Preload("Evaluations").First(&sprint, "id = ?", id); result.Error != nil {
+	if result := database.DB.Preload("Project").Preload("Tasks").Preload("UserStories").Preload("Evaluations", visibleEvaluationsScope(c)).First(&sprint, "id = ?", id); result.Error != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Sprint no encontrado"})
 		return
 	}
@@ -66,10 +106,12 @@ func CreateSprint(c *gin.Context) {
 		endDate, _ = time.Parse(time.RFC3339, *req.EndDate)
 	}
 
-	status := "PLANNING"
-	if req.Status != "" {
-		status = req.Status
+	// Sprints always start in planning; /start and /complete move them forward
+	if req.Status != "" && req.Status != models.SprintPlanning {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Un sprint nuevo debe estar en PLANNING"})
+		return
 	}
+	status := models.SprintPlanning
 
 	sprint := models.Sprint{
 		ID:          utils.GenerateCUID(),
@@ -108,8 +150,9 @@ func UpdateSprint(c *gin.Context) {
 	if req.Description != nil {
 		sprint.Description = req.Description
 	}
-	if req.Status != "" {
-		sprint.Status = req.Status
+	if req.Status != "" && req.Status != sprint.Status {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Usa las acciones start y complete para cambiar el estado del sprint"})
+		return
 	}
 	if req.StartDate != nil {
 		t, _ := time.Parse(time.RFC3339, *req.StartDate)
@@ -143,17 +186,375 @@ func AddStoryToSprint(c *gin.Context) {
 		return
 	}
 
+	if status, message := checkSprintTarget(sprintID, userStory.ProjectID); status != 0 {
+		c.JSON(status, gin.H{"error": message})
+		return
+	}
+
+	previous := userStory
+	before := userStorySnapshot(userStory)
 	userStory.SprintID = &sprintID
-	database.DB.Save(&userStory)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&userStory).Error; err != nil {
+			return err
+		}
+		if err := recordScopeChange(tx, c, previous, userStory); err != nil {
+			return err
+		}
+		return recordHistory(tx, c, models.EntityUserStory, userStory.ID, userStory.ProjectID, before, userStorySnapshot(userStory))
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al agregar historia al sprint"})
+		return
+	}
 
 	c.JSON(http.StatusCreated, gin.H{"data": userStory})
 }
 
+// checkSprintTarget reports whether work of projectID can be planned into
+// sprintID: the sprint must exist, belong to the same project and not be
+// completed. It returns the status and message to answer with, or 0.
+func checkSprintTarget(sprintID, projectID string) (int, string) {
+	var sprint models.Sprint
+	if result := database.DB.First(&sprint, "id = ?", sprintID); result.Error != nil {
+		return http.StatusNotFound, "Sprint no encontrado"
+	}
+	if sprint.ProjectID != projectID {
+		return http.StatusBadRequest, "El sprint no pertenece al proyecto"
+	}
+	if sprint.Status == models.SprintCompleted {
+		return http.StatusConflict, "El sprint ya está completado"
+	}
+	return 0, ""
+}
+
+// POST /api/sprints/:id/remove-story
+// Sends the story back to the backlog.
+func RemoveStoryFromSprint(c *gin.Context) {
+	sprintID := c.Param("id")
+	var req AddStoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var userStory models.UserStory
+	if result := database.DB.First(&userStory, "id = ? AND sprint_id = ?", req.UserStoryID, sprintID); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "La historia no está en el sprint"})
+		return
+	}
+
+	var sprint models.Sprint
+	database.DB.First(&sprint, "id = ?", sprintID)
+	if sprint.Status == models.SprintCompleted {
+		c.JSON(http.StatusConflict, gin.H{"error": "El sprint ya está completado"})
+		return
+	}
+
+	previous := userStory
+	before := userStorySnapshot(userStory)
+	userStory.SprintID = nil
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&userStory).Update("sprint_id", nil).Error; err != nil {
+			return err
+		}
+		if err := recordScopeChange(tx, c, previous, userStory); err != nil {
+			return err
+		}
+		return recordHistory(tx, c, models.EntityUserStory, userStory.ID, userStory.ProjectID, before, userStorySnapshot(userStory))
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al quitar la historia del sprint"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": userStory})
+}
+
+// recordScopeChange logs how a story update changes the scope of active sprints:
+// moving it in or out, or re-estimating it while it's in one.
+func recordScopeChange(tx *gorm.DB, c *gin.Context, before, after models.UserStory) error {
+	pointsOf := func(us models.UserStory) int {
+		if us.StoryPoints == nil {
+			return 0
+		}
+		return *us.StoryPoints
+	}
+	isActive := func(sprintID *string) bool {
+		if sprintID == nil {
+			return false
+		}
+		var count int64
+		tx.Model(&models.Sprint{}).Where("id = ? AND status = ?", *sprintID, models.SprintActive).Count(&count)
+		return count > 0
+	}
+
+	var changes []models.SprintScopeChange
+	sameSprint := (before.SprintID == nil && after.SprintID == nil) ||
+		(before.SprintID != nil && after.SprintID != nil && *before.SprintID == *after.SprintID)
+	if sameSprint {
+		if delta := pointsOf(after) - pointsOf(before); delta != 0 && isActive(after.SprintID) {
+			changes = append(changes, models.SprintScopeChange{SprintID: *after.SprintID, Type: models.ScopeEstimateChanged, Delta: delta})
+		}
+	} else {
+		if isActive(before.SprintID) {
+			changes = append(changes, models.SprintScopeChange{SprintID: *before.SprintID, Type: models.ScopeRemoved, Delta: -pointsOf(before)})
+		}
+		if isActive(after.SprintID) {
+			changes = append(changes, models.SprintScopeChange{SprintID: *after.SprintID, Type: models.ScopeAdded, Delta: pointsOf(after)})
+		}
+	}
+
+	for _, change := range changes {
+		change.ID = utils.GenerateCUID()
+		change.UserStoryID = after.ID
+		change.CreatedAt = time.Now()
+		if userID := c.GetString("userID"); userID != "" {
+			change.UserID = &userID
+		}
+		if err := tx.Create(&change).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func DeleteSprint(c *gin.Context) {
 	id := c.Param("id")
-	if result := database.DB.Delete(&models.Sprint{}, "id = ?", id); result.Error != nil {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		sprint := tx.Model(&models.Sprint{}).Select("id").Where("id = ?", id)
+		if err := deleteSprintRows(tx, c, sprint); err != nil {
+			return err
+		}
+		return tx.Delete(&models.Sprint{}, "id = ?", id).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar sprint"})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"data": gin.H{"message": "Sprint eliminado"}})
 }
+
+// sprintScope counts the stories, story points and tasks of a sprint; with
+// done set it only counts finished work.
+func sprintScope(tx *gorm.DB, sprintID string, done bool) (stories, points, tasks int) {
+	var userStories []models.UserStory
+	tx.Where("sprint_id = ?", sprintID).Find(&userStories)
+	for _, us := range userStories {
+		if done && !doneStatuses[us.Status] {
+			continue
+		}
+		stories++
+		if us.StoryPoints != nil {
+			points += *us.StoryPoints
+		}
+	}
+
+	var sprintTasks []models.Task
+	tx.Where("sprint_id = ?", sprintID).Find(&sprintTasks)
+	for _, t := range sprintTasks {
+		if !done || doneStatuses[t.Status] {
+			tasks++
+		}
+	}
+	return stories, points, tasks
+}
+
+// POST /api/sprints/:id/start
+func StartSprint(c *gin.Context) {
+	var sprint models.Sprint
+	if result := database.DB.First(&sprint, "id = ?", c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sprint no encontrado"})
+		return
+	}
+	if sprint.Status != models.SprintPlanning {
+		c.JSON(http.StatusConflict, gin.H{"error": "Solo se puede iniciar un sprint en PLANNING"})
+		return
+	}
+
+	now := time.Now()
+	conflict := ""
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the project so two of its sprints can't start at the same time
+		var project models.Project
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&project, "id = ?", sprint.ProjectID).Error; err != nil {
+			return err
+		}
+		var active int64
+		tx.Model(&models.Sprint{}).Where("project_id = ? AND status = ?", sprint.ProjectID, models.SprintActive).Count(&active)
+		if active > 0 {
+			conflict = "El proyecto ya tiene un sprint activo"
+			return nil
+		}
+
+		updates := map[string]interface{}{"status": models.SprintActive, "started_at": now}
+		if sprint.StartDate.IsZero() {
+			updates["start_date"] = now
+		}
+		result := tx.Model(&models.Sprint{}).Where("id = ? AND status = ?", sprint.ID, models.SprintPlanning).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			conflict = "Solo se puede iniciar un sprint en PLANNING"
+			return nil
+		}
+
+		stories, points, tasks := sprintScope(tx, sprint.ID, false)
+		return tx.Create(&models.SprintReport{
+			ID:               utils.GenerateCUID(),
+			SprintID:         sprint.ID,
+			CommittedStories: stories,
+			CommittedPoints:  points,
+			CommittedTasks:   tasks,
+			StartedAt:        now,
+		}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al iniciar el sprint"})
+		return
+	}
+	if conflict != "" {
+		c.JSON(http.StatusConflict, gin.H{"error": conflict})
+		return
+	}
+
+	database.DB.First(&sprint, "id = ?", sprint.ID)
+	c.JSON(http.StatusOK, gin.H{"data": sprint})
+}
+
+// POST /api/sprints/:id/complete
+// Unfinished stories and tasks move to the backlog or to the next sprint.
+func CompleteSprint(c *gin.Context) {
+	var req CompleteSprintRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.CarryOver == "" {
+		req.CarryOver = models.CarryOverBacklog
+	}
+	if req.CarryOver != models.CarryOverBacklog && req.CarryOver != models.CarryOverNextSprint {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "carryOver debe ser BACKLOG o NEXT_SPRINT"})
+		return
+	}
+
+	var sprint models.Sprint
+	if result := database.DB.First(&sprint, "id = ?", c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sprint no encontrado"})
+		return
+	}
+	if sprint.Status != models.SprintActive {
+		c.JSON(http.StatusConflict, gin.H{"error": "Solo se puede completar un sprint activo"})
+		return
+	}
+
+	// Unfinished work goes to the given sprint or the next one being planned
+	var target *string
+	if req.CarryOver == models.CarryOverNextSprint {
+		var next []models.Sprint
+		query := database.DB.Where("project_id = ? AND status = ? AND id <> ?", sprint.ProjectID, models.SprintPlanning, sprint.ID)
+		if req.NextSprintID != nil {
+			query = query.Where("id = ?", *req.NextSprintID)
+		}
+		query.Order("start_date asc, created_at asc").Limit(1).Find(&next)
+		if len(next) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No hay un sprint en PLANNING al que mover el trabajo pendiente"})
+			return
+		}
+		target = &next[0].ID
+	}
+
+	var report models.SprintReport
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var existing []models.SprintReport
+		tx.Where("sprint_id = ?", sprint.ID).Limit(1).Find(&existing)
+		if len(existing) > 0 {
+			report = existing[0]
+		} else {
+			// Sprints started before the lifecycle existed have no start snapshot
+			stories, points, tasks := sprintScope(tx, sprint.ID, false)
+			report = models.SprintReport{
+				ID:               utils.GenerateCUID(),
+				SprintID:         sprint.ID,
+				CommittedStories: stories,
+				CommittedPoints:  points,
+				CommittedTasks:   tasks,
+				StartedAt:        sprint.CreatedAt,
+			}
+			if sprint.StartedAt != nil {
+				report.StartedAt = *sprint.StartedAt
+			}
+		}
+		report.CompletedStories, report.CompletedPoints, report.CompletedTasks = sprintScope(tx, sprint.ID, true)
+
+		var stories []models.UserStory
+		tx.Where("sprint_id = ?", sprint.ID).Find(&stories)
+		for _, us := range stories {
+			if doneStatuses[us.Status] {
+				continue
+			}
+			before := userStorySnapshot(us)
+			us.SprintID = target
+			if err := tx.Model(&us).Update("sprint_id", target).Error; err != nil {
+				return err
+			}
+			if err := recordHistory(tx, c, models.EntityUserStory, us.ID, us.ProjectID, before, userStorySnapshot(us)); err != nil {
+				return err
+			}
+			report.CarriedOverStories++
+			if us.StoryPoints != nil {
+				report.CarriedOverPoints += *us.StoryPoints
+			}
+		}
+
+		var tasks []models.Task
+		tx.Where("sprint_id = ?", sprint.ID).Find(&tasks)
+		for _, t := range tasks {
+			if doneStatuses[t.Status] {
+				continue
+			}
+			before := taskSnapshot(t)
+			t.SprintID = target
+			if err := tx.Model(&t).Update("sprint_id", target).Error; err != nil {
+				return err
+			}
+			if err := recordHistory(tx, c, models.EntityTask, t.ID, t.ProjectID, before, taskSnapshot(t)); err != nil {
+				return err
+			}
+			report.CarriedOverTasks++
+		}
+
+		now := time.Now()
+		report.CarryOver = &req.CarryOver
+		report.NextSprintID = target
+		report.CompletedAt = &now
+		if err := tx.Save(&report).Error; err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{"status": models.SprintCompleted, "completed_at": now}
+		if sprint.EndDate.IsZero() {
+			updates["end_date"] = now
+		}
+		return tx.Model(&sprint).Updates(updates).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al completar el sprint"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
+
+// GET /api/sprints/:id/report
+func GetSprintReport(c *gin.Context) {
+	var report models.SprintReport
+	if result := database.DB.Preload("NextSprint").First(&report, "sprint_id = ?", c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "El sprint aún no ha iniciado"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
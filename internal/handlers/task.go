@@ -13,65 +13,97 @@ import (
 )
 
 type CreateTaskRequest struct {
-	Title       string  `json:"title" binding:"required"`
-	Description string  `json:"description"`
-	ProjectID   string  `json:"projectId" binding:"required"`
-	AssigneeID  string  `json:"assigneeId"`
-	Priority    string  `json:"priority"`
-	Deadline    *string `json:"deadline"`
-	Status      string  `json:"status"`
-	SprintID    string  `json:"sprintId"`
-	UserStoryID string  `json:"userStoryId"`
+	Title             string  `json:"title" binding:"required"`
+	Description       string  `json:"description"`
+	ProjectID         string  `json:"projectId" binding:"required"`
+	AssigneeID        string  `json:"assigneeId"`
+	Priority          string  `json:"priority"`
+	Deadline          *string `json:"deadline"`
+	Status            string  `json:"status"`
+	SprintID          string  `json:"sprintId"`
+	UserStoryID       string  `json:"userStoryId"`
+	OriginalEstimate  *int    `json:"originalEstimate"` // minutes
+	RemainingEstimate *int    `json:"remainingEstimate"`
 }
 
 type UpdateTaskRequest struct {
-	Title       string  `json:"title"`
-	Description string  `json:"description"`
-	AssigneeID  string  `json:"assigneeId"`
-	Priority    string  `json:"priority"`
-	Deadline    *string `json:"deadline"`
-	Status      string  `json:"status"`
-	SprintID    string  `json:"sprintId"`
-	UserStoryID string  `json:"userStoryId"`
+	Title             string  `json:"title"`
+	Description       string  `json:"description"`
+	AssigneeID        string  `json:"assigneeId"`
+	Priority          string  `json:"priority"`
+	Deadline          *string `json:"deadline"`
+	Status            string  `json:"status"`
+	SprintID          string  `json:"sprintId"`
+	UserStoryID       string  `json:"userStoryId"`
+	OriginalEstimate  *int    `json:"originalEstimate"` // minutes
+	RemainingEstimate *int    `json:"remainingEstimate"`
 }
 
 type CriteriaScore struct {
 	CriteriaID string `json:"criteriaId"`
 	Score      int    `json:"score"`
+	Comment    string `json:"comment"`
 }
 
 type EvaluateTaskRequest struct {
-	Score          int             `json:"score" binding:"required"`
+	Score          *int            `json:"score"`
+	MaxScore       *int            `json:"maxScore"` // required with a manual score
 	Feedback       string          `json:"feedback"`
-	EvaluatorID    string          `json:"evaluatorId" binding:"required"`
+	RubricID       *string         `json:"rubricId"`
 	CriteriaScores []CriteriaScore `json:"criteriaScores"`
+	Status         string          `json:"status"` // DRAFT (default) or SUBMITTED
 }
 
-func GetAllTasks(c *gin.Context) {
-	assigneeID := c.Query("assigneeId")
-	projectID := c.Query("projectId")
+var taskList = listSpec{
+	Table: "tasks",
+	Filters: map[string]listFilter{
+		"projectId":    {Column: "project_id"},
+		"assigneeId":   {Column: "assignee_id"},
+		"sprintId":     {Column: "sprint_id"},
+		"userStoryId":  {Column: "user_story_id"},
+		"status":       {Column: "status"},
+		"priority":     {Column: "priority"},
+		"blocked":      {Column: "blocked", Kind: filterBool},
+		"createdFrom":  {Column: "created_at", Kind: filterFrom},
+		"createdTo":    {Column: "created_at", Kind: filterTo},
+		"deadlineFrom": {Column: "deadline", Kind: filterFrom},
+		"deadlineTo":   {Column: "deadline", Kind: filterTo},
+	},
+	Sorts: map[string]string{
+		"createdAt": "created_at",
+		"updatedAt": "updated_at",
+		"title":     "title",
+		"status":    "status",
+		"priority":  priorityOrder,
+		"deadline":  "COALESCE(deadline, '9999-12-31')", // tasks without deadline last
+	},
+	DefaultSort: "createdAt",
+}
 
+// GET /api/tasks
+// Paginated; ?include=evaluations adds the task evaluations.
+func GetAllTasks(c *gin.Context) {
 	var tasks []models.Task
-	query := database.DB.Preload("Assignee").Preload("Project").Preload("Evaluations")
-
-	if assigneeID != "" {
-		query = query.Where("assignee_id = ?", assigneeID)
+	query := inVisibleProjects(c, database.DB.Preload("Assignee").Preload("Project"), "project_id")
+	if includes(c)["evaluations"] {
+		query = query.Preload("Evaluations", visibleEvaluationsScope(c))
 	}
-	if projectID != "" {
-		query = query.Where("project_id = ?", projectID)
+	// Tasks belong to an epic through their user story
+	if epicID := c.Query("epicId"); epicID != "" {
+		query = query.Where("user_story_id IN (?)", database.DB.Model(&models.UserStory{}).Select("id").Where("epic_id = ?", epicID))
 	}
 
-	if result := query.Find(&tasks); result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener tareas"})
+	page, ok := listPage(c, query, taskList, &tasks)
+	if !ok {
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"data": tasks})
+	c.JSON(http.StatusOK, page)
 }
 
 func GetTask(c *gin.Context) {
 	id := c.Param("id")
 	var task models.Task
-	if result := database.DB.Preload("Assignee").Preload("Project").Preload("Evaluations").First(&task, "id = ?", id); result.Error != nil {
+	if result := database.DB.Preload("Assignee").Preload("Project").Preload("Evaluations", visibleEvaluationsScope(c)).First(&task, "id = ?", id); result.Error != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Tarea no encontrada"})
 		return
 	}
@@ -115,31 +147,68 @@ func CreateTask(c *gin.Context) {
 		task.AssigneeID = &req.AssigneeID
 	}
 	if req.SprintID != "" {
+		if status, message := checkSprintTarget(req.SprintID, task.ProjectID); status != 0 {
+			c.JSON(status, gin.H{"error": message})
+			return
+		}
 		task.SprintID = &req.SprintID
 	}
 	if req.UserStoryID != "" {
 		task.UserStoryID = &req.UserStoryID
 	}
+	if badEstimate(req.OriginalEstimate) || badEstimate(req.RemainingEstimate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Las estimaciones no pueden ser negativas"})
+		return
+	}
+	task.OriginalEstimate = req.OriginalEstimate
+	task.RemainingEstimate = req.RemainingEstimate
+	// Nothing has been logged yet, so all the estimate remains
+	if task.RemainingEstimate == nil {
+		task.RemainingEstimate = req.OriginalEstimate
+	}
 
-	if result := database.DB.Create(&task); result.Error != nil {
+	var warning string
+	var rejected gin.H
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// The column the new task lands in has a WIP limit too
+		if warning, rejected = checkWipLimit(tx, task, "", task.Status); rejected != nil {
+			return nil
+		}
+		if err := tx.Create(&task).Error; err != nil {
+			return err
+		}
+		return recordHistory(tx, c, models.EntityTask, task.ID, task.ProjectID, nil, taskSnapshot(task))
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear tarea"})
 		return
 	}
+	if rejected != nil {
+		c.JSON(http.StatusConflict, rejected)
+		return
+	}
 
 	// Notificación
 	if req.AssigneeID != "" {
-		notification := models.Notification{
-			ID:        utils.GenerateCUID(),
-			UserID:    req.AssigneeID,
-			Title:     "Nueva Tarea Asignada",
-			Message:   "Se te ha asignado la tarea: " + task.Title,
-			Type:      "TASK_ASSIGNED",
-			CreatedAt: time.Now(),
-		}
-		database.DB.Create(&notification)
+		notify(req.AssigneeID, "Nueva Tarea Asignada", "Se te ha asignado la tarea: "+task.Title, "TASK_ASSIGNED")
+	}
+
+	response := gin.H{"data": task}
+	if warning != "" {
+		response["warning"] = warning
 	}
+	c.JSON(http.StatusCreated, response)
+}
 
-	c.JSON(http.StatusCreated, gin.H{"data": task})
+// setTaskStatus changes the status and keeps CompletedAt in step with it.
+func setTaskStatus(task *models.Task, status string) {
+	task.Status = status
+	if status == "COMPLETED" || status == "DONE" {
+		now := time.Now()
+		task.CompletedAt = &now
+	} else if status == "TODO" || status == "IN_PROGRESS" || status == "PENDING" {
+		task.CompletedAt = nil
+	}
 }
 
 func UpdateTask(c *gin.Context) {
@@ -155,6 +224,8 @@ func UpdateTask(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Tarea no encontrada"})
 		return
 	}
+	before := taskSnapshot(task)
+	previousStatus := task.Status
 
 	if req.Title != "" {
 		task.Title = req.Title
@@ -168,7 +239,11 @@ func UpdateTask(c *gin.Context) {
 	if req.AssigneeID != "" {
 		task.AssigneeID = &req.AssigneeID
 	}
-	if req.SprintID != "" {
+	if req.SprintID != "" && (task.SprintID == nil || *task.SprintID != req.SprintID) {
+		if status, message := checkSprintTarget(req.SprintID, task.ProjectID); status != 0 {
+			c.JSON(status, gin.H{"error": message})
+			return
+		}
 		task.SprintID = &req.SprintID
 	}
 	if req.UserStoryID != "" {
@@ -178,24 +253,65 @@ func UpdateTask(c *gin.Context) {
 		t, _ := time.Parse(time.RFC3339, *req.Deadline)
 		task.Deadline = &t
 	}
+	if badEstimate(req.OriginalEstimate) || badEstimate(req.RemainingEstimate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Las estimaciones no pueden ser negativas"})
+		return
+	}
+	if req.OriginalEstimate != nil {
+		task.OriginalEstimate = req.OriginalEstimate
+	}
+	if req.RemainingEstimate != nil {
+		task.RemainingEstimate = req.RemainingEstimate
+	}
 
 	if req.Status != "" {
-		task.Status = req.Status
-		if req.Status == "COMPLETED" || req.Status == "DONE" {
-			now := time.Now()
-			task.CompletedAt = &now
-		} else if req.Status == "TODO" || req.Status == "IN_PROGRESS" || req.Status == "PENDING" {
-			task.CompletedAt = nil
-		}
+		setTaskStatus(&task, req.Status)
 	}
 
-	database.DB.Save(&task)
-	c.JSON(http.StatusOK, gin.H{"data": task})
+	var warning string
+	var rejected gin.H
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Status changes respect WIP limits the same way board moves do
+		if req.Status != "" && req.Status != previousStatus {
+			if warning, rejected = checkWipLimit(tx, task, previousStatus, req.Status); rejected != nil {
+				return nil
+			}
+		}
+		if err := tx.Save(&task).Error; err != nil {
+			return err
+		}
+		if err := refreshDependents(tx, models.EntityTask, task.ID); err != nil {
+			return err
+		}
+		return recordHistory(tx, c, models.EntityTask, task.ID, task.ProjectID, before, taskSnapshot(task))
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar tarea"})
+		return
+	}
+	if rejected != nil {
+		c.JSON(http.StatusConflict, rejected)
+		return
+	}
+	response := gin.H{"data": task}
+	if warning != "" {
+		response["warning"] = warning
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 func DeleteTask(c *gin.Context) {
 	id := c.Param("id")
-	if result := database.DB.Delete(&models.Task{}, "id = ?", id); result.Error != nil {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := removeDependencies(tx, models.EntityTask, id); err != nil {
+			return err
+		}
+		if err := deleteTaskRows(tx, id); err != nil {
+			return err
+		}
+		return tx.Delete(&models.Task{}, "id = ?", id).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar tarea"})
 		return
 	}
@@ -216,37 +332,39 @@ func EvaluateTask(c *gin.Context) {
 		return
 	}
 
-	err := database.DB.Transaction(func(tx *gorm.DB) error {
-		evaluation := models.Evaluation{
-			ID:          utils.GenerateCUID(),
-			TaskID:      &taskID,
-			ProjectID:   task.ProjectID,
-			EvaluatorID: req.EvaluatorID,
-			Score:       &req.Score,
-			Feedback:    &req.Feedback,
-			Status:      "COMPLETED",
-			CreatedAt:   time.Now(),
-		}
+	status, err := initialStatus(req.Status)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rubricID, score, err := resolveScore(task.ProjectID, req.RubricID, req.Score, req.MaxScore, req.CriteriaScores, status == models.EvaluationDraft)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	evaluation := models.Evaluation{
+		ID:          utils.GenerateCUID(),
+		TaskID:      &taskID,
+		ProjectID:   task.ProjectID,
+		EvaluatorID: c.GetString("userID"),
+		RubricID:    rubricID,
+		Score:       score,
+		Feedback:    &req.Feedback,
+		Status:      status,
+		CreatedAt:   time.Now(),
+	}
+	if status == models.EvaluationSubmitted {
+		evaluation.SubmittedAt = &evaluation.CreatedAt
+	}
 
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Create(&evaluation).Error; err != nil {
 			return err
 		}
 
-		if len(req.CriteriaScores) > 0 {
-			for _, cs := range req.CriteriaScores {
-				ec := models.EvaluationCriteria{
-					ID:           utils.GenerateCUID(),
-					EvaluationID: evaluation.ID,
-					CriteriaID:   cs.CriteriaID,
-					Score:        cs.Score,
-				}
-				if err := tx.Create(&ec).Error; err != nil {
-					return err
-				}
-			}
-		}
-
-		return nil
+		return saveCriteriaScores(tx, evaluation.ID, req.CriteriaScores)
 	})
 
 	if err != nil {
@@ -254,18 +372,6 @@ func EvaluateTask(c *gin.Context) {
 		return
 	}
 
-	// Notify Assignee
-	if task.AssigneeID != nil {
-		notification := models.Notification{
-			ID:        utils.GenerateCUID(),
-			UserID:    *task.AssigneeID,
-			Title:     "Tarea Evaluada",
-			Message:   "Tu tarea \"" + task.Title + "\" ha sido evaluada",
-			Type:      "EVALUATION_COMPLETED",
-			CreatedAt: time.Now(),
-		}
-		database.DB.Create(&notification)
-	}
-
-	c.JSON(http.StatusCreated, gin.H{"message": "Evaluación guardada"})
+	// The assignee is notified once the evaluation is published
+	c.JSON(http.StatusCreated, gin.H{"message": "Evaluación guardada", "data": gin.H{"id": evaluation.ID, "score": evaluation.Score, "status": evaluation.Status}})
 }
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"Wrk_Api/internal/database"
+	"Wrk_Api/internal/middleware"
 	"Wrk_Api/internal/models"
 	"Wrk_Api/internal/utils"
 
@@ -26,23 +27,31 @@ type UpdateUserRequest struct {
 	Active   *bool  `json:"active"`
 }
 
-// Helper to check if user is admin (Mock implementation)
-func isAdmin(c *gin.Context) bool {
-	role, exists := c.Get("role")
-	if !exists {
-		return false
-	}
-	return role == "ADMIN" // Adjust based on your role definitions
+var userList = listSpec{
+	Table: "users",
+	Filters: map[string]listFilter{
+		"role":        {Column: "role"},
+		"active":      {Column: "active", Kind: filterBool},
+		"createdFrom": {Column: "created_at", Kind: filterFrom},
+		"createdTo":   {Column: "created_at", Kind: filterTo},
+	},
+	Sorts: map[string]string{
+		"name":      "name",
+		"email":     "email",
+		"role":      "role",
+		"createdAt": "created_at",
+	},
+	DefaultSort: "name",
 }
 
 func GetAllUsers(c *gin.Context) {
 	var users []models.User
-	if result := database.DB.Find(&users); result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener usuarios"})
+	page, ok := listPage(c, database.DB, userList, &users)
+	if !ok {
 		return
 	}
 
-	var response []gin.H
+	response := []gin.H{}
 	for _, u := range users {
 		response = append(response, gin.H{
 			"id":        u.ID,
@@ -54,7 +63,8 @@ func GetAllUsers(c *gin.Context) {
 		})
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": response})
+	page["data"] = response
+	c.JSON(http.StatusOK, page)
 }
 
 func GetUser(c *gin.Context) {
@@ -133,18 +143,31 @@ func UpdateUser(c *gin.Context) {
 	if req.Email != "" {
 		user.Email = req.Email
 	}
+	// Only admins may change roles or (de)activate accounts
+	if (req.Role != "" || req.Active != nil) && !middleware.IsAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Solo un administrador puede cambiar el rol o el estado"})
+		return
+	}
+
 	if req.Role != "" {
 		user.Role = req.Role
 	}
+	// Deactivation and password changes must end existing sessions right away
+	revokeSessions := false
 	if req.Active != nil {
+		revokeSessions = user.Active && !*req.Active
 		user.Active = *req.Active
 	}
 	if req.Password != "" {
 		hashed, _ := bcrypt.GenerateFromPassword([]byte(req.Password), 10)
 		user.Password = string(hashed)
+		revokeSessions = true
 	}
 
 	database.DB.Save(&user)
+	if revokeSessions {
+		revokeUserSessions(user.ID)
+	}
 	c.JSON(http.StatusOK, gin.H{"data": user})
 }
 
@@ -154,5 +177,6 @@ func DeleteUser(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar usuario"})
 		return
 	}
+	revokeUserSessions(id)
 	c.JSON(http.StatusOK, gin.H{"data": gin.H{"message": "Usuario eliminado"}})
 }
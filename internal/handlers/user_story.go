@@ -9,6 +9,7 @@ import (
 	"Wrk_Api/internal/utils"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type CreateUserStoryRequest struct {
@@ -19,26 +20,55 @@ type CreateUserStoryRequest struct {
 	AssigneeID  string `json:"assigneeId"`
 	Priority    string `json:"priority"`
 	StoryPoints int    `json:"storyPoints"`
+	EpicID      string `json:"epicId"`
 }
 
 type UpdateUserStoryRequest struct {
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Acceptance  string `json:"acceptance"`
-	Priority    string `json:"priority"`
-	StoryPoints *int   `json:"storyPoints"`
-	AssigneeID  string `json:"assigneeId"`
-	SprintID    string `json:"sprintId"`
-	Status      string `json:"status"`
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	Acceptance  string  `json:"acceptance"`
+	Priority    string  `json:"priority"`
+	StoryPoints *int    `json:"storyPoints"`
+	AssigneeID  string  `json:"assigneeId"`
+	SprintID    string  `json:"sprintId"`
+	Status      string  `json:"status"`
+	EpicID      *string `json:"epicId"` // "" removes the story from its epic
+}
+
+var userStoryList = listSpec{
+	Table: "user_stories",
+	Filters: map[string]listFilter{
+		"projectId":   {Column: "project_id"},
+		"epicId":      {Column: "epic_id"},
+		"sprintId":    {Column: "sprint_id"},
+		"assigneeId":  {Column: "assignee_id"},
+		"status":      {Column: "status"},
+		"priority":    {Column: "priority"},
+		"blocked":     {Column: "blocked", Kind: filterBool},
+		"createdFrom": {Column: "created_at", Kind: filterFrom},
+		"createdTo":   {Column: "created_at", Kind: filterTo},
+	},
+	Sorts: map[string]string{
+		"project":     "project_id",
+		"rank":        "user_stories.rank",
+		"createdAt":   "created_at",
+		"updatedAt":   "updated_at",
+		"title":       "title",
+		"status":      "status",
+		"priority":    priorityOrder,
+		"storyPoints": "COALESCE(story_points, 0)",
+	},
+	DefaultSort: "project,rank,createdAt",
 }
 
+// GET /api/user-stories
 func GetAllUserStories(c *gin.Context) {
 	var stories []models.UserStory
-	if result := database.DB.Preload("Project").Preload("Assignee").Find(&stories); result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener user stories"})
+	page, ok := listPage(c, inVisibleProjects(c, database.DB.Preload("Project").Preload("Assignee"), "project_id"), userStoryList, &stories)
+	if !ok {
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"data": stories})
+	c.JSON(http.StatusOK, page)
 }
 
 func GetUserStory(c *gin.Context) {
@@ -78,9 +108,28 @@ func CreateUserStory(c *gin.Context) {
 	if req.AssigneeID != "" {
 		story.AssigneeID = &req.AssigneeID
 	}
+	if req.EpicID != "" {
+		if !epicInProject(req.EpicID, req.ProjectID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "La épica no pertenece al proyecto"})
+			return
+		}
+		story.EpicID = &req.EpicID
+	}
 
-	if result := database.DB.Create(&story); result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear user story", "details": result.Error.Error()})
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// New stories go to the bottom of the backlog
+		rank, err := lastRank(tx, story.ProjectID)
+		if err != nil {
+			return err
+		}
+		story.Rank = rank
+		if err := tx.Create(&story).Error; err != nil {
+			return err
+		}
+		return recordHistory(tx, c, models.EntityUserStory, story.ID, story.ProjectID, nil, userStorySnapshot(story))
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear user story", "details": err.Error()})
 		return
 	}
 
@@ -102,6 +151,8 @@ func UpdateUserStory(c *gin.Context) {
 	}
 
 	previousAssigneeID := story.AssigneeID
+	previous := story
+	before := userStorySnapshot(story)
 
 	if req.Title != "" {
 		story.Title = req.Title
@@ -118,12 +169,26 @@ func UpdateUserStory(c *gin.Context) {
 	if req.StoryPoints != nil {
 		story.StoryPoints = req.StoryPoints
 	}
-	if req.SprintID != "" {
+	if req.SprintID != "" && (story.SprintID == nil || *story.SprintID != req.SprintID) {
+		if status, message := checkSprintTarget(req.SprintID, story.ProjectID); status != 0 {
+			c.JSON(status, gin.H{"error": message})
+			return
+		}
 		story.SprintID = &req.SprintID
 	}
 	if req.AssigneeID != "" {
 		story.AssigneeID = &req.AssigneeID
 	}
+	if req.EpicID != nil {
+		if *req.EpicID == "" {
+			story.EpicID = nil
+		} else if !epicInProject(*req.EpicID, story.ProjectID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "La épica no pertenece al proyecto"})
+			return
+		} else {
+			story.EpicID = req.EpicID
+		}
+	}
 
 	// Status logic
 	if req.Status != "" {
@@ -136,19 +201,26 @@ func UpdateUserStory(c *gin.Context) {
 		}
 	}
 
-	database.DB.Save(&story)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&story).Error; err != nil {
+			return err
+		}
+		if err := recordScopeChange(tx, c, previous, story); err != nil {
+			return err
+		}
+		if err := refreshDependents(tx, models.EntityUserStory, story.ID); err != nil {
+			return err
+		}
+		return recordHistory(tx, c, models.EntityUserStory, story.ID, story.ProjectID, before, userStorySnapshot(story))
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar user story"})
+		return
+	}
 
 	// Notification Logic
 	if req.AssigneeID != "" && (previousAssigneeID == nil || *previousAssigneeID != req.AssigneeID) {
-		notification := models.Notification{
-			ID:        utils.GenerateCUID(),
-			UserID:    req.AssigneeID,
-			Title:     "Historia de Usuario Asignada",
-			Message:   "Se te ha asignado la historia \"" + story.Title + "\" en el proyecto " + story.Project.Name,
-			Type:      "TASK_ASSIGNED",
-			CreatedAt: time.Now(),
-		}
-		database.DB.Create(&notification)
+		notify(req.AssigneeID, "Historia de Usuario Asignada", "Se te ha asignado la historia \""+story.Title+"\" en el proyecto "+story.Project.Name, "TASK_ASSIGNED")
 	}
 
 	c.JSON(http.StatusOK, gin.H{"data": story})
@@ -156,7 +228,35 @@ func UpdateUserStory(c *gin.Context) {
 
 func DeleteUserStory(c *gin.Context) {
 	id := c.Param("id")
-	if result := database.DB.Delete(&models.UserStory{}, "id = ?", id); result.Error != nil {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Deleting a story takes it out of its sprint's scope
+		var story models.UserStory
+		if tx.First(&story, "id = ?", id).Error == nil {
+			removed := story
+			removed.SprintID = nil
+			if err := recordScopeChange(tx, c, story, removed); err != nil {
+				return err
+			}
+		}
+		if err := removeDependencies(tx, models.EntityUserStory, id); err != nil {
+			return err
+		}
+		// Its tasks stay in the project without a story
+		var tasks []models.Task
+		tx.Where("user_story_id = ?", id).Find(&tasks)
+		for _, task := range tasks {
+			before := taskSnapshot(task)
+			task.UserStoryID = nil
+			if err := tx.Model(&task).Update("user_story_id", nil).Error; err != nil {
+				return err
+			}
+			if err := recordHistory(tx, c, models.EntityTask, task.ID, task.ProjectID, before, taskSnapshot(task)); err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&models.UserStory{}, "id = ?", id).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar user story"})
 		return
 	}
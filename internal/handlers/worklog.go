@@ -0,0 +1,438 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"Wrk_Api/internal/database"
+	"Wrk_Api/internal/middleware"
+	"Wrk_Api/internal/models"
+	"Wrk_Api/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LogWorkRequest struct {
+	Minutes           int     `json:"minutes" binding:"required,min=1"`
+	Date              *string `json:"date"` // YYYY-MM-DD or RFC3339, defaults to today
+	Note              *string `json:"note"`
+	RemainingEstimate *int    `json:"remainingEstimate"` // overrides the automatic decrease
+}
+
+type UpdateWorklogRequest struct {
+	Minutes *int    `json:"minutes"`
+	Date    *string `json:"date"`
+	Note    *string `json:"note"`
+}
+
+func badEstimate(minutes *int) bool {
+	return minutes != nil && *minutes < 0
+}
+
+func parseWorkDate(raw *string) (time.Time, bool) {
+	if raw == nil || *raw == "" {
+		return time.Now(), true
+	}
+	if date, err := time.Parse("2006-01-02", *raw); err == nil {
+		return date, true
+	}
+	date, err := time.Parse(time.RFC3339, *raw)
+	return date, err == nil
+}
+
+// burnEstimate lowers the remaining estimate of a task by the logged minutes;
+// negative minutes give time back when a worklog shrinks or is deleted. The
+// change goes into the task history like any other update.
+func burnEstimate(tx *gorm.DB, c *gin.Context, taskID string, minutes int, override *int) error {
+	var task models.Task
+	if err := tx.First(&task, "id = ?", taskID).Error; err != nil {
+		return err
+	}
+	remaining := override
+	if remaining == nil {
+		if task.RemainingEstimate == nil {
+			return nil
+		}
+		left := *task.RemainingEstimate - minutes
+		if left < 0 {
+			left = 0
+		}
+		remaining = &left
+	}
+	before := taskSnapshot(task)
+	if err := tx.Model(&task).UpdateColumn("remaining_estimate", *remaining).Error; err != nil {
+		return err
+	}
+	task.RemainingEstimate = remaining
+	return recordHistory(tx, c, models.EntityTask, task.ID, task.ProjectID, before, taskSnapshot(task))
+}
+
+// canEditWorklog lets authors change their own entries and project managers change any.
+func canEditWorklog(c *gin.Context, worklog models.Worklog) bool {
+	return worklog.UserID == c.GetString("userID") || middleware.Can(c, worklog.ProjectID, middleware.PermProjectManage)
+}
+
+// GET /api/tasks/:id/worklogs
+func GetTaskWorklogs(c *gin.Context) {
+	var worklogs []models.Worklog
+	database.DB.Preload("User").Where("task_id = ?", c.Param("id")).Order("date desc, created_at desc").Find(&worklogs)
+
+	total := 0
+	for _, worklog := range worklogs {
+		total += worklog.Minutes
+	}
+	c.JSON(http.StatusOK, gin.H{"data": worklogs, "totalMinutes": total})
+}
+
+// POST /api/tasks/:id/worklogs
+func LogWork(c *gin.Context) {
+	var req LogWorkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if badEstimate(req.RemainingEstimate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Las estimaciones no pueden ser negativas"})
+		return
+	}
+	date, ok := parseWorkDate(req.Date)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Fecha inválida"})
+		return
+	}
+
+	var task models.Task
+	if result := database.DB.First(&task, "id = ?", c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tarea no encontrada"})
+		return
+	}
+
+	worklog := models.Worklog{
+		ID:        utils.GenerateCUID(),
+		TaskID:    task.ID,
+		ProjectID: task.ProjectID,
+		SprintID:  task.SprintID,
+		UserID:    c.GetString("userID"),
+		Minutes:   req.Minutes,
+		Date:      date,
+		Note:      req.Note,
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&worklog).Error; err != nil {
+			return err
+		}
+		return burnEstimate(tx, c, task.ID, worklog.Minutes, req.RemainingEstimate)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al registrar el trabajo"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": worklog})
+}
+
+// PUT /api/worklogs/:id
+func UpdateWorklog(c *gin.Context) {
+	var req UpdateWorklogRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var worklog models.Worklog
+	if result := database.DB.First(&worklog, "id = ?", c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Registro de trabajo no encontrado"})
+		return
+	}
+	if !canEditWorklog(c, worklog) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Solo puedes modificar tus propios registros"})
+		return
+	}
+	if worklog.StartedAt != nil && worklog.EndedAt == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Detén el temporizador antes de editar el registro"})
+		return
+	}
+
+	previousMinutes := worklog.Minutes
+	if req.Minutes != nil {
+		if *req.Minutes < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "minutes debe ser mayor que cero"})
+			return
+		}
+		worklog.Minutes = *req.Minutes
+	}
+	if req.Date != nil {
+		date, ok := parseWorkDate(req.Date)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Fecha inválida"})
+			return
+		}
+		worklog.Date = date
+	}
+	if req.Note != nil {
+		worklog.Note = req.Note
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&worklog).Error; err != nil {
+			return err
+		}
+		if delta := worklog.Minutes - previousMinutes; delta != 0 {
+			return burnEstimate(tx, c, worklog.TaskID, delta, nil)
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar el registro"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": worklog})
+}
+
+// DELETE /api/worklogs/:id
+func DeleteWorklog(c *gin.Context) {
+	var worklog models.Worklog
+	if result := database.DB.First(&worklog, "id = ?", c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Registro de trabajo no encontrado"})
+		return
+	}
+	if !canEditWorklog(c, worklog) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Solo puedes eliminar tus propios registros"})
+		return
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&worklog).Error; err != nil {
+			return err
+		}
+		if worklog.Minutes == 0 {
+			return nil
+		}
+		return burnEstimate(tx, c, worklog.TaskID, -worklog.Minutes, nil)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar el registro"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Registro eliminado"})
+}
+
+// GET /api/worklogs/timer
+// The caller's running timer, if any.
+func GetRunningTimer(c *gin.Context) {
+	var timers []models.Worklog
+	database.DB.Preload("Task").Where("user_id = ? AND started_at IS NOT NULL AND ended_at IS NULL", c.GetString("userID")).Limit(1).Find(&timers)
+	if len(timers) == 0 {
+		c.JSON(http.StatusOK, gin.H{"data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": timers[0], "elapsedSeconds": int(time.Since(*timers[0].StartedAt).Seconds())})
+}
+
+// POST /api/tasks/:id/timer/start
+// A user runs at most one timer at a time.
+func StartTimer(c *gin.Context) {
+	userID := c.GetString("userID")
+	var task models.Task
+	if result := database.DB.First(&task, "id = ?", c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tarea no encontrada"})
+		return
+	}
+
+	now := time.Now()
+	timer := models.Worklog{
+		ID:        utils.GenerateCUID(),
+		TaskID:    task.ID,
+		ProjectID: task.ProjectID,
+		SprintID:  task.SprintID,
+		UserID:    userID,
+		Date:      now,
+		StartedAt: &now,
+	}
+	var running []models.Worklog
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the user so two starts can't both see no running timer
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, "id = ?", userID).Error; err != nil {
+			return err
+		}
+		tx.Where("user_id = ? AND started_at IS NOT NULL AND ended_at IS NULL", userID).Limit(1).Find(&running)
+		if len(running) > 0 {
+			return nil
+		}
+		return tx.Create(&timer).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al iniciar el temporizador"})
+		return
+	}
+	if len(running) > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Ya tienes un temporizador en marcha", "data": running[0]})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": timer})
+}
+
+// POST /api/tasks/:id/timer/stop
+// Elapsed time is rounded up to whole minutes.
+func StopTimer(c *gin.Context) {
+	var note struct {
+		Note *string `json:"note"`
+	}
+	c.ShouldBindJSON(&note)
+
+	var timer models.Worklog
+	err := database.DB.Where("task_id = ? AND user_id = ? AND started_at IS NOT NULL AND ended_at IS NULL", c.Param("id"), c.GetString("userID")).
+		First(&timer).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No hay un temporizador en marcha para esta tarea"})
+		return
+	}
+
+	now := time.Now()
+	timer.EndedAt = &now
+	timer.Minutes = int(math.Ceil(now.Sub(*timer.StartedAt).Minutes()))
+	if timer.Minutes < 1 {
+		timer.Minutes = 1
+	}
+	if note.Note != nil {
+		timer.Note = note.Note
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&timer).Error; err != nil {
+			return err
+		}
+		return burnEstimate(tx, c, timer.TaskID, timer.Minutes, nil)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al detener el temporizador"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": timer})
+}
+
+type TaskEffort struct {
+	TaskID            string `json:"taskId"`
+	Title             string `json:"title"`
+	Status            string `json:"status"`
+	Minutes           int    `json:"minutes"`
+	OriginalEstimate  *int   `json:"originalEstimate"`
+	RemainingEstimate *int   `json:"remainingEstimate"`
+}
+
+type UserEffort struct {
+	User    models.User  `json:"user"`
+	Minutes int          `json:"minutes"`
+	Tasks   []TaskEffort `json:"tasks"`
+}
+
+// effortReport groups finished worklogs matching the query by user and task.
+func effortReport(query *gorm.DB) ([]UserEffort, int) {
+	var worklogs []models.Worklog
+	query.Preload("User").Preload("Task").Where("worklogs.ended_at IS NOT NULL OR worklogs.started_at IS NULL").Find(&worklogs)
+
+	byUser := map[string]*UserEffort{}
+	taskIndex := map[string]map[string]int{}
+	total := 0
+	for _, worklog := range worklogs {
+		effort, ok := byUser[worklog.UserID]
+		if !ok {
+			effort = &UserEffort{User: worklog.User, Tasks: []TaskEffort{}}
+			byUser[worklog.UserID] = effort
+			taskIndex[worklog.UserID] = map[string]int{}
+		}
+		i, ok := taskIndex[worklog.UserID][worklog.TaskID]
+		if !ok {
+			i = len(effort.Tasks)
+			taskIndex[worklog.UserID][worklog.TaskID] = i
+			effort.Tasks = append(effort.Tasks, TaskEffort{
+				TaskID:            worklog.TaskID,
+				Title:             worklog.Task.Title,
+				Status:            worklog.Task.Status,
+				OriginalEstimate:  worklog.Task.OriginalEstimate,
+				RemainingEstimate: worklog.Task.RemainingEstimate,
+			})
+		}
+		effort.Tasks[i].Minutes += worklog.Minutes
+		effort.Minutes += worklog.Minutes
+		total += worklog.Minutes
+	}
+
+	report := []UserEffort{}
+	for _, effort := range byUser {
+		sort.Slice(effort.Tasks, func(i, j int) bool { return effort.Tasks[i].Minutes > effort.Tasks[j].Minutes })
+		report = append(report, *effort)
+	}
+	sort.Slice(report, func(i, j int) bool { return report[i].Minutes > report[j].Minutes })
+	return report, total
+}
+
+// GET /api/metrics/sprints/:sprintId/effort
+func GetSprintEffort(c *gin.Context) {
+	sprintID := c.Param("sprintId")
+	report, total := effortReport(database.DB.Where("worklogs.sprint_id = ?", sprintID))
+
+	// Estimates of the tasks currently in the sprint
+	var tasks []models.Task
+	database.DB.Where("sprint_id = ?", sprintID).Find(&tasks)
+	original, remaining := 0, 0
+	for _, task := range tasks {
+		if task.OriginalEstimate != nil {
+			original += *task.OriginalEstimate
+		}
+		if task.RemainingEstimate != nil {
+			remaining += *task.RemainingEstimate
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":              report,
+		"totalMinutes":      total,
+		"originalEstimate":  original,
+		"remainingEstimate": remaining,
+	})
+}
+
+// GET /api/metrics/projects/:projectId/effort?from=YYYY-MM-DD&to=YYYY-MM-DD
+func GetProjectEffort(c *gin.Context) {
+	from, err := parseDay(c.Query("from"), time.Time{})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from debe tener el formato YYYY-MM-DD"})
+		return
+	}
+	to, err := parseDay(c.Query("to"), time.Time{})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to debe tener el formato YYYY-MM-DD"})
+		return
+	}
+	inRange := func(query *gorm.DB) *gorm.DB {
+		query = query.Where("worklogs.project_id = ?", c.Param("projectId"))
+		if !from.IsZero() {
+			query = query.Where("worklogs.date >= ?", from)
+		}
+		if !to.IsZero() {
+			query = query.Where("worklogs.date < ?", to.AddDate(0, 0, 1))
+		}
+		return query
+	}
+	report, total := effortReport(inRange(database.DB))
+
+	// Minutes per sprint, so instructors can compare iterations
+	type SprintEffort struct {
+		SprintID *string `json:"sprintId"`
+		Name     string  `json:"name"`
+		Minutes  int     `json:"minutes"`
+	}
+	bySprint := []SprintEffort{}
+	inRange(database.DB.Table("worklogs")).
+		Select("worklogs.sprint_id, COALESCE(sprints.name, '') AS name, SUM(worklogs.minutes) AS minutes").
+		Joins("LEFT JOIN sprints ON sprints.id = worklogs.sprint_id").
+		Group("worklogs.sprint_id, sprints.name").
+		Order("minutes desc").
+		Scan(&bySprint)
+
+	c.JSON(http.StatusOK, gin.H{"data": report, "totalMinutes": total, "sprints": bySprint})
+}
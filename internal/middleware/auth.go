@@ -5,15 +5,28 @@ import (
 	"net/http"
 	"strings"
 
+	"Wrk_Api/internal/database"
+	"Wrk_Api/internal/models"
 	"Wrk_Api/internal/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// queryTokenRoutes accept the access token in ?token=, since browsers can't set
+// headers on WebSocket/EventSource requests. Everywhere else it would only end
+// up in request logs.
+var queryTokenRoutes = map[string]bool{
+	"/api/chat/ws":              true,
+	"/api/notifications/stream": true,
+}
+
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" && queryTokenRoutes[c.FullPath()] && c.Query("token") != "" {
+			authHeader = "Bearer " + c.Query("token")
+		}
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
 			c.Abort()
@@ -42,16 +55,34 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			c.Set("userID", claims["userId"])
-			c.Set("email", claims["email"])
-			c.Set("role", claims["role"])
-		} else {
+		claims, ok := token.Claims.(jwt.MapClaims)
+		if !ok {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
 			c.Abort()
 			return
 		}
 
+		// Tokens are bound to a server-side session so logout, password changes
+		// and deactivation take effect immediately.
+		sessionID, _ := claims["sid"].(string)
+		var session models.Session
+		if sessionID == "" || database.DB.Preload("User").First(&session, "id = ?", sessionID).Error != nil || !session.Active() {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Session expired or revoked"})
+			c.Abort()
+			return
+		}
+		if session.User.ID == "" || !session.User.Active {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User inactive"})
+			c.Abort()
+			return
+		}
+
+		// The role comes from the database so permission changes apply without re-login
+		c.Set("userID", claims["userId"])
+		c.Set("email", claims["email"])
+		c.Set("role", session.User.Role)
+		c.Set("sessionID", sessionID)
+
 		c.Next()
 	}
 }
@@ -0,0 +1,294 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"Wrk_Api/internal/database"
+	"Wrk_Api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Global roles (User.Role)
+const (
+	RoleAdmin = "ADMIN"
+)
+
+// Project roles (ProjectMember.Role). The project owner gets RoleOwner implicitly.
+const (
+	RoleOwner         = "OWNER"
+	RoleProductOwner  = "PRODUCT_OWNER"
+	RoleScrumMaster   = "SCRUM_MASTER"
+	RoleTeamDeveloper = "TEAM_DEVELOPER"
+	RoleEvaluator     = "EVALUATOR"
+)
+
+type Permission string
+
+const (
+	PermProjectView    Permission = "project:view"
+	PermProjectManage  Permission = "project:manage"
+	PermProjectDelete  Permission = "project:delete"
+	PermSprintManage   Permission = "sprint:manage"
+	PermSprintDelete   Permission = "sprint:delete"
+	PermBacklogManage  Permission = "backlog:manage"
+	PermTaskWrite      Permission = "task:write"
+	PermTaskDelete     Permission = "task:delete"
+	PermEvaluate       Permission = "evaluation:write"
+	PermContribute     Permission = "project:contribute"
+	PermDocumentDelete Permission = "document:delete"
+)
+
+var rolePermissions = map[string][]Permission{
+	RoleOwner: {
+		PermProjectView, PermProjectManage, PermProjectDelete, PermSprintManage, PermSprintDelete,
+		PermBacklogManage, PermTaskWrite, PermTaskDelete, PermEvaluate, PermContribute, PermDocumentDelete,
+	},
+	RoleScrumMaster: {
+		PermProjectView, PermProjectManage, PermSprintManage, PermSprintDelete,
+		PermBacklogManage, PermTaskWrite, PermTaskDelete, PermContribute, PermDocumentDelete,
+	},
+	RoleProductOwner: {
+		PermProjectView, PermSprintManage, PermBacklogManage, PermTaskWrite, PermTaskDelete, PermContribute,
+	},
+	RoleTeamDeveloper: {
+		PermProjectView, PermTaskWrite, PermContribute,
+	},
+	RoleEvaluator: {
+		PermProjectView, PermEvaluate, PermContribute,
+	},
+}
+
+// ProjectResolver extracts the project a request operates on.
+// It returns false when the project (or the resource pointing to it) can't be found.
+type ProjectResolver func(c *gin.Context) (string, bool)
+
+// projectQueries maps a table to the query returning the project of one of its rows.
+var projectQueries = map[string]string{
+	"retrospective_items": "SELECT sprints.project_id FROM retrospective_items JOIN sprints ON sprints.id = retrospective_items.sprint_id WHERE retrospective_items.id = ?",
+}
+
+func lookupProjectID(table, id string) (string, bool) {
+	if id == "" {
+		return "", false
+	}
+	query, ok := projectQueries[table]
+	if !ok {
+		query = "SELECT project_id FROM " + table + " WHERE id = ?"
+	}
+	var projectID *string
+	if err := database.DB.Raw(query, id).Scan(&projectID).Error; err != nil || projectID == nil || *projectID == "" {
+		return "", false
+	}
+	return *projectID, true
+}
+
+// bodyField reads a top-level field from the JSON or form body without consuming it.
+func bodyField(c *gin.Context, field string) string {
+	if strings.HasPrefix(c.ContentType(), "multipart/") || c.ContentType() == "application/x-www-form-urlencoded" {
+		return c.PostForm(field)
+	}
+	if c.Request.Body == nil {
+		return ""
+	}
+	raw, err := io.ReadAll(c.Request.Body)
+	c.Request.Body = io.NopCloser(bytes.NewBuffer(raw))
+	if err != nil {
+		return ""
+	}
+	var body map[string]interface{}
+	if json.Unmarshal(raw, &body) != nil {
+		return ""
+	}
+	value, _ := body[field].(string)
+	return value
+}
+
+// ProjectParam resolves the project ID from a path parameter.
+func ProjectParam(param string) ProjectResolver {
+	return func(c *gin.Context) (string, bool) {
+		var count int64
+		database.DB.Model(&models.Project{}).Where("id = ?", c.Param(param)).Count(&count)
+		return c.Param(param), count > 0
+	}
+}
+
+// ProjectField resolves the project ID from a request body field.
+func ProjectField(field string) ProjectResolver {
+	return func(c *gin.Context) (string, bool) {
+		projectID := bodyField(c, field)
+		var count int64
+		database.DB.Model(&models.Project{}).Where("id = ?", projectID).Count(&count)
+		return projectID, count > 0
+	}
+}
+
+// ProjectOf resolves the project of the row in table identified by a path parameter.
+func ProjectOf(table, param string) ProjectResolver {
+	return func(c *gin.Context) (string, bool) {
+		return lookupProjectID(table, c.Param(param))
+	}
+}
+
+// ProjectOfField resolves the project of the row in table identified by a body field.
+func ProjectOfField(table, field string) ProjectResolver {
+	return func(c *gin.Context) (string, bool) {
+		return lookupProjectID(table, bodyField(c, field))
+	}
+}
+
+func currentUser(c *gin.Context) (string, string) {
+	userID, _ := c.Get("userID")
+	role, _ := c.Get("role")
+	id, _ := userID.(string)
+	r, _ := role.(string)
+	return id, r
+}
+
+// ProjectRoles returns the roles the user holds in a project.
+func ProjectRoles(userID, projectID string) []string {
+	roles := []string{}
+
+	var owners int64
+	database.DB.Model(&models.Project{}).Where("id = ? AND owner_id = ?", projectID, userID).Count(&owners)
+	if owners > 0 {
+		roles = append(roles, RoleOwner)
+	}
+
+	var members []models.ProjectMember
+	database.DB.Where("project_id = ? AND user_id = ?", projectID, userID).Limit(1).Find(&members)
+	for _, member := range members {
+		roles = append(roles, member.Role)
+	}
+	return roles
+}
+
+// ProjectsWith returns the projects where the user holds perm, ignoring the global admin role.
+func ProjectsWith(userID string, perm Permission) []string {
+	projectIDs := []string{}
+	if hasPermission([]string{RoleOwner}, perm) {
+		database.DB.Model(&models.Project{}).Where("owner_id = ?", userID).Pluck("id", &projectIDs)
+	}
+
+	roles := []string{}
+	for role := range rolePermissions {
+		if role != RoleOwner && hasPermission([]string{role}, perm) {
+			roles = append(roles, role)
+		}
+	}
+	var memberOf []string
+	database.DB.Model(&models.ProjectMember{}).Where("user_id = ? AND role IN ?", userID, roles).Pluck("project_id", &memberOf)
+	return append(projectIDs, memberOf...)
+}
+
+func hasPermission(roles []string, perm Permission) bool {
+	for _, role := range roles {
+		for _, p := range rolePermissions[role] {
+			if p == perm {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Can reports whether the authenticated user holds perm in the project.
+// Global admins can do everything.
+func Can(c *gin.Context, projectID string, perm Permission) bool {
+	userID, role := currentUser(c)
+	if role == RoleAdmin {
+		return true
+	}
+	return hasPermission(ProjectRoles(userID, projectID), perm)
+}
+
+// IsAdmin reports whether the authenticated user has the global ADMIN role.
+func IsAdmin(c *gin.Context) bool {
+	_, role := currentUser(c)
+	return role == RoleAdmin
+}
+
+// Require allows the request only if the user holds perm in the resolved project.
+func Require(perm Permission, resolve ProjectResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID, ok := resolve(c)
+		if !ok {
+			// Global rubrics/templates have no project; only admins manage those
+			if IsAdmin(c) {
+				c.Next()
+				return
+			}
+			c.JSON(http.StatusNotFound, gin.H{"error": "Recurso no encontrado"})
+			c.Abort()
+			return
+		}
+
+		if !Can(c, projectID, perm) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "No tienes permisos para esta acción"})
+			c.Abort()
+			return
+		}
+
+		c.Set("projectID", projectID)
+		c.Next()
+	}
+}
+
+// RequireRole allows the request only for users with one of the given global roles.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_, role := currentUser(c)
+		for _, r := range roles {
+			if r == role {
+				c.Next()
+				return
+			}
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": "No tienes permisos para esta acción"})
+		c.Abort()
+	}
+}
+
+// RequireSelf allows the request when the path parameter is the caller's own user ID,
+// the caller is an admin, or the caller holds one of perms in a project the target user belongs to.
+func RequireSelf(param string, perms ...Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, role := currentUser(c)
+		target := c.Param(param)
+		if target == userID || role == RoleAdmin {
+			c.Next()
+			return
+		}
+
+		if len(perms) > 0 {
+			var projectIDs []string
+			database.DB.Model(&models.ProjectMember{}).Where("user_id = ?", target).Pluck("project_id", &projectIDs)
+			var owned []string
+			database.DB.Model(&models.Project{}).Where("owner_id = ?", target).Pluck("id", &owned)
+			for _, projectID := range append(projectIDs, owned...) {
+				roles := ProjectRoles(userID, projectID)
+				for _, perm := range perms {
+					if hasPermission(roles, perm) {
+						c.Next()
+						return
+					}
+				}
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+		c.Abort()
+	}
+}
+
+// AnyUser is an explicit no-op for routes open to every authenticated user;
+// the handler itself scopes the data to the caller.
+func AnyUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+	}
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// What happens when a move would put a column over its WIP limit
+const (
+	WipBlock = "BLOCK" // reject the move
+	WipWarn  = "WARN"  // allow it and report a warning
+)
+
+// BoardColumn is a column of a project's Kanban board, holding the tasks in Status.
+type BoardColumn struct {
+	ID        string `gorm:"primaryKey;size:64"`
+	ProjectID string `gorm:"size:64;uniqueIndex:idx_board_column"`
+	Status    string `gorm:"size:64;uniqueIndex:idx_board_column"`
+	Name      string
+	Position  int
+	WipLimit  *int   // nil means no limit
+	WipPolicy string `gorm:"default:'BLOCK'"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	Project Project `gorm:"foreignKey:ProjectID"`
+}
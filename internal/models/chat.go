@@ -5,34 +5,34 @@ import (
 )
 
 type Chat struct {
-	ID        string    `gorm:"primaryKey;type:text"`
+	ID        string    `gorm:"primaryKey;size:64"`
 	ProjectID *string   `gorm:"index"`
 	Title     *string
 	Type      string    `gorm:"default:'PROJECT'"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
 
-	Project      *Project          `gorm:"foreignKey:ProjectID;constraint:OnDelete:CASCADE"`
-	Messages     []Message         `gorm:"foreignKey:ChatID;constraint:OnDelete:CASCADE"`
-	Participants []ChatParticipant `gorm:"foreignKey:ChatID;constraint:OnDelete:CASCADE"`
+	Project      *Project          `gorm:"foreignKey:ProjectID"`
+	Messages     []Message         `gorm:"foreignKey:ChatID"`
+	Participants []ChatParticipant `gorm:"foreignKey:ChatID"`
 }
 
 type ChatParticipant struct {
-	ChatID string `gorm:"primaryKey;type:text"` // Composite PK part 1
-	UserID string `gorm:"primaryKey;type:text"` // Composite PK part 2
+	ChatID string `gorm:"primaryKey;size:64"` // Composite PK part 1
+	UserID string `gorm:"primaryKey;size:64"` // Composite PK part 2
 
-	Chat Chat `gorm:"foreignKey:ChatID;constraint:OnDelete:CASCADE"`
-	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Chat Chat `gorm:"foreignKey:ChatID"`
+	User User `gorm:"foreignKey:UserID"`
 }
 
 type Message struct {
-	ID        string    `gorm:"primaryKey;type:text"`
+	ID        string    `gorm:"primaryKey;size:64"`
 	ChatID    string    `gorm:"index"`
 	UserID    string
 	Content   string
 	CreatedAt time.Time
 	UpdatedAt time.Time
 
-	Chat Chat `gorm:"foreignKey:ChatID;constraint:OnDelete:CASCADE"`
-	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Chat Chat `gorm:"foreignKey:ChatID"`
+	User User `gorm:"foreignKey:UserID"`
 }
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+)
+
+// Comment is a markdown message on a task or user story. Replies point to the
+// top-level comment of their thread through ParentID.
+type Comment struct {
+	ID         string  `gorm:"primaryKey;size:64"`
+	ProjectID  string  `gorm:"index"`
+	EntityType string  `gorm:"index:idx_comment_entity"` // EntityTask or EntityUserStory
+	EntityID   string  `gorm:"index:idx_comment_entity"`
+	ParentID   *string `gorm:"index"`
+	AuthorID   string  `gorm:"index"`
+	Content    string  `gorm:"type:text;not null"`
+	Deleted    bool    // removed by its author but kept because it has replies
+	EditedAt   *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+
+	Author  User      `gorm:"foreignKey:AuthorID"`
+	Project Project   `gorm:"foreignKey:ProjectID"`
+	Replies []Comment `gorm:"foreignKey:ParentID"`
+}
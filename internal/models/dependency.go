@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// Dependency links two tasks or two user stories: BlockerID must be done
+// before work on BlockedID can go on. EntityType is EntityTask or EntityUserStory.
+type Dependency struct {
+	ID          string `gorm:"primaryKey;size:64"`
+	ProjectID   string `gorm:"index"`
+	EntityType  string `gorm:"size:32;uniqueIndex:idx_dependency_link"`
+	BlockerID   string `gorm:"size:64;uniqueIndex:idx_dependency_link;index"`
+	BlockedID   string `gorm:"size:64;uniqueIndex:idx_dependency_link;index"`
+	CreatedByID *string
+	CreatedAt   time.Time
+
+	Project   Project `gorm:"foreignKey:ProjectID"`
+	CreatedBy *User   `gorm:"foreignKey:CreatedByID"`
+}
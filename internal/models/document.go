@@ -5,17 +5,21 @@ import (
 )
 
 type Document struct {
-	ID        string    `gorm:"primaryKey;type:text"`
-	ProjectID string    `gorm:"index"`
-	Name      string
-	URL       string
-	Type      string
-	Size      *int
-	Version   int       `gorm:"default:1"`
-	ParentID  *string   `gorm:"index"`
-	UploadedAt time.Time
+	ID           string `gorm:"primaryKey;size:64"`
+	ProjectID    string `gorm:"index"`
+	Name         string
+	URL          string
+	Type         string // MIME type sniffed from the content
+	Size         *int   // KB
+	SizeBytes    int64
+	Checksum     string // hex SHA-256 of the content
+	StorageKey   string
+	Version      int     `gorm:"default:1;uniqueIndex:idx_document_version,priority:2"`
+	ParentID     *string `gorm:"index;size:64;uniqueIndex:idx_document_version,priority:1"` // first version of the document; nil on the first version itself
+	UploadedByID *string
+	UploadedAt   time.Time
 
-	Project Project   `gorm:"foreignKey:ProjectID;constraint:OnDelete:CASCADE"`
-	Parent  *Document `gorm:"foreignKey:ParentID;constraint:OnDelete:SET NULL"`
+	Project  Project    `gorm:"foreignKey:ProjectID"`
+	Parent   *Document  `gorm:"foreignKey:ParentID"`
 	Versions []Document `gorm:"foreignKey:ParentID"`
 }
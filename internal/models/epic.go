@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	EpicOpen = "OPEN"
+	EpicDone = "DONE"
+)
+
+// Epic groups user stories of a project around a larger feature or theme.
+type Epic struct {
+	ID          string `gorm:"primaryKey;size:64"`
+	ProjectID   string `gorm:"index"`
+	Title       string `gorm:"not null"`
+	Description *string
+	Color       *string
+	Status      string `gorm:"default:'OPEN'"`
+	TargetDate  *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+
+	Project     Project     `gorm:"foreignKey:ProjectID"`
+	UserStories []UserStory `gorm:"foreignKey:EpicID"`
+}
@@ -5,55 +5,68 @@ import (
 )
 
 type Rubric struct {
-	ID          string    `gorm:"primaryKey;type:text"`
+	ID          string    `gorm:"primaryKey;size:64"`
 	ProjectID   *string   `gorm:"index"`
 	Name        string    `gorm:"not null"`
 	Description *string
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 
-	Project  *Project   `gorm:"foreignKey:ProjectID;constraint:OnDelete:CASCADE"`
-	Criteria []Criteria `gorm:"foreignKey:RubricID;constraint:OnDelete:CASCADE"`
+	Project  *Project   `gorm:"foreignKey:ProjectID"`
+	Criteria []Criteria `gorm:"foreignKey:RubricID"`
 }
 
 type Criteria struct {
-	ID          string `gorm:"primaryKey;type:text"`
+	ID          string `gorm:"primaryKey;size:64"`
 	RubricID    string `gorm:"index"`
 	Name        string `gorm:"not null"`
 	Description *string
 	MaxScore    int `gorm:"default:100"`
 	Weight      int `gorm:"default:1"`
 
-	Rubric      Rubric               `gorm:"foreignKey:RubricID;constraint:OnDelete:CASCADE"`
+	Rubric      Rubric               `gorm:"foreignKey:RubricID"`
 	Evaluations []EvaluationCriteria `gorm:"foreignKey:CriteriaID"`
 }
 
+// Evaluation lifecycle: DRAFT -> SUBMITTED -> PUBLISHED, reopening goes back to DRAFT.
+// COMPLETED is the status written before the lifecycle existed and counts as published.
+const (
+	EvaluationDraft     = "DRAFT"
+	EvaluationSubmitted = "SUBMITTED"
+	EvaluationPublished = "PUBLISHED"
+	EvaluationCompleted = "COMPLETED"
+)
+
 type Evaluation struct {
-	ID          string    `gorm:"primaryKey;type:text"`
+	ID          string    `gorm:"primaryKey;size:64"`
 	ProjectID   string    `gorm:"index"`
 	TaskID      *string   `gorm:"index"`
 	SprintID    *string   `gorm:"index"`
 	EvaluatorID string    `gorm:"index"`
-	Status      string    `gorm:"default:'PENDING'"`
+	RubricID    *string   `gorm:"index"`
+	Status      string    `gorm:"default:'DRAFT'"`
 	Feedback    *string
 	Score       *int
+	SubmittedAt *time.Time
+	PublishedAt *time.Time
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 
-	Project   Project              `gorm:"foreignKey:ProjectID;constraint:OnDelete:CASCADE"`
-	Task      *Task                `gorm:"foreignKey:TaskID;constraint:OnDelete:CASCADE"`
-	Sprint    *Sprint              `gorm:"foreignKey:SprintID;constraint:OnDelete:CASCADE"`
+	Project   Project              `gorm:"foreignKey:ProjectID"`
+	Task      *Task                `gorm:"foreignKey:TaskID"`
+	Sprint    *Sprint              `gorm:"foreignKey:SprintID"`
 	Evaluator User                 `gorm:"foreignKey:EvaluatorID"`
-	Criteria  []EvaluationCriteria `gorm:"foreignKey:EvaluationID;constraint:OnDelete:CASCADE"`
+	Rubric    *Rubric              `gorm:"foreignKey:RubricID"`
+	Criteria  []EvaluationCriteria `gorm:"foreignKey:EvaluationID"`
 }
 
 type EvaluationCriteria struct {
-	ID           string `gorm:"primaryKey;type:text"`
+	ID           string `gorm:"primaryKey;size:64"`
 	EvaluationID string `gorm:"index:idx_eval_criteria,unique"`
 	CriteriaID   string `gorm:"index:idx_eval_criteria,unique"`
 	Score        int    `gorm:"default:0"`
 	Comment      *string
 
-	Evaluation Evaluation `gorm:"foreignKey:EvaluationID;constraint:OnDelete:CASCADE"`
+	Evaluation Evaluation `gorm:"foreignKey:EvaluationID"`
 	Criteria   Criteria   `gorm:"foreignKey:CriteriaID"`
 }
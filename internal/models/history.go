@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// History entity types
+const (
+	EntityTask      = "TASK"
+	EntityUserStory = "USER_STORY"
+)
+
+// HistoryEntry records a single field change on a task or user story.
+type HistoryEntry struct {
+	ID         string `gorm:"primaryKey;size:64"`
+	EntityType string `gorm:"index:idx_history_entity"`
+	EntityID   string `gorm:"index:idx_history_entity"`
+	ProjectID  string `gorm:"index"`
+	Field      string `gorm:"index"`
+	OldValue   *string
+	NewValue   *string
+	UserID     *string
+	CreatedAt  time.Time `gorm:"index"`
+
+	User *User `gorm:"foreignKey:UserID"`
+}
@@ -5,7 +5,7 @@ import (
 )
 
 type Notification struct {
-	ID        string    `gorm:"primaryKey;type:text"`
+	ID        string    `gorm:"primaryKey;size:64"`
 	UserID    string    `gorm:"index"`
 	Title     string
 	Message   string
@@ -13,5 +13,5 @@ type Notification struct {
 	Read      bool      `gorm:"default:false"`
 	CreatedAt time.Time
 
-	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	User User `gorm:"foreignKey:UserID"`
 }
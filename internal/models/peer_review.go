@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	PeerRoundOpen   = "OPEN"
+	PeerRoundClosed = "CLOSED"
+)
+
+// PeerReviewRound asks every team member of a project to rate each teammate,
+// and themselves, against a rubric at the end of a sprint.
+type PeerReviewRound struct {
+	ID          string  `gorm:"primaryKey;size:64"`
+	ProjectID   string  `gorm:"index"`
+	SprintID    *string `gorm:"index"`
+	RubricID    string  `gorm:"index"`
+	CreatedByID string
+	Title       string `gorm:"not null"`
+	Anonymous   bool   `gorm:"default:false"`
+	Status      string `gorm:"default:'OPEN'"`
+	DueDate     *time.Time
+	ClosedAt    *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+
+	Project Project      `gorm:"foreignKey:ProjectID"`
+	Sprint  *Sprint      `gorm:"foreignKey:SprintID"`
+	Rubric  Rubric       `gorm:"foreignKey:RubricID"`
+	Reviews []PeerReview `gorm:"foreignKey:RoundID"`
+}
+
+// PeerReview is one member's rating of a teammate (or of themselves when
+// ReviewerID equals RevieweeID) within a round.
+type PeerReview struct {
+	ID         string `gorm:"primaryKey;size:64"`
+	RoundID    string `gorm:"index:idx_peer_review,unique"`
+	ReviewerID string `gorm:"index:idx_peer_review,unique"`
+	RevieweeID string `gorm:"index:idx_peer_review,unique"`
+	Score      int
+	Comment    *string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+
+	Round    PeerReviewRound   `gorm:"foreignKey:RoundID"`
+	Reviewer User              `gorm:"foreignKey:ReviewerID"`
+	Reviewee User              `gorm:"foreignKey:RevieweeID"`
+	Scores   []PeerReviewScore `gorm:"foreignKey:ReviewID"`
+}
+
+type PeerReviewScore struct {
+	ID         string `gorm:"primaryKey;size:64"`
+	ReviewID   string `gorm:"index:idx_peer_review_criteria,unique"`
+	CriteriaID string `gorm:"index:idx_peer_review_criteria,unique"`
+	Score      int
+
+	Review   PeerReview `gorm:"foreignKey:ReviewID"`
+	Criteria Criteria   `gorm:"foreignKey:CriteriaID"`
+}
@@ -5,7 +5,7 @@ import (
 )
 
 type Project struct {
-	ID          string    `gorm:"primaryKey;type:text"`
+	ID          string    `gorm:"primaryKey;size:64"`
 	Name        string    `gorm:"not null"`
 	Description *string
 	Status      string    `gorm:"default:'ACTIVE'"`
@@ -14,26 +14,33 @@ type Project struct {
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 
+	// Storage limits; nil falls back to the server defaults
+	StorageQuota *int64  // bytes, 0 = unlimited
+	MaxFileSize  *int64  // bytes, 0 = unlimited
+	AllowedTypes *string // comma-separated MIME types, "image/*" wildcards allowed
+
+	RetroTemplate string `gorm:"default:'CLASSIC'"` // format of new retrospectives
+
 	// Relations
 	OwnerID     string
 	Owner       User      `gorm:"foreignKey:OwnerID"`
-	Members     []ProjectMember `gorm:"foreignKey:ProjectID;constraint:OnDelete:CASCADE"`
-	Sprints     []Sprint        `gorm:"foreignKey:ProjectID;constraint:OnDelete:CASCADE"`
-	UserStories []UserStory     `gorm:"foreignKey:ProjectID;constraint:OnDelete:CASCADE"`
-	Tasks       []Task          `gorm:"foreignKey:ProjectID;constraint:OnDelete:CASCADE"`
-	Evaluations []Evaluation    `gorm:"foreignKey:ProjectID;constraint:OnDelete:CASCADE"`
-	Rubrics     []Rubric        `gorm:"foreignKey:ProjectID;constraint:OnDelete:CASCADE"`
-	Chats       []Chat          `gorm:"foreignKey:ProjectID;constraint:OnDelete:CASCADE"`
-	Documents   []Document      `gorm:"foreignKey:ProjectID;constraint:OnDelete:CASCADE"`
+	Members     []ProjectMember `gorm:"foreignKey:ProjectID"`
+	Sprints     []Sprint        `gorm:"foreignKey:ProjectID"`
+	UserStories []UserStory     `gorm:"foreignKey:ProjectID"`
+	Tasks       []Task          `gorm:"foreignKey:ProjectID"`
+	Evaluations []Evaluation    `gorm:"foreignKey:ProjectID"`
+	Rubrics     []Rubric        `gorm:"foreignKey:ProjectID"`
+	Chats       []Chat          `gorm:"foreignKey:ProjectID"`
+	Documents   []Document      `gorm:"foreignKey:ProjectID"`
 }
 
 type ProjectMember struct {
-	ID        string    `gorm:"primaryKey;type:text"`
+	ID        string    `gorm:"primaryKey;size:64"`
 	ProjectID string    `gorm:"index:idx_project_user,unique"`
 	UserID    string    `gorm:"index:idx_project_user,unique"`
 	Role      string    `gorm:"not null"`
 	JoinedAt  time.Time `gorm:"default:CURRENT_TIMESTAMP"`
 
-	Project Project `gorm:"foreignKey:ProjectID;constraint:OnDelete:CASCADE"`
-	User    User    `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Project Project `gorm:"foreignKey:ProjectID"`
+	User    User    `gorm:"foreignKey:UserID"`
 }
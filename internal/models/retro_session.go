@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+)
+
+// Retro session phases, in order
+const (
+	RetroPhaseCollect = "COLLECT"
+	RetroPhaseGroup   = "GROUP"
+	RetroPhaseVote    = "VOTE"
+	RetroPhaseDiscuss = "DISCUSS"
+	RetroPhaseClosed  = "CLOSED"
+)
+
+var RetroPhases = []string{RetroPhaseCollect, RetroPhaseGroup, RetroPhaseVote, RetroPhaseDiscuss, RetroPhaseClosed}
+
+// RetroSession runs the retrospective of a sprint through its phases.
+type RetroSession struct {
+	ID            string `gorm:"primaryKey;size:64"`
+	ProjectID     string `gorm:"index"`
+	SprintID      string `gorm:"size:64;uniqueIndex"`
+	Title         string
+	Template      string // retro format, copied from the project when the session opens
+	Phase         string `gorm:"default:'COLLECT'"`
+	VoteBudget    int    `gorm:"default:3"` // dots each participant can spend
+	FacilitatorID *string
+	ClosedAt      *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+
+	Project     Project             `gorm:"foreignKey:ProjectID"`
+	Sprint      Sprint              `gorm:"foreignKey:SprintID"`
+	Facilitator *User               `gorm:"foreignKey:FacilitatorID"`
+	Items       []RetrospectiveItem `gorm:"foreignKey:SessionID"`
+	Groups      []RetroGroup        `gorm:"foreignKey:SessionID"`
+}
+
+// RetroGroup clusters related items so they are voted and discussed together.
+type RetroGroup struct {
+	ID        string `gorm:"primaryKey;size:64"`
+	SessionID string `gorm:"index"`
+	Title     string
+	CreatedAt time.Time
+
+	Session RetroSession `gorm:"foreignKey:SessionID"`
+}
+
+// RetroVote is one dot placed on an ungrouped item or on a group. A user may
+// put several dots on the same target.
+type RetroVote struct {
+	ID         string `gorm:"primaryKey;size:64"`
+	SessionID  string `gorm:"index"`
+	UserID     string `gorm:"index"`
+	TargetType string // ITEM or GROUP
+	TargetID   string `gorm:"index"`
+	CreatedAt  time.Time
+
+	Session RetroSession `gorm:"foreignKey:SessionID"`
+}
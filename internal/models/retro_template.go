@@ -0,0 +1,76 @@
+package models
+
+// Retrospective formats a project can choose from
+const (
+	RetroTemplateClassic           = "CLASSIC"
+	RetroTemplateStartStopContinue = "START_STOP_CONTINUE"
+	RetroTemplateFourLs            = "FOUR_LS"
+	RetroTemplateMadSadGlad        = "MAD_SAD_GLAD"
+	RetroActionType                = "ACTION"
+)
+
+// RetroColumn is one column of a retrospective board; Type is the value items store.
+type RetroColumn struct {
+	Type  string
+	Label string
+}
+
+// RetroTemplate defines the columns valid for a retrospective. Every template
+// keeps an ACTION column so agreed actions can become tasks.
+type RetroTemplate struct {
+	Key     string
+	Name    string
+	Columns []RetroColumn
+}
+
+var actionColumn = RetroColumn{Type: RetroActionType, Label: "Acciones"}
+
+var RetroTemplates = []RetroTemplate{
+	{Key: RetroTemplateClassic, Name: "Bien / Mal / Acciones", Columns: []RetroColumn{
+		{Type: "GOOD", Label: "Lo que salió bien"},
+		{Type: "BAD", Label: "Lo que salió mal"},
+		actionColumn,
+	}},
+	{Key: RetroTemplateStartStopContinue, Name: "Start / Stop / Continue", Columns: []RetroColumn{
+		{Type: "START", Label: "Empezar a hacer"},
+		{Type: "STOP", Label: "Dejar de hacer"},
+		{Type: "CONTINUE", Label: "Seguir haciendo"},
+		actionColumn,
+	}},
+	{Key: RetroTemplateFourLs, Name: "4Ls", Columns: []RetroColumn{
+		{Type: "LIKED", Label: "Nos gustó"},
+		{Type: "LEARNED", Label: "Aprendimos"},
+		{Type: "LACKED", Label: "Nos faltó"},
+		{Type: "LONGED_FOR", Label: "Deseamos"},
+		actionColumn,
+	}},
+	{Key: RetroTemplateMadSadGlad, Name: "Mad / Sad / Glad", Columns: []RetroColumn{
+		{Type: "MAD", Label: "Enojados"},
+		{Type: "SAD", Label: "Tristes"},
+		{Type: "GLAD", Label: "Contentos"},
+		actionColumn,
+	}},
+}
+
+// FindRetroTemplate returns the template with the key; an empty key is the classic format.
+func FindRetroTemplate(key string) (RetroTemplate, bool) {
+	if key == "" {
+		key = RetroTemplateClassic
+	}
+	for _, template := range RetroTemplates {
+		if template.Key == key {
+			return template, true
+		}
+	}
+	return RetroTemplate{}, false
+}
+
+// HasType reports whether items of the type belong in the template.
+func (t RetroTemplate) HasType(itemType string) bool {
+	for _, column := range t.Columns {
+		if column.Type == itemType {
+			return true
+		}
+	}
+	return false
+}
@@ -5,13 +5,18 @@ import (
 )
 
 type RetrospectiveItem struct {
-	ID        string    `gorm:"primaryKey;type:text"`
-	SprintID  string    `gorm:"index"`
+	ID        string  `gorm:"primaryKey;size:64"`
+	SprintID  string  `gorm:"index"`
+	SessionID *string `gorm:"index"` // set for items collected in a retro session
+	GroupID   *string `gorm:"index"`
 	Type      string
 	Content   string
 	UserID    string
+	Anonymous bool // author hidden from everyone but admins
 	CreatedAt time.Time
 
-	Sprint Sprint `gorm:"foreignKey:SprintID;constraint:OnDelete:CASCADE"`
-	User   User   `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Sprint Sprint      `gorm:"foreignKey:SprintID"`
+	User   User        `gorm:"foreignKey:UserID"`
+	Group  *RetroGroup `gorm:"foreignKey:GroupID"`
+	Task   *Task       `gorm:"foreignKey:RetroItemID"` // task created from an ACTION item
 }
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+type Session struct {
+	ID               string `gorm:"primaryKey;size:64"`
+	UserID           string `gorm:"index"`
+	RefreshTokenHash string `gorm:"size:64;uniqueIndex;not null"`
+	UserAgent        string
+	IP               string
+	ExpiresAt        time.Time
+	RevokedAt        *time.Time
+	LastUsedAt       time.Time
+	CreatedAt        time.Time
+
+	User User `gorm:"foreignKey:UserID"`
+}
+
+// Active reports whether the session can still authenticate requests.
+func (s *Session) Active() bool {
+	return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
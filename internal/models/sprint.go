@@ -4,26 +4,86 @@ import (
 	"time"
 )
 
+// Sprint lifecycle: PLANNING -> ACTIVE -> COMPLETED, driven by the start and complete actions.
+const (
+	SprintPlanning  = "PLANNING"
+	SprintActive    = "ACTIVE"
+	SprintCompleted = "COMPLETED"
+)
+
 type Sprint struct {
-	ID          string    `gorm:"primaryKey;type:text"`
+	ID          string    `gorm:"primaryKey;size:64"`
 	ProjectID   string    `gorm:"index"`
 	Name        string    `gorm:"not null"`
 	Description *string
 	StartDate   time.Time
 	EndDate     time.Time
 	Status      string    `gorm:"default:'PLANNING'"`
+	StartedAt   *time.Time
+	CompletedAt *time.Time
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 
-	Project            Project             `gorm:"foreignKey:ProjectID;constraint:OnDelete:CASCADE"`
+	Project            Project             `gorm:"foreignKey:ProjectID"`
 	UserStories        []UserStory         `gorm:"foreignKey:SprintID"`
 	Tasks              []Task              `gorm:"foreignKey:SprintID"`
 	RetrospectiveItems []RetrospectiveItem `gorm:"foreignKey:SprintID"`
 	Evaluations        []Evaluation        `gorm:"foreignKey:SprintID"`
 }
 
+// Where unfinished work goes when a sprint is completed
+const (
+	CarryOverBacklog    = "BACKLOG"
+	CarryOverNextSprint = "NEXT_SPRINT"
+)
+
+// SprintReport snapshots the scope committed when the sprint started and what
+// was completed and carried over when it ended.
+type SprintReport struct {
+	ID                 string `gorm:"primaryKey;size:64"`
+	SprintID           string `gorm:"uniqueIndex"`
+	CommittedStories   int
+	CommittedPoints    int
+	CommittedTasks     int
+	CompletedStories   int
+	CompletedPoints    int
+	CompletedTasks     int
+	CarriedOverStories int
+	CarriedOverPoints  int
+	CarriedOverTasks   int
+	CarryOver          *string // BACKLOG or NEXT_SPRINT
+	NextSprintID       *string
+	StartedAt          time.Time
+	CompletedAt        *time.Time
+
+	Sprint     Sprint  `gorm:"foreignKey:SprintID"`
+	NextSprint *Sprint `gorm:"foreignKey:NextSprintID"`
+}
+
+const (
+	ScopeAdded           = "ADDED"
+	ScopeRemoved         = "REMOVED"
+	ScopeEstimateChanged = "ESTIMATE_CHANGED"
+)
+
+// SprintScopeChange records a change to the scope of a sprint after it started.
+// Delta is the change in story points (negative when scope shrinks).
+type SprintScopeChange struct {
+	ID          string `gorm:"primaryKey;size:64"`
+	SprintID    string `gorm:"index"`
+	UserStoryID string `gorm:"index"`
+	Type        string
+	Delta       int
+	UserID      *string
+	CreatedAt   time.Time
+
+	Sprint    Sprint    `gorm:"foreignKey:SprintID"`
+	UserStory UserStory `gorm:"foreignKey:UserStoryID"`
+	User      *User     `gorm:"foreignKey:UserID"`
+}
+
 type UserStory struct {
-	ID          string    `gorm:"primaryKey;type:text"`
+	ID          string    `gorm:"primaryKey;size:64"`
 	ProjectID   string    `gorm:"index"`
 	Title       string    `gorm:"not null"`
 	Description string    `gorm:"not null"`
@@ -31,6 +91,8 @@ type UserStory struct {
 	Priority    string    `gorm:"default:'MEDIUM'"`
 	StoryPoints *int
 	Status      string    `gorm:"default:'BACKLOG'"`
+	Rank        string    `gorm:"index"` // backlog order, compared lexicographically
+	Blocked     bool      // set while a story it depends on isn't done
 	CompletedAt *time.Time
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
@@ -39,28 +101,36 @@ type UserStory struct {
 	Assignee   *User   `gorm:"foreignKey:AssigneeID"`
 	SprintID   *string
 	Sprint     *Sprint `gorm:"foreignKey:SprintID"`
-	Project    Project `gorm:"foreignKey:ProjectID;constraint:OnDelete:CASCADE"`
+	EpicID     *string `gorm:"index"`
+	Epic       *Epic   `gorm:"foreignKey:EpicID"`
+	Project    Project `gorm:"foreignKey:ProjectID"`
 	Tasks      []Task  `gorm:"foreignKey:UserStoryID"`
 }
 
 type Task struct {
-	ID          string    `gorm:"primaryKey;type:text"`
-	ProjectID   string    `gorm:"index"`
-	UserStoryID *string   `gorm:"index"`
-	SprintID    *string   `gorm:"index"`
-	Title       string    `gorm:"not null"`
-	Description *string
-	Priority    string    `gorm:"default:'MEDIUM'"`
-	Status      string    `gorm:"default:'TODO'"`
-	Deadline    *time.Time
-	CompletedAt *time.Time
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	ID                string  `gorm:"primaryKey;size:64"`
+	ProjectID         string  `gorm:"index"`
+	UserStoryID       *string `gorm:"index"`
+	SprintID          *string `gorm:"index"`
+	Title             string  `gorm:"not null"`
+	Description       *string
+	Priority          string `gorm:"default:'MEDIUM'"`
+	Status            string `gorm:"default:'TODO'"`
+	Deadline          *time.Time
+	Blocked           bool    // set while a task it depends on isn't done
+	BoardRank         string  `gorm:"index"` // order within its board column, compared lexicographically
+	OriginalEstimate  *int    // minutes
+	RemainingEstimate *int    // minutes, lowered as work is logged
+	RetroItemID       *string `gorm:"index"` // retro ACTION item the task was created from
+	CompletedAt       *time.Time
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
 
-	AssigneeID *string
-	Assignee   *User      `gorm:"foreignKey:AssigneeID"`
-	Project    Project    `gorm:"foreignKey:ProjectID;constraint:OnDelete:CASCADE"`
-	UserStory  *UserStory `gorm:"foreignKey:UserStoryID"`
-	Sprint     *Sprint    `gorm:"foreignKey:SprintID"`
+	AssigneeID  *string
+	Assignee    *User        `gorm:"foreignKey:AssigneeID"`
+	Project     Project      `gorm:"foreignKey:ProjectID"`
+	UserStory   *UserStory   `gorm:"foreignKey:UserStoryID"`
+	Sprint      *Sprint      `gorm:"foreignKey:SprintID"`
 	Evaluations []Evaluation `gorm:"foreignKey:TaskID"`
+	Worklogs    []Worklog    `gorm:"foreignKey:TaskID"`
 }
@@ -7,13 +7,13 @@ import (
 )
 
 type User struct {
-	ID        string         `gorm:"primaryKey;type:text"`
-	Email     string         `gorm:"uniqueIndex;not null"`
-	Name      string         `gorm:"not null"`
-	Password  string         `gorm:"not null"`
-	Role      string         `gorm:"default:'TEAM_DEVELOPER'"`
-	Avatar    *string        `gorm:"type:text"`
-	Active    bool           `gorm:"default:true"`
+	ID        string  `gorm:"primaryKey;size:64"`
+	Email     string  `gorm:"size:255;uniqueIndex;not null"`
+	Name      string  `gorm:"not null"`
+	Password  string  `gorm:"not null"`
+	Role      string  `gorm:"default:'TEAM_DEVELOPER'"`
+	Avatar    *string `gorm:"type:text"`
+	Active    bool    `gorm:"default:true"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	DeletedAt gorm.DeletedAt `gorm:"index"`
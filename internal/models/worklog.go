@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+// Worklog records time spent on a task, in minutes. A worklog with StartedAt
+// set and no EndedAt is a running timer; Minutes is filled in when it stops.
+type Worklog struct {
+	ID        string  `gorm:"primaryKey;size:64"`
+	TaskID    string  `gorm:"index"`
+	ProjectID string  `gorm:"index"`
+	SprintID  *string `gorm:"index"` // sprint of the task when the work was logged
+	UserID    string  `gorm:"index"`
+	Minutes   int
+	Date      time.Time `gorm:"index"` // day the work was done
+	Note      *string
+	StartedAt *time.Time
+	EndedAt   *time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	Task    Task    `gorm:"foreignKey:TaskID"`
+	Project Project `gorm:"foreignKey:ProjectID"`
+	Sprint  *Sprint `gorm:"foreignKey:SprintID"`
+	User    User    `gorm:"foreignKey:UserID"`
+}
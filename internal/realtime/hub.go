@@ -0,0 +1,128 @@
+package realtime
+
+import (
+	"sync"
+)
+
+// Event is the payload pushed to subscribers (WebSocket and SSE clients).
+type Event struct {
+	ID   string      `json:"id,omitempty"`
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// Subscription receives the events published to the topics it follows.
+type Subscription struct {
+	C chan Event
+	// Done is closed when the hub disconnects the subscription (see Disconnect).
+	Done chan struct{}
+
+	hub    *Hub
+	topics map[string]bool
+}
+
+// Hub fans events out to every subscription following a topic.
+type Hub struct {
+	mu     sync.RWMutex
+	topics map[string]map[*Subscription]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{topics: make(map[string]map[*Subscription]struct{})}
+}
+
+// Default is the process-wide hub used by the handlers.
+var Default = NewHub()
+
+func UserTopic(userID string) string { return "user:" + userID }
+func ChatTopic(chatID string) string { return "chat:" + chatID }
+
+func NotificationTopic(userID string) string { return "notifications:" + userID }
+
+// ProjectChatTopic is keyed by project because project chats are created lazily.
+func ProjectChatTopic(projectID string) string { return "project-chat:" + projectID }
+
+// SessionTopic carries no events; connections follow it so they can be
+// disconnected when their session is revoked.
+func SessionTopic(sessionID string) string { return "session:" + sessionID }
+
+func (h *Hub) Subscribe(topics ...string) *Subscription {
+	sub := &Subscription{
+		C:      make(chan Event, 64),
+		Done:   make(chan struct{}),
+		hub:    h,
+		topics: make(map[string]bool),
+	}
+	sub.Add(topics...)
+	return sub
+}
+
+// Add makes the subscription follow more topics.
+func (s *Subscription) Add(topics ...string) {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	for _, topic := range topics {
+		if s.topics[topic] {
+			continue
+		}
+		s.topics[topic] = true
+		if s.hub.topics[topic] == nil {
+			s.hub.topics[topic] = make(map[*Subscription]struct{})
+		}
+		s.hub.topics[topic][s] = struct{}{}
+	}
+}
+
+// Remove stops following the given topics.
+func (s *Subscription) Remove(topics ...string) {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	for _, topic := range topics {
+		s.hub.unlink(s, topic)
+	}
+}
+
+// Close detaches the subscription from every topic.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	for topic := range s.topics {
+		s.hub.unlink(s, topic)
+	}
+}
+
+// Disconnect ends every subscription following the topic: it is detached from
+// all its topics and its Done channel is closed.
+func (h *Hub) Disconnect(topic string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.topics[topic] {
+		for t := range sub.topics {
+			h.unlink(sub, t)
+		}
+		close(sub.Done)
+	}
+}
+
+func (h *Hub) unlink(s *Subscription, topic string) {
+	delete(s.topics, topic)
+	if subs, ok := h.topics[topic]; ok {
+		delete(subs, s)
+		if len(subs) == 0 {
+			delete(h.topics, topic)
+		}
+	}
+}
+
+// Publish delivers the event to every subscription of the topic.
+// Slow subscribers whose buffer is full miss the event rather than blocking the publisher.
+func (h *Hub) Publish(topic string, event Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for sub := range h.topics[topic] {
+		select {
+		case sub.C <- event:
+		default:
+		}
+	}
+}
@@ -18,135 +18,267 @@ func SetupRoutes(r *gin.Engine) {
 	{
 		auth.POST("/register", handlers.Register)
 		auth.POST("/login", handlers.Login)
+		auth.POST("/refresh", handlers.Refresh)
+		auth.POST("/logout", middleware.AuthMiddleware(), handlers.Logout)
+		auth.POST("/logout-all", middleware.AuthMiddleware(), handlers.LogoutAll)
+		auth.GET("/sessions", middleware.AuthMiddleware(), handlers.GetSessions)
 	}
 
+	// Permission shorthands
+	admin := middleware.RequireRole(middleware.RoleAdmin)
+	anyUser := middleware.AnyUser()
+	can := middleware.Require
+
 	// Protected Routes
 	protected := api.Group("/")
 	protected.Use(middleware.AuthMiddleware())
 	{
 		// Users
-		protected.GET("/users", handlers.GetAllUsers)
-		protected.GET("/users/:id", handlers.GetUser)
-		protected.POST("/users/", handlers.CreateUser)
-		protected.PUT("/users/:id", handlers.UpdateUser)
-		protected.DELETE("/users/:id", handlers.DeleteUser)
+		protected.GET("/users", anyUser, handlers.GetAllUsers)
+		protected.GET("/users/:id", anyUser, handlers.GetUser)
+		protected.POST("/users/", admin, handlers.CreateUser)
+		protected.PUT("/users/:id", middleware.RequireSelf("id"), handlers.UpdateUser)
+		protected.DELETE("/users/:id", admin, handlers.DeleteUser)
+
+		// Search across everything the caller can see
+		protected.GET("/search", anyUser, handlers.Search)
 
 		// Projects
 		projects := protected.Group("/projects")
 		{
-			projects.GET("/", handlers.GetAllProjects)
-			projects.GET("/:id", handlers.GetProject)
-			projects.POST("/", handlers.CreateProject)
-			projects.PUT("/:id", handlers.UpdateProject)
-			projects.DELETE("/:id", handlers.DeleteProject)
+			projects.GET("/", anyUser, handlers.GetAllProjects)
+			projects.GET("/:id", can(middleware.PermProjectView, middleware.ProjectParam("id")), handlers.GetProject)
+			projects.POST("/", anyUser, handlers.CreateProject)
+			projects.PUT("/:id", can(middleware.PermProjectManage, middleware.ProjectParam("id")), handlers.UpdateProject)
+			projects.DELETE("/:id", can(middleware.PermProjectDelete, middleware.ProjectParam("id")), handlers.DeleteProject)
 
 			// Project Members
-			projects.POST("/:id/members", handlers.AddProjectMember)
-			projects.DELETE("/:id/members/:userId", handlers.RemoveProjectMember)
+			projects.POST("/:id/members", can(middleware.PermProjectManage, middleware.ProjectParam("id")), handlers.AddProjectMember)
+			projects.DELETE("/:id/members/:userId", can(middleware.PermProjectManage, middleware.ProjectParam("id")), handlers.RemoveProjectMember)
+
+			// Storage usage and limits; only admins change the limits
+			projects.GET("/:id/storage", can(middleware.PermProjectView, middleware.ProjectParam("id")), handlers.GetProjectStorage)
+			projects.PUT("/:id/storage", admin, handlers.UpdateProjectStorage)
+
+			// Retrospective format used by new retros
+			projects.GET("/:id/retro-template", can(middleware.PermProjectView, middleware.ProjectParam("id")), handlers.GetProjectRetroTemplate)
+			projects.PUT("/:id/retro-template", can(middleware.PermProjectManage, middleware.ProjectParam("id")), handlers.UpdateProjectRetroTemplate)
+
+			// Unscheduled stories in rank order
+			projects.GET("/:id/backlog", can(middleware.PermProjectView, middleware.ProjectParam("id")), handlers.GetProjectBacklog)
 		}
 
 		// Sprints
 		sprints := protected.Group("/sprints")
 		{
-			sprints.GET("/", handlers.GetAllSprints)
-			sprints.GET("/:id", handlers.GetSprint)
-			sprints.POST("/", handlers.CreateSprint)
-			sprints.PUT("/:id", handlers.UpdateSprint)
-			sprints.DELETE("/:id", handlers.DeleteSprint)
-			
+			sprints.GET("/", anyUser, handlers.GetAllSprints)
+			sprints.GET("/:id", can(middleware.PermProjectView, middleware.ProjectOf("sprints", "id")), handlers.GetSprint)
+			sprints.POST("/", can(middleware.PermSprintManage, middleware.ProjectField("projectId")), handlers.CreateSprint)
+			sprints.PUT("/:id", can(middleware.PermSprintManage, middleware.ProjectOf("sprints", "id")), handlers.UpdateSprint)
+			sprints.DELETE("/:id", can(middleware.PermSprintDelete, middleware.ProjectOf("sprints", "id")), handlers.DeleteSprint)
+
 			// Sprint Actions
-			sprints.POST("/:id/add-story", handlers.AddStoryToSprint)
+			sprints.POST("/:id/add-story", can(middleware.PermSprintManage, middleware.ProjectOf("sprints", "id")), handlers.AddStoryToSprint)
+			sprints.POST("/:id/remove-story", can(middleware.PermSprintManage, middleware.ProjectOf("sprints", "id")), handlers.RemoveStoryFromSprint)
+			sprints.POST("/:id/start", can(middleware.PermSprintManage, middleware.ProjectOf("sprints", "id")), handlers.StartSprint)
+			sprints.POST("/:id/complete", can(middleware.PermSprintManage, middleware.ProjectOf("sprints", "id")), handlers.CompleteSprint)
+			sprints.GET("/:id/dependencies", can(middleware.PermProjectView, middleware.ProjectOf("sprints", "id")), handlers.GetSprintDependencyGraph)
+			sprints.GET("/:id/report", can(middleware.PermProjectView, middleware.ProjectOf("sprints", "id")), handlers.GetSprintReport)
+		}
+
+		// Epics
+		epics := protected.Group("/epics")
+		{
+			epics.GET("/project/:projectId", can(middleware.PermProjectView, middleware.ProjectParam("projectId")), handlers.GetProjectEpics)
+			epics.GET("/:id", can(middleware.PermProjectView, middleware.ProjectOf("epics", "id")), handlers.GetEpic)
+			epics.POST("/", can(middleware.PermBacklogManage, middleware.ProjectField("projectId")), handlers.CreateEpic)
+			epics.PUT("/:id", can(middleware.PermBacklogManage, middleware.ProjectOf("epics", "id")), handlers.UpdateEpic)
+			epics.DELETE("/:id", can(middleware.PermBacklogManage, middleware.ProjectOf("epics", "id")), handlers.DeleteEpic)
+		}
+
+		worklogs := protected.Group("/worklogs")
+		{
+			worklogs.GET("/timer", anyUser, handlers.GetRunningTimer)
+			worklogs.PUT("/:id", can(middleware.PermTaskWrite, middleware.ProjectOf("worklogs", "id")), handlers.UpdateWorklog)
+			worklogs.DELETE("/:id", can(middleware.PermTaskWrite, middleware.ProjectOf("worklogs", "id")), handlers.DeleteWorklog)
+		}
+
+		boards := protected.Group("/boards")
+		{
+			boards.GET("/projects/:projectId", can(middleware.PermProjectView, middleware.ProjectParam("projectId")), handlers.GetProjectBoard)
+			boards.GET("/sprints/:sprintId", can(middleware.PermProjectView, middleware.ProjectOf("sprints", "sprintId")), handlers.GetSprintBoard)
+			boards.GET("/projects/:projectId/columns", can(middleware.PermProjectView, middleware.ProjectParam("projectId")), handlers.GetBoardColumns)
+			boards.PUT("/projects/:projectId/columns", can(middleware.PermProjectManage, middleware.ProjectParam("projectId")), handlers.UpdateBoardColumns)
+		}
+
+		comments := protected.Group("/comments")
+		{
+			comments.PUT("/:id", can(middleware.PermContribute, middleware.ProjectOf("comments", "id")), handlers.UpdateComment)
+			comments.DELETE("/:id", can(middleware.PermProjectView, middleware.ProjectOf("comments", "id")), handlers.DeleteComment)
 		}
 
 		// User Stories
 		userStories := protected.Group("/user-stories")
 		{
-			userStories.GET("/", handlers.GetAllUserStories)
-			userStories.GET("/:id", handlers.GetUserStory)
-			userStories.POST("/", handlers.CreateUserStory)
-			userStories.PUT("/:id", handlers.UpdateUserStory)
-			userStories.DELETE("/:id", handlers.DeleteUserStory)
+			userStories.GET("/", anyUser, handlers.GetAllUserStories)
+			userStories.GET("/:id", can(middleware.PermProjectView, middleware.ProjectOf("user_stories", "id")), handlers.GetUserStory)
+			userStories.POST("/", can(middleware.PermBacklogManage, middleware.ProjectField("projectId")), handlers.CreateUserStory)
+			userStories.PUT("/:id", can(middleware.PermBacklogManage, middleware.ProjectOf("user_stories", "id")), handlers.UpdateUserStory)
+			userStories.DELETE("/:id", can(middleware.PermBacklogManage, middleware.ProjectOf("user_stories", "id")), handlers.DeleteUserStory)
+			userStories.POST("/:id/move", can(middleware.PermBacklogManage, middleware.ProjectOf("user_stories", "id")), handlers.MoveUserStory)
+			userStories.GET("/:id/dependencies", can(middleware.PermProjectView, middleware.ProjectOf("user_stories", "id")), handlers.GetUserStoryDependencies)
+			userStories.POST("/:id/blocked-by", can(middleware.PermBacklogManage, middleware.ProjectOf("user_stories", "id")), handlers.AddUserStoryDependency)
+			userStories.DELETE("/:id/blocked-by/:blockerId", can(middleware.PermBacklogManage, middleware.ProjectOf("user_stories", "id")), handlers.RemoveUserStoryDependency)
+			userStories.GET("/:id/comments", can(middleware.PermProjectView, middleware.ProjectOf("user_stories", "id")), handlers.GetUserStoryComments)
+			userStories.POST("/:id/comments", can(middleware.PermContribute, middleware.ProjectOf("user_stories", "id")), handlers.CreateUserStoryComment)
+			userStories.GET("/:id/history", can(middleware.PermProjectView, middleware.ProjectOf("user_stories", "id")), handlers.GetUserStoryHistory)
 		}
 
 		// Tasks
 		tasks := protected.Group("/tasks")
 		{
-			tasks.GET("/", handlers.GetAllTasks)
-			tasks.GET("/:id", handlers.GetTask)
-			tasks.POST("/", handlers.CreateTask)
-			tasks.PUT("/:id", handlers.UpdateTask)
-			tasks.DELETE("/:id", handlers.DeleteTask)
+			tasks.GET("/", anyUser, handlers.GetAllTasks)
+			tasks.GET("/:id", can(middleware.PermProjectView, middleware.ProjectOf("tasks", "id")), handlers.GetTask)
+			tasks.POST("/", can(middleware.PermTaskWrite, middleware.ProjectField("projectId")), handlers.CreateTask)
+			tasks.PUT("/:id", can(middleware.PermTaskWrite, middleware.ProjectOf("tasks", "id")), handlers.UpdateTask)
+			tasks.DELETE("/:id", can(middleware.PermTaskDelete, middleware.ProjectOf("tasks", "id")), handlers.DeleteTask)
+			tasks.GET("/:id/dependencies", can(middleware.PermProjectView, middleware.ProjectOf("tasks", "id")), handlers.GetTaskDependencies)
+			tasks.POST("/:id/blocked-by", can(middleware.PermTaskWrite, middleware.ProjectOf("tasks", "id")), handlers.AddTaskDependency)
+			tasks.DELETE("/:id/blocked-by/:blockerId", can(middleware.PermTaskWrite, middleware.ProjectOf("tasks", "id")), handlers.RemoveTaskDependency)
+			tasks.GET("/:id/worklogs", can(middleware.PermProjectView, middleware.ProjectOf("tasks", "id")), handlers.GetTaskWorklogs)
+			tasks.POST("/:id/worklogs", can(middleware.PermTaskWrite, middleware.ProjectOf("tasks", "id")), handlers.LogWork)
+			tasks.POST("/:id/timer/start", can(middleware.PermTaskWrite, middleware.ProjectOf("tasks", "id")), handlers.StartTimer)
+			tasks.POST("/:id/timer/stop", can(middleware.PermTaskWrite, middleware.ProjectOf("tasks", "id")), handlers.StopTimer)
+			tasks.POST("/:id/move", can(middleware.PermTaskWrite, middleware.ProjectOf("tasks", "id")), handlers.MoveTask)
+			tasks.GET("/:id/comments", can(middleware.PermProjectView, middleware.ProjectOf("tasks", "id")), handlers.GetTaskComments)
+			tasks.POST("/:id/comments", can(middleware.PermContribute, middleware.ProjectOf("tasks", "id")), handlers.CreateTaskComment)
+			tasks.GET("/:id/history", can(middleware.PermProjectView, middleware.ProjectOf("tasks", "id")), handlers.GetTaskHistory)
 
 			// Task Actions
-			tasks.POST("/:id/evaluate", handlers.EvaluateTask)
+			tasks.POST("/:id/evaluate", can(middleware.PermEvaluate, middleware.ProjectOf("tasks", "id")), handlers.EvaluateTask)
 		}
 
 		// Chat
 		chat := protected.Group("/chat")
 		{
 			// Project Chat
-			chat.GET("/:projectId/messages", handlers.GetProjectMessages)
-			chat.POST("/:projectId/messages", handlers.SendProjectMessage)
+			chat.GET("/:projectId/messages", can(middleware.PermProjectView, middleware.ProjectParam("projectId")), handlers.GetProjectMessages)
+			chat.POST("/:projectId/messages", can(middleware.PermContribute, middleware.ProjectParam("projectId")), handlers.SendProjectMessage)
 
-			// Direct Chat
-			chat.GET("/user/:userId/all", handlers.GetDirectChats)
-			chat.POST("/direct", handlers.CreateOrGetDirectChat)
-			chat.GET("/conversation/:chatId/messages", handlers.GetConversationMessages)
-			chat.POST("/conversation/:chatId/messages", handlers.SendConversationMessage)
+			// Direct Chat (participation is checked by the handlers)
+			chat.GET("/user/:userId/all", middleware.RequireSelf("userId"), handlers.GetDirectChats)
+			chat.POST("/direct", anyUser, handlers.CreateOrGetDirectChat)
+			chat.GET("/conversation/:chatId/messages", anyUser, handlers.GetConversationMessages)
+			chat.POST("/conversation/:chatId/messages", anyUser, handlers.SendConversationMessage)
+
+			// Messages (the handlers only allow the author)
+			chat.PUT("/messages/:messageId", anyUser, handlers.UpdateMessage)
+			chat.DELETE("/messages/:messageId", anyUser, handlers.DeleteMessage)
+
+			// Realtime
+			chat.GET("/ws", anyUser, handlers.ChatSocket)
 		}
 
 		// Notifications
 		notifications := protected.Group("/notifications")
 		{
-			notifications.GET("/", handlers.GetNotifications)
-			notifications.PUT("/:id/read", handlers.MarkNotificationRead)
+			notifications.GET("/", anyUser, handlers.GetNotifications)
+			notifications.GET("/stream", anyUser, handlers.StreamNotifications)
+			notifications.PUT("/:id/read", anyUser, handlers.MarkNotificationRead)
 		}
 
 		// Rubrics
 		rubrics := protected.Group("/rubrics")
 		{
-			rubrics.GET("/", handlers.GetAllRubrics)
-			rubrics.GET("/:id", handlers.GetRubric)
-			rubrics.POST("/", handlers.CreateRubric)
-			rubrics.DELETE("/:id", handlers.DeleteRubric)
+			rubrics.GET("/", anyUser, handlers.GetAllRubrics)
+			rubrics.GET("/:id", anyUser, handlers.GetRubric)
+			rubrics.POST("/", can(middleware.PermEvaluate, middleware.ProjectField("projectId")), handlers.CreateRubric)
+			rubrics.DELETE("/:id", can(middleware.PermEvaluate, middleware.ProjectOf("rubrics", "id")), handlers.DeleteRubric)
 		}
 
 		// Evaluations (Module)
 		evaluations := protected.Group("/evaluations")
 		{
-			evaluations.GET("/:id", handlers.GetEvaluation)
-			evaluations.POST("/", handlers.CreateEvaluation)
-			evaluations.PUT("/:id", handlers.UpdateEvaluation)
-			
-			evaluations.GET("/task/:taskId", handlers.GetTaskEvaluations)
-			evaluations.GET("/sprint/:sprintId", handlers.GetSprintEvaluations)
-			evaluations.GET("/project/:projectId/general", handlers.GetProjectEvaluations)
-			evaluations.GET("/student/:studentId", handlers.GetStudentEvaluations)
+			evaluations.GET("/:id", can(middleware.PermProjectView, middleware.ProjectOf("evaluations", "id")), handlers.GetEvaluation)
+			evaluations.POST("/", can(middleware.PermEvaluate, middleware.ProjectField("projectId")), handlers.CreateEvaluation)
+			evaluations.PUT("/:id", can(middleware.PermEvaluate, middleware.ProjectOf("evaluations", "id")), handlers.UpdateEvaluation)
+			evaluations.POST("/:id/submit", can(middleware.PermEvaluate, middleware.ProjectOf("evaluations", "id")), handlers.SubmitEvaluation)
+			evaluations.POST("/:id/publish", can(middleware.PermEvaluate, middleware.ProjectOf("evaluations", "id")), handlers.PublishEvaluation)
+			evaluations.POST("/:id/reopen", can(middleware.PermEvaluate, middleware.ProjectOf("evaluations", "id")), handlers.ReopenEvaluation)
+
+			evaluations.GET("/task/:taskId", can(middleware.PermProjectView, middleware.ProjectOf("tasks", "taskId")), handlers.GetTaskEvaluations)
+			evaluations.GET("/sprint/:sprintId", can(middleware.PermProjectView, middleware.ProjectOf("sprints", "sprintId")), handlers.GetSprintEvaluations)
+			evaluations.GET("/project/:projectId/general", can(middleware.PermProjectView, middleware.ProjectParam("projectId")), handlers.GetProjectEvaluations)
+			evaluations.GET("/student/:studentId", middleware.RequireSelf("studentId", middleware.PermEvaluate), handlers.GetStudentEvaluations)
+		}
+
+		// Peer Reviews
+		peerReviews := protected.Group("/peer-reviews")
+		{
+			peerReviews.POST("/", can(middleware.PermEvaluate, middleware.ProjectField("projectId")), handlers.CreatePeerReviewRound)
+			peerReviews.GET("/project/:projectId", can(middleware.PermProjectView, middleware.ProjectParam("projectId")), handlers.GetProjectPeerReviewRounds)
+			peerReviews.GET("/:id", can(middleware.PermProjectView, middleware.ProjectOf("peer_review_rounds", "id")), handlers.GetPeerReviewRound)
+			peerReviews.POST("/:id/reviews", can(middleware.PermContribute, middleware.ProjectOf("peer_review_rounds", "id")), handlers.SubmitPeerReview)
+			peerReviews.POST("/:id/close", can(middleware.PermEvaluate, middleware.ProjectOf("peer_review_rounds", "id")), handlers.ClosePeerReviewRound)
+			peerReviews.GET("/:id/progress", can(middleware.PermEvaluate, middleware.ProjectOf("peer_review_rounds", "id")), handlers.GetPeerReviewProgress)
+			peerReviews.GET("/:id/results", can(middleware.PermProjectView, middleware.ProjectOf("peer_review_rounds", "id")), handlers.GetPeerReviewResults)
 		}
 
 		// Retrospectives
 		retrospectives := protected.Group("/retrospectives")
 		{
-			retrospectives.GET("/:sprintId", handlers.GetSprintRetrospective)
-			retrospectives.POST("/", handlers.CreateRetrospectiveItem)
-			retrospectives.DELETE("/:id", handlers.DeleteRetrospectiveItem)
+			retrospectives.GET("/:sprintId", can(middleware.PermProjectView, middleware.ProjectOf("sprints", "sprintId")), handlers.GetSprintRetrospective)
+			retrospectives.POST("/", can(middleware.PermContribute, middleware.ProjectOfField("sprints", "sprintId")), handlers.CreateRetrospectiveItem)
+			retrospectives.DELETE("/:id", can(middleware.PermContribute, middleware.ProjectOf("retrospective_items", "id")), handlers.DeleteRetrospectiveItem)
+		}
+
+		retroSessions := protected.Group("/retro-sessions")
+		{
+			retroSessions.POST("/", can(middleware.PermSprintManage, middleware.ProjectOfField("sprints", "sprintId")), handlers.CreateRetroSession)
+			retroSessions.GET("/sprint/:sprintId", can(middleware.PermProjectView, middleware.ProjectOf("sprints", "sprintId")), handlers.GetSprintRetroSession)
+			retroSessions.GET("/:id", can(middleware.PermProjectView, middleware.ProjectOf("retro_sessions", "id")), handlers.GetRetroSession)
+			retroSessions.POST("/:id/advance", can(middleware.PermSprintManage, middleware.ProjectOf("retro_sessions", "id")), handlers.AdvanceRetroSession)
+			retroSessions.POST("/:id/items", can(middleware.PermContribute, middleware.ProjectOf("retro_sessions", "id")), handlers.CreateSessionItem)
+			retroSessions.POST("/:id/groups", can(middleware.PermContribute, middleware.ProjectOf("retro_sessions", "id")), handlers.CreateRetroGroup)
+			retroSessions.PUT("/:id/items/:itemId/group", can(middleware.PermContribute, middleware.ProjectOf("retro_sessions", "id")), handlers.SetRetroItemGroup)
+			retroSessions.POST("/:id/votes", can(middleware.PermContribute, middleware.ProjectOf("retro_sessions", "id")), handlers.CastRetroVote)
+			retroSessions.DELETE("/:id/votes/:targetId", can(middleware.PermContribute, middleware.ProjectOf("retro_sessions", "id")), handlers.RemoveRetroVote)
+			retroSessions.POST("/:id/items/:itemId/convert", can(middleware.PermTaskWrite, middleware.ProjectOf("retro_sessions", "id")), handlers.ConvertRetroAction)
 		}
 
 		// Documents
 		documents := protected.Group("/documents")
 		{
-			documents.GET("/:projectId", handlers.GetProjectDocuments)
-			documents.POST("/", handlers.UploadDocument)
-			documents.DELETE("/:id", handlers.DeleteDocument)
+			// Gin requires one wildcard name per segment, so the project list also uses :id
+			documents.GET("/:id", can(middleware.PermProjectView, middleware.ProjectParam("id")), handlers.GetProjectDocuments)
+			documents.POST("/", handlers.LimitUploadSize, can(middleware.PermContribute, middleware.ProjectField("projectId")), handlers.UploadDocument)
+			documents.GET("/:id/download", can(middleware.PermProjectView, middleware.ProjectOf("documents", "id")), handlers.DownloadDocument)
+			documents.GET("/:id/versions", can(middleware.PermProjectView, middleware.ProjectOf("documents", "id")), handlers.GetDocumentVersions)
+			documents.DELETE("/:id", can(middleware.PermDocumentDelete, middleware.ProjectOf("documents", "id")), handlers.DeleteDocument)
 		}
 
 		// Metrics
 		metrics := protected.Group("/metrics")
 		{
-			metrics.GET("/sprints/:sprintId/burndown", handlers.GetSprintBurndown)
-			metrics.GET("/projects/:projectId/velocity", handlers.GetProjectVelocity)
-			metrics.GET("/projects/:projectId/contribution", handlers.GetProjectContribution)
-			metrics.GET("/export/projects/:projectId", handlers.ExportProjectCSV)
+			metrics.GET("/sprints/:sprintId/burndown", can(middleware.PermProjectView, middleware.ProjectOf("sprints", "sprintId")), handlers.GetSprintBurndown)
+			metrics.GET("/epics/:epicId/burnup", can(middleware.PermProjectView, middleware.ProjectOf("epics", "epicId")), handlers.GetEpicBurnup)
+			metrics.GET("/projects/:projectId/effort", can(middleware.PermProjectView, middleware.ProjectParam("projectId")), handlers.GetProjectEffort)
+			metrics.GET("/sprints/:sprintId/effort", can(middleware.PermProjectView, middleware.ProjectOf("sprints", "sprintId")), handlers.GetSprintEffort)
+			metrics.GET("/projects/:projectId/velocity", can(middleware.PermProjectView, middleware.ProjectParam("projectId")), handlers.GetProjectVelocity)
+			metrics.GET("/projects/:projectId/contribution", can(middleware.PermProjectView, middleware.ProjectParam("projectId")), handlers.GetProjectContribution)
+			metrics.GET("/export/projects/:projectId", can(middleware.PermProjectView, middleware.ProjectParam("projectId")), handlers.ExportProjectCSV)
+			metrics.GET("/projects/:projectId/gradebook", can(middleware.PermEvaluate, middleware.ProjectParam("projectId")), handlers.GetProjectGradebook)
+			metrics.GET("/students/:studentId/gradebook", middleware.RequireSelf("studentId", middleware.PermEvaluate), handlers.GetStudentGradebook)
+
+			// Flow metrics
+			metrics.GET("/projects/:projectId/cycle-time", can(middleware.PermProjectView, middleware.ProjectParam("projectId")), handlers.GetCycleTime)
+			metrics.GET("/projects/:projectId/lead-time", can(middleware.PermProjectView, middleware.ProjectParam("projectId")), handlers.GetLeadTime)
+			metrics.GET("/projects/:projectId/throughput", can(middleware.PermProjectView, middleware.ProjectParam("projectId")), handlers.GetThroughput)
+			metrics.GET("/projects/:projectId/cumulative-flow", can(middleware.PermProjectView, middleware.ProjectParam("projectId")), handlers.GetCumulativeFlow)
+			metrics.GET("/sprints/:sprintId/cycle-time", can(middleware.PermProjectView, middleware.ProjectOf("sprints", "sprintId")), handlers.GetCycleTime)
+			metrics.GET("/sprints/:sprintId/lead-time", can(middleware.PermProjectView, middleware.ProjectOf("sprints", "sprintId")), handlers.GetLeadTime)
+			metrics.GET("/sprints/:sprintId/throughput", can(middleware.PermProjectView, middleware.ProjectOf("sprints", "sprintId")), handlers.GetThroughput)
+			metrics.GET("/sprints/:sprintId/cumulative-flow", can(middleware.PermProjectView, middleware.ProjectOf("sprints", "sprintId")), handlers.GetCumulativeFlow)
 		}
 	}
 }
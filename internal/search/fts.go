@@ -0,0 +1,188 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// FTS searches an SQLite FTS5 index, available when the binary is built with
+// -tags sqlite_fts5. Triggers on the source tables keep search_documents
+// current, and search_index indexes it as external content.
+type FTS struct{}
+
+func (FTS) Name() string { return "fts5" }
+
+// fts5Available reports whether the SQLite library was compiled with FTS5.
+func fts5Available(db *gorm.DB) bool {
+	var used int
+	db.Raw("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&used)
+	return used == 1
+}
+
+// setupFTS creates the index, fills it from the current data and installs the
+// triggers.
+func setupFTS(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		statements := []string{
+			`CREATE TABLE IF NOT EXISTS search_documents (
+				id INTEGER PRIMARY KEY,
+				entity_type TEXT NOT NULL,
+				entity_id TEXT NOT NULL,
+				project_id TEXT,
+				chat_id TEXT,
+				title TEXT NOT NULL DEFAULT '',
+				body TEXT NOT NULL DEFAULT '',
+				UNIQUE (entity_type, entity_id)
+			)`,
+			`CREATE VIRTUAL TABLE IF NOT EXISTS search_index USING fts5(
+				title, body, content='search_documents', content_rowid='id', tokenize='unicode61 remove_diacritics 2'
+			)`,
+			// Fill from scratch, so running it again also picks up rows written without the triggers
+			`DROP TRIGGER IF EXISTS search_index_ai`,
+			`DROP TRIGGER IF EXISTS search_index_ad`,
+			`DELETE FROM search_documents`,
+		}
+		for _, src := range sources {
+			statements = append(statements, fmt.Sprintf(
+				"INSERT INTO search_documents (entity_type, entity_id, project_id, chat_id, title, body) SELECT '%s', %s.id, %s, %s, %s, %s FROM %s",
+				src.Type, src.Table, expr(src.Project, src.Table), expr(src.Chat, src.Table), expr(src.Title, src.Table), ftsBody(src, src.Table), src.Table))
+		}
+		statements = append(statements,
+			`INSERT INTO search_index (search_index) VALUES ('rebuild')`,
+			`CREATE TRIGGER search_index_ai AFTER INSERT ON search_documents BEGIN
+				INSERT INTO search_index (rowid, title, body) VALUES (new.id, new.title, new.body);
+			END`,
+			`CREATE TRIGGER search_index_ad AFTER DELETE ON search_documents BEGIN
+				INSERT INTO search_index (search_index, rowid, title, body) VALUES ('delete', old.id, old.title, old.body);
+			END`,
+		)
+		for _, src := range sources {
+			statements = append(statements, sourceTriggers(src)...)
+		}
+
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// dropFTS removes the triggers and tables setupFTS created.
+func dropFTS(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		statements := []string{}
+		for _, src := range sources {
+			name := "search_sync_" + src.Table
+			statements = append(statements,
+				"DROP TRIGGER IF EXISTS "+name+"_ai",
+				"DROP TRIGGER IF EXISTS "+name+"_au",
+				"DROP TRIGGER IF EXISTS "+name+"_ad",
+			)
+		}
+		statements = append(statements,
+			`DROP TRIGGER IF EXISTS search_index_ai`,
+			`DROP TRIGGER IF EXISTS search_index_ad`,
+			`DROP TABLE IF EXISTS search_index`,
+			`DROP TABLE IF EXISTS search_documents`,
+		)
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func ftsBody(src source, row string) string {
+	parts := make([]string, len(src.Body))
+	for i, b := range src.Body {
+		parts[i] = expr(b, row)
+	}
+	return strings.Join(parts, " || ' ' || ")
+}
+
+// sourceTriggers mirrors inserts, updates and deletes of a source table into search_documents.
+func sourceTriggers(src source) []string {
+	insert := fmt.Sprintf(
+		"INSERT INTO search_documents (entity_type, entity_id, project_id, chat_id, title, body) VALUES ('%s', NEW.id, %s, %s, %s, %s);",
+		src.Type, expr(src.Project, "NEW"), expr(src.Chat, "NEW"), expr(src.Title, "NEW"), ftsBody(src, "NEW"))
+	remove := fmt.Sprintf("DELETE FROM search_documents WHERE entity_type = '%s' AND entity_id = OLD.id;", src.Type)
+	name := "search_sync_" + src.Table
+	return []string{
+		"DROP TRIGGER IF EXISTS " + name + "_ai",
+		"DROP TRIGGER IF EXISTS " + name + "_au",
+		"DROP TRIGGER IF EXISTS " + name + "_ad",
+		fmt.Sprintf("CREATE TRIGGER %s_ai AFTER INSERT ON %s BEGIN %s END", name, src.Table, insert),
+		fmt.Sprintf("CREATE TRIGGER %s_au AFTER UPDATE OF %s ON %s BEGIN %s %s END", name, strings.Join(src.Watch, ", "), src.Table, remove, insert),
+		fmt.Sprintf("CREATE TRIGGER %s_ad AFTER DELETE ON %s BEGIN %s END", name, src.Table, remove),
+	}
+}
+
+// matchQuery turns the search terms into an FTS5 query matching every term as a prefix.
+func matchQuery(terms []string) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		parts[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"*`
+	}
+	return strings.Join(parts, " ")
+}
+
+type ftsRow struct {
+	EntityType string
+	EntityID   string
+	ProjectID  *string
+	ChatID     *string
+	Title      string
+	Marked     string
+	Snippet    string
+}
+
+func (FTS) Search(db *gorm.DB, q Query) ([]Hit, error) {
+	terms := Terms(q.Text)
+	if len(terms) == 0 {
+		return []Hit{}, nil
+	}
+
+	query := db.Table("search_index").
+		Select(fmt.Sprintf(`d.entity_type, d.entity_id, d.project_id, d.chat_id, d.title,
+			highlight(search_index, 0, '%s', '%s') AS marked,
+			snippet(search_index, 1, '%s', '%s', '…', %d) AS snippet`, markStart, markEnd, markStart, markEnd, snippetWords)).
+		Joins("JOIN search_documents d ON d.id = search_index.rowid").
+		Where("search_index MATCH ?", matchQuery(terms))
+	if len(q.Types) > 0 {
+		query = query.Where("d.entity_type IN ?", q.Types)
+	}
+	if !q.Scope.All {
+		query = query.Where("(d.project_id IN ? OR d.chat_id IN ?)", q.Scope.ProjectIDs, q.Scope.ChatIDs)
+	} else if len(q.Scope.ProjectIDs) > 0 {
+		query = query.Where("d.project_id IN ?", q.Scope.ProjectIDs)
+	}
+
+	// Title matches weigh five times body matches
+	var rows []ftsRow
+	if err := query.Order("bm25(search_index, 5.0, 1.0)").Limit(q.Limit).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	hits := make([]Hit, 0, len(rows))
+	for _, row := range rows {
+		snippet := row.Snippet
+		if !strings.Contains(snippet, markStart) {
+			snippet = row.Marked
+		}
+		hits = append(hits, Hit{
+			Type:      row.EntityType,
+			ID:        row.EntityID,
+			ProjectID: row.ProjectID,
+			ChatID:    row.ChatID,
+			Title:     row.Title,
+			Snippet:   finishSnippet(snippet),
+		})
+	}
+	return dropOldVersions(db, hits), nil
+}
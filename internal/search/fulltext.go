@@ -0,0 +1,44 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Fulltext searches MySQL through a FULLTEXT index on each source table, in
+// boolean mode. InnoDB leaves out words shorter than innodb_ft_min_token_size
+// (3 by default) and its stopwords, so those never match.
+type Fulltext struct{}
+
+func (Fulltext) Name() string { return "fulltext" }
+
+func fulltextIndex(src source) string {
+	return fmt.Sprintf("CREATE FULLTEXT INDEX %s ON %s (%s)", src.indexName(), src.Table, strings.Join(src.Text, ", "))
+}
+
+// fulltextQuery requires every term, matched as a prefix.
+func fulltextQuery(terms []string) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		parts[i] = "+" + term + "*"
+	}
+	return strings.Join(parts, " ")
+}
+
+func fulltextMatch(query *gorm.DB, src source, terms []string) *gorm.DB {
+	// The column list has to be the one the index was built on
+	columns := make([]string, len(src.Text))
+	for i, column := range src.Text {
+		columns[i] = src.Table + "." + column
+	}
+	match := "MATCH (" + strings.Join(columns, ", ") + ") AGAINST (? IN BOOLEAN MODE)"
+	against := fulltextQuery(terms)
+	return query.Where(match, against).Order(clause.OrderBy{Expression: clause.Expr{SQL: match + " DESC", Vars: []interface{}{against}}})
+}
+
+func (Fulltext) Search(db *gorm.DB, q Query) ([]Hit, error) {
+	return searchTables(db, q, fulltextMatch)
+}
@@ -0,0 +1,136 @@
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// snippetWords is how many words a snippet shows around the first match.
+const snippetWords = 12
+
+// Like searches the source tables directly with LIKE. It needs no index and
+// works on every database, at the cost of scanning the tables.
+type Like struct{}
+
+func (Like) Name() string { return "like" }
+
+type likeRow struct {
+	ID        string
+	ProjectID *string
+	ChatID    *string
+	Title     string
+	Body      string
+	Extra     string
+}
+
+// likePattern escapes LIKE wildcards in term, using ! as the escape character.
+func likePattern(term string) string {
+	replacer := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+	return "%" + replacer.Replace(term) + "%"
+}
+
+func (Like) Search(db *gorm.DB, q Query) ([]Hit, error) {
+	return searchTables(db, q, likeMatch)
+}
+
+// likeMatch requires every term to appear in one of the columns.
+func likeMatch(query *gorm.DB, src source, terms []string) *gorm.DB {
+	columns := src.columns()
+	for _, term := range terms {
+		conditions := make([]string, len(columns))
+		args := make([]interface{}, len(columns))
+		for i, column := range columns {
+			conditions[i] = "LOWER(" + column + ") LIKE ? ESCAPE '!'"
+			args[i] = likePattern(term)
+		}
+		query = query.Where("("+strings.Join(conditions, " OR ")+")", args...)
+	}
+	return query
+}
+
+// tableMatch narrows a query on the table of src to the rows matching every
+// term, best candidates first where the database can rank them.
+type tableMatch func(query *gorm.DB, src source, terms []string) *gorm.DB
+
+// searchTables queries each source table with match and ranks the hits the
+// same way for every engine that uses it.
+func searchTables(db *gorm.DB, q Query, match tableMatch) ([]Hit, error) {
+	terms := Terms(q.Text)
+	if len(terms) == 0 {
+		return []Hit{}, nil
+	}
+	pattern := termPattern(terms)
+
+	hits := []Hit{}
+	for _, src := range sources {
+		if !src.selected(q.Types) {
+			continue
+		}
+		columns := src.columns()
+		extra := "''"
+		if len(columns) > 2 {
+			extra = columns[2]
+		}
+		project := expr(src.Project, src.Table)
+		chat := expr(src.Chat, src.Table)
+
+		query := db.Table(src.Table).Select(fmt.Sprintf("%s.id AS id, %s AS project_id, %s AS chat_id, %s AS title, %s AS body, %s AS extra",
+			src.Table, project, chat, columns[0], columns[1], extra))
+		query = match(query, src, terms)
+		if !q.Scope.All {
+			query = query.Where("("+project+" IN ? OR "+chat+" IN ?)", q.Scope.ProjectIDs, q.Scope.ChatIDs)
+		} else if len(q.Scope.ProjectIDs) > 0 {
+			query = query.Where(project+" IN ?", q.Scope.ProjectIDs)
+		}
+
+		var rows []likeRow
+		if err := query.Limit(q.Limit * 4).Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			text := strings.TrimSpace(row.Body + " " + row.Extra)
+			hit := Hit{Type: src.Type, ID: row.ID, ProjectID: row.ProjectID, ChatID: row.ChatID, Title: row.Title}
+			// Title matches weigh more, as in the FTS5 ranking
+			hit.score = 5*float64(len(pattern.FindAllStringIndex(row.Title, -1))) + float64(len(pattern.FindAllStringIndex(text, -1)))
+			if pattern.MatchString(text) {
+				hit.Snippet = finishSnippet(likeSnippet(text, pattern))
+			} else {
+				hit.Snippet = finishSnippet(pattern.ReplaceAllString(row.Title, markStart+"$0"+markEnd))
+			}
+			hits = append(hits, hit)
+		}
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].score > hits[j].score })
+	hits = dropOldVersions(db, hits)
+	if len(hits) > q.Limit {
+		hits = hits[:q.Limit]
+	}
+	return hits, nil
+}
+
+// likeSnippet cuts a window of words around the first match and marks every match in it.
+func likeSnippet(text string, pattern *regexp.Regexp) string {
+	words := strings.Fields(text)
+	first := 0
+	for i, word := range words {
+		if pattern.MatchString(word) {
+			first = i
+			break
+		}
+	}
+	start := max(0, first-snippetWords/3)
+	end := min(len(words), start+snippetWords)
+	window := strings.Join(words[start:end], " ")
+	if start > 0 {
+		window = "…" + window
+	}
+	if end < len(words) {
+		window += "…"
+	}
+	return pattern.ReplaceAllString(window, markStart+"$0"+markEnd)
+}
@@ -0,0 +1,267 @@
+package search
+
+import (
+	"errors"
+	"html"
+	"log"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"Wrk_Api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Searchable entity types
+const (
+	TypeProject   = "PROJECT"
+	TypeTask      = models.EntityTask
+	TypeUserStory = models.EntityUserStory
+	TypeMessage   = "MESSAGE"
+	TypeRetroItem = "RETRO_ITEM"
+	TypeDocument  = "DOCUMENT"
+)
+
+// Hit is one search result. Snippet is HTML-escaped with the matches wrapped in <mark>.
+type Hit struct {
+	Type      string  `json:"type"`
+	ID        string  `json:"id"`
+	ProjectID *string `json:"projectId"`
+	ChatID    *string `json:"chatId,omitempty"`
+	Title     string  `json:"title"`
+	Snippet   string  `json:"snippet"`
+
+	score float64
+}
+
+// Scope limits results to what the caller may see. All skips the check (admins).
+type Scope struct {
+	All        bool
+	ProjectIDs []string
+	ChatIDs    []string // direct conversations the caller takes part in
+}
+
+type Query struct {
+	Text  string
+	Types []string // empty searches every type
+	Scope Scope
+	Limit int
+}
+
+// Engine runs searches against the database.
+type Engine interface {
+	Name() string
+	Search(db *gorm.DB, q Query) ([]Hit, error)
+}
+
+// Default is the engine used by the handlers. Setup picks FTS5 when SQLite supports it.
+var Default Engine = Like{}
+
+// source describes how a table is indexed. Expressions refer to the row as "$.".
+// The index is built by a migration, so changing sources takes a new
+// migration that runs Uninstall and Install again.
+type source struct {
+	Type    string
+	Table   string
+	Title   string
+	Body    []string
+	Project string
+	Chat    string
+	Watch   []string // columns whose changes reindex the row
+	Text    []string // columns the Postgres and MySQL indexes cover
+}
+
+var sources = []source{
+	{Type: TypeProject, Table: "projects", Title: "$.name", Body: []string{"COALESCE($.description, '')"},
+		Project: "$.id", Chat: "NULL", Watch: []string{"name", "description"}, Text: []string{"name", "description"}},
+	{Type: TypeTask, Table: "tasks", Title: "$.title", Body: []string{"COALESCE($.description, '')"},
+		Project: "$.project_id", Chat: "NULL", Watch: []string{"title", "description", "project_id"}, Text: []string{"title", "description"}},
+	{Type: TypeUserStory, Table: "user_stories", Title: "$.title", Body: []string{"$.description", "COALESCE($.acceptance, '')"},
+		Project: "$.project_id", Chat: "NULL", Watch: []string{"title", "description", "acceptance", "project_id"}, Text: []string{"title", "description", "acceptance"}},
+	{Type: TypeMessage, Table: "messages", Title: "''", Body: []string{"$.content"},
+		Project: "(SELECT chats.project_id FROM chats WHERE chats.id = $.chat_id)", Chat: "$.chat_id", Watch: []string{"content"}, Text: []string{"content"}},
+	{Type: TypeRetroItem, Table: "retrospective_items", Title: "''", Body: []string{"$.content"},
+		Project: "(SELECT sprints.project_id FROM sprints WHERE sprints.id = $.sprint_id)", Chat: "NULL", Watch: []string{"content"}, Text: []string{"content"}},
+	{Type: TypeDocument, Table: "documents", Title: "$.name", Body: []string{"''"},
+		Project: "$.project_id", Chat: "NULL", Watch: []string{"name"}, Text: []string{"name"}},
+}
+
+// expr binds a source expression to a row reference ("NEW", "OLD" or a table name).
+func expr(e, row string) string {
+	return strings.ReplaceAll(e, "$.", row+".")
+}
+
+// columns are the title and body expressions bound to the table.
+func (s source) columns() []string {
+	columns := []string{expr(s.Title, s.Table)}
+	for _, b := range s.Body {
+		columns = append(columns, expr(b, s.Table))
+	}
+	return columns
+}
+
+// indexName is the name of the Postgres or MySQL full-text index on the table.
+func (s source) indexName() string {
+	return "search_" + s.Table
+}
+
+func (s source) selected(types []string) bool {
+	if len(types) == 0 {
+		return true
+	}
+	for _, t := range types {
+		if t == s.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// Terms splits the search text into words.
+func Terms(text string) []string {
+	terms := []string{}
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsNumber(r) }) {
+		terms = append(terms, strings.ToLower(word))
+	}
+	return terms
+}
+
+// Match markers used while building snippets, replaced once the text is escaped.
+const (
+	markStart = "\x02"
+	markEnd   = "\x03"
+)
+
+// finishSnippet escapes a snippet carrying match markers and turns them into <mark> tags.
+func finishSnippet(marked string) string {
+	escaped := html.EscapeString(marked)
+	escaped = strings.ReplaceAll(escaped, markStart, "<mark>")
+	return strings.ReplaceAll(escaped, markEnd, "</mark>")
+}
+
+// dropOldVersions removes document hits superseded by a newer version.
+func dropOldVersions(db *gorm.DB, hits []Hit) []Hit {
+	ids := []string{}
+	for _, hit := range hits {
+		if hit.Type == TypeDocument {
+			ids = append(ids, hit.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return hits
+	}
+	var old []string
+	db.Table("documents AS d").Where("d.id IN ?", ids).
+		Where("EXISTS (SELECT 1 FROM documents n WHERE COALESCE(n.parent_id, n.id) = COALESCE(d.parent_id, d.id) AND n.version > d.version)").
+		Pluck("d.id", &old)
+	if len(old) == 0 {
+		return hits
+	}
+	superseded := map[string]bool{}
+	for _, id := range old {
+		superseded[id] = true
+	}
+	kept := hits[:0]
+	for _, hit := range hits {
+		if hit.Type != TypeDocument || !superseded[hit.ID] {
+			kept = append(kept, hit)
+		}
+	}
+	return kept
+}
+
+// termPattern matches any of the terms, ignoring case.
+func termPattern(terms []string) *regexp.Regexp {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = regexp.QuoteMeta(term)
+	}
+	return regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
+}
+
+// Install builds the full-text index for db's dialect. It runs once, as a
+// migration, and the index stays current on its own from then on. Postgres
+// gets GIN indexes over to_tsvector and MySQL FULLTEXT indexes on each
+// table. SQLite gets an FTS5 index, which needs a binary built with
+// -tags sqlite_fts5; without it nothing is built yet and Setup builds the
+// index once a binary with FTS5 starts.
+func Install(db *gorm.DB) error {
+	switch db.Dialector.Name() {
+	case "sqlite":
+		if !fts5Available(db) {
+			log.Println("Search: SQLite built without FTS5 (-tags sqlite_fts5), no index built")
+			return nil
+		}
+		return setupFTS(db)
+	case "postgres":
+		return createTableIndexes(db, tsIndex)
+	case "mysql":
+		return createTableIndexes(db, fulltextIndex)
+	}
+	return nil
+}
+
+// Uninstall drops what Install built.
+func Uninstall(db *gorm.DB) error {
+	if db.Dialector.Name() == "sqlite" {
+		return dropFTS(db)
+	}
+	for _, src := range sources {
+		if db.Migrator().HasIndex(src.Table, src.indexName()) {
+			if err := db.Migrator().DropIndex(src.Table, src.indexName()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// createTableIndexes creates the index statement returns on every source table.
+func createTableIndexes(db *gorm.DB, statement func(src source) string) error {
+	for _, src := range sources {
+		if db.Migrator().HasIndex(src.Table, src.indexName()) {
+			continue
+		}
+		if err := db.Exec(statement(src)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Setup chooses the engine for the index the migrations built, or LIKE when
+// there is none. On SQLite it builds the FTS5 index if the migration ran on
+// a binary without FTS5, and fails when the database has the index but this
+// binary can't use it: its triggers would break every write to the indexed
+// tables.
+func Setup(db *gorm.DB) error {
+	Default = Like{}
+	switch db.Dialector.Name() {
+	case "sqlite":
+		indexed, available := db.Migrator().HasTable("search_index"), fts5Available(db)
+		if indexed && !available {
+			return errors.New("the database has an FTS5 search index but this binary was built without FTS5 (-tags sqlite_fts5)")
+		}
+		if !available {
+			break
+		}
+		if !indexed {
+			log.Println("Search: building the FTS5 index")
+			if err := setupFTS(db); err != nil {
+				return err
+			}
+		}
+		Default = FTS{}
+	case "postgres":
+		if db.Migrator().HasIndex(sources[0].Table, sources[0].indexName()) {
+			Default = TSVector{}
+		}
+	case "mysql":
+		if db.Migrator().HasIndex(sources[0].Table, sources[0].indexName()) {
+			Default = Fulltext{}
+		}
+	}
+	log.Printf("Search: using %s", Default.Name())
+	return nil
+}
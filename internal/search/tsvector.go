@@ -0,0 +1,50 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TSVector searches Postgres through a GIN index over to_tsvector of each
+// source table. The 'simple' configuration matches words as written, without
+// stemming, like the FTS5 index does.
+type TSVector struct{}
+
+func (TSVector) Name() string { return "tsvector" }
+
+// tsDocument is the text search vector of a source row. The index is built on
+// this expression, so queries have to use it as it is to hit the index.
+func tsDocument(src source, prefix string) string {
+	parts := make([]string, len(src.Text))
+	for i, column := range src.Text {
+		parts[i] = fmt.Sprintf("COALESCE(%s%s, '')", prefix, column)
+	}
+	return "to_tsvector('simple', " + strings.Join(parts, " || ' ' || ") + ")"
+}
+
+func tsIndex(src source) string {
+	return fmt.Sprintf("CREATE INDEX %s ON %s USING GIN (%s)", src.indexName(), src.Table, tsDocument(src, ""))
+}
+
+// tsQuery matches every term as a prefix.
+func tsQuery(terms []string) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		parts[i] = term + ":*"
+	}
+	return strings.Join(parts, " & ")
+}
+
+func tsMatch(query *gorm.DB, src source, terms []string) *gorm.DB {
+	document := tsDocument(src, src.Table+".")
+	match := tsQuery(terms)
+	return query.Where(document+" @@ to_tsquery('simple', ?)", match).
+		Order(clause.OrderBy{Expression: clause.Expr{SQL: "ts_rank(" + document + ", to_tsquery('simple', ?)) DESC", Vars: []interface{}{match}}})
+}
+
+func (TSVector) Search(db *gorm.DB, q Query) ([]Hit, error) {
+	return searchTables(db, q, tsMatch)
+}
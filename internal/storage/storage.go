@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrNotFound is returned by Get when no object is stored under the key.
+var ErrNotFound = errors.New("storage: object not found")
+
+// Storage keeps the bytes of uploaded files; metadata lives in the database.
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Default is the backend used by the handlers. Setup replaces it from the environment.
+var Default Storage = NewLocal("uploads")
+
+// Limits applied to projects that don't override them. Zero means unlimited;
+// an empty AllowedTypes list accepts every type.
+var (
+	DefaultQuota        int64 = 500 << 20
+	DefaultMaxFileSize  int64 = 25 << 20
+	DefaultAllowedTypes []string
+)
+
+// TypeAllowed reports whether contentType matches one of the patterns
+// ("application/pdf", "image/*"). An empty list allows everything.
+func TypeAllowed(contentType string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == mediaType || pattern == "*/*" {
+			return true
+		}
+		if strings.HasSuffix(pattern, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(pattern, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTypes splits a comma-separated list of MIME patterns.
+func ParseTypes(list string) []string {
+	types := []string{}
+	for _, t := range strings.Split(list, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+func envBytes(name string, fallback int64) int64 {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return fallback
+	}
+	value, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || value < 0 {
+		log.Fatalf("%s must be a number of bytes", name)
+	}
+	return value
+}
+
+// Setup configures Default from STORAGE_DRIVER ("local" or "s3") and the
+// default limits from STORAGE_QUOTA, STORAGE_MAX_FILE_SIZE and STORAGE_ALLOWED_TYPES.
+func Setup() {
+	DefaultQuota = envBytes("STORAGE_QUOTA", DefaultQuota)
+	DefaultMaxFileSize = envBytes("STORAGE_MAX_FILE_SIZE", DefaultMaxFileSize)
+	DefaultAllowedTypes = ParseTypes(os.Getenv("STORAGE_ALLOWED_TYPES"))
+
+	switch strings.ToLower(os.Getenv("STORAGE_DRIVER")) {
+	case "", "local":
+		root := os.Getenv("STORAGE_PATH")
+		if root == "" {
+			root = "uploads"
+		}
+		Default = NewLocal(root)
+		log.Printf("Storing files in %s", root)
+	case "s3":
+		s3, err := NewS3(S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			UseSSL:    os.Getenv("S3_USE_SSL") != "false",
+		})
+		if err != nil {
+			log.Fatal("Failed to configure S3 storage:", err)
+		}
+		Default = s3
+		log.Printf("Storing files in bucket %s", os.Getenv("S3_BUCKET"))
+	default:
+		log.Fatal("Unknown STORAGE_DRIVER: ", os.Getenv("STORAGE_DRIVER"))
+	}
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	codeBlockPattern  = regexp.MustCompile("(?s)```.*?```")
+	inlineCodePattern = regexp.MustCompile("`[^`\n]*`")
+	// A mention starts a word: "@ana" but not the "@" inside "ana@uni.edu"
+	mentionPattern = regexp.MustCompile(`(^|[^\w@])@([\w][\w.\-]*[\w]|[\w])`)
+)
+
+// ParseMentions returns the lower-cased handles mentioned in a markdown text,
+// without duplicates and ignoring code spans and blocks.
+func ParseMentions(content string) []string {
+	content = codeBlockPattern.ReplaceAllString(content, " ")
+	content = inlineCodePattern.ReplaceAllString(content, " ")
+
+	handles := []string{}
+	seen := map[string]bool{}
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		handle := strings.ToLower(match[2])
+		if !seen[handle] {
+			seen[handle] = true
+			handles = append(handles, handle)
+		}
+	}
+	return handles
+}
+
+// MentionHandles lists the handles that refer to a user: the local part of
+// the email and the name without spaces, both lower-cased.
+func MentionHandles(name, email string) []string {
+	handles := []string{}
+	if local, _, found := strings.Cut(strings.ToLower(email), "@"); found && local != "" {
+		handles = append(handles, local)
+	}
+	if compact := strings.ToLower(strings.Join(strings.Fields(name), "")); compact != "" {
+		handles = append(handles, compact)
+	}
+	return handles
+}
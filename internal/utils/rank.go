@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"strings"
+)
+
+// Ranks are base-36 strings compared lexicographically, so an item can be
+// moved between two others by giving it a rank in between without touching
+// any other row.
+const rankDigits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+const rankBase = len(rankDigits)
+
+func rankDigit(rank string, i int, fallback int) int {
+	if i >= len(rank) {
+		return fallback
+	}
+	return strings.IndexByte(rankDigits, rank[i])
+}
+
+// RankBetween returns a rank strictly between prev and next. An empty prev
+// means "before everything" and an empty next "after everything".
+func RankBetween(prev, next string) string {
+	var sb strings.Builder
+	bounded := next != ""
+	for i := 0; ; i++ {
+		p := rankDigit(prev, i, 0)
+		n := rankBase
+		if bounded {
+			n = rankDigit(next, i, 0)
+		}
+
+		if p == n {
+			sb.WriteByte(rankDigits[p])
+			continue
+		}
+		if mid := (p + n) / 2; mid > p {
+			sb.WriteByte(rankDigits[mid])
+			return sb.String()
+		}
+		// Adjacent digits: keep prev's digit and look for room in the next position
+		sb.WriteByte(rankDigits[p])
+		bounded = false
+	}
+}
+
+// RankSequence returns n evenly spaced, increasing ranks of equal length,
+// used to rebalance a list whose ranks have grown long or collided.
+func RankSequence(n int) []string {
+	width, capacity := 1, rankBase
+	for capacity <= n {
+		width++
+		capacity *= rankBase
+	}
+	step := capacity / (n + 1)
+
+	ranks := make([]string, n)
+	for i := range ranks {
+		value := (i + 1) * step
+		digits := make([]byte, width)
+		for d := width - 1; d >= 0; d-- {
+			digits[d] = rankDigits[value%rankBase]
+			value /= rankBase
+		}
+		ranks[i] = string(digits)
+	}
+	return ranks
+}
@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// GenerateAccessToken signs a short-lived JWT bound to a server-side session.
+func GenerateAccessToken(userID, email, role, sessionID string) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"userId": userID,
+		"email":  email,
+		"role":   role,
+		"sid":    sessionID,
+		"exp":    time.Now().Add(AccessTokenTTL).Unix(),
+	})
+	return token.SignedString(GetJWTSecret())
+}
+
+// GenerateRefreshToken returns an opaque random token. Only its hash is persisted.
+func GenerateRefreshToken() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
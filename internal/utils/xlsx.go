@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// plainDecimal matches the values written as numbers. ParseFloat alone would
+// also take "NaN", "Inf", "1e400" or "0x1p-2", which Excel can't read.
+var plainDecimal = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+var xlsxStaticParts = []struct{ name, body string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`},
+}
+
+// WriteXLSX writes rows as a single-sheet Excel workbook. Plain decimal cells
+// are stored as numbers, everything else as inline strings.
+func WriteXLSX(w io.Writer, sheetName string, rows [][]string) error {
+	zw := zip.NewWriter(w)
+
+	for _, part := range xlsxStaticParts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, part.body); err != nil {
+			return err
+		}
+	}
+
+	f, err := zw.Create("xl/workbook.xml")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(f, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`, xmlEscape(sheetName))
+
+	f, err = zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for r, row := range rows {
+		fmt.Fprintf(&sb, `<row r="%d">`, r+1)
+		for col, value := range row {
+			ref := columnName(col) + strconv.Itoa(r+1)
+			if value == "" {
+				continue
+			}
+			if plainDecimal.MatchString(value) {
+				fmt.Fprintf(&sb, `<c r="%s"><v>%s</v></c>`, ref, value)
+			} else {
+				fmt.Fprintf(&sb, `<c r="%s" t="inlineStr"><is><t>%s</t></is></c>`, ref, xmlEscape(value))
+			}
+		}
+		sb.WriteString(`</row>`)
+	}
+	sb.WriteString(`</sheetData></worksheet>`)
+	if _, err := io.WriteString(f, sb.String()); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// CSVSafe returns rows with a quote in front of every cell a spreadsheet would
+// read as a formula, so exported text can't run as one. Numbers are kept as is.
+func CSVSafe(rows [][]string) [][]string {
+	safe := make([][]string, len(rows))
+	for r, row := range rows {
+		safe[r] = make([]string, len(row))
+		for col, value := range row {
+			if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) && !plainDecimal.MatchString(value) {
+				value = "'" + value
+			}
+			safe[r][col] = value
+		}
+	}
+	return safe
+}
+
+// columnName converts a zero-based column index to its spreadsheet letters (0 -> A, 26 -> AA).
+func columnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+func xmlEscape(value string) string {
+	var sb strings.Builder
+	xml.EscapeText(&sb, []byte(value))
+	return sb.String()
+}
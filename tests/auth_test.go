@@ -5,8 +5,11 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 
+	"Wrk_Api/internal/database"
+	"Wrk_Api/internal/models"
 	"Wrk_Api/internal/routes"
 
 	"github.com/gin-gonic/gin"
@@ -25,7 +28,7 @@ func TestAuthRegisterAndLogin(t *testing.T) {
 		"name":     "Test User",
 		"email":    "test@example.com",
 		"password": "password123",
-		"role":     "TEAM_DEVELOPER",
+		"role":     "ADMIN",
 	}
 	body, _ := json.Marshal(registerBody)
 
@@ -38,6 +41,11 @@ func TestAuthRegisterAndLogin(t *testing.T) {
 	var response map[string]interface{}
 	json.Unmarshal(w.Body.Bytes(), &response)
 	assert.Equal(t, "Usuario registrado exitosamente", response["message"])
+	// The role in the body is ignored
+	assert.Equal(t, "TEAM_DEVELOPER", response["user"].(map[string]interface{})["role"])
+	var registered models.User
+	database.DB.First(&registered, "email = ?", "test@example.com")
+	assert.Equal(t, "TEAM_DEVELOPER", registered.Role)
 
 	// Test Login
 	loginBody := map[string]string{
@@ -56,3 +64,140 @@ func TestAuthRegisterAndLogin(t *testing.T) {
 	assert.Contains(t, response, "token")
 	assert.Equal(t, "Inicio de sesión exitoso", response["message"])
 }
+
+func TestAuthSessions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	SetupTestDB()
+	r := gin.Default()
+	routes.SetupRoutes(r)
+
+	admin := models.User{ID: "admin-1", Name: "Admin", Email: "admin@sessions.com", Role: "ADMIN"}
+	database.DB.Create(&admin)
+	adminHeader := "Bearer " + generateTestToken(admin.ID, admin.Email, admin.Role)
+
+	registerBody, _ := json.Marshal(map[string]string{
+		"name":     "Session User",
+		"email":    "session@example.com",
+		"password": "password123",
+	})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/auth/register", bytes.NewBuffer(registerBody))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	login := func() (string, string) {
+		body, _ := json.Marshal(map[string]string{"email": "session@example.com", "password": "password123"})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/auth/login", bytes.NewBuffer(body))
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp["token"].(string), resp["refreshToken"].(string)
+	}
+
+	me := func(token string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/notifications/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	refresh := func(refreshToken string) (int, map[string]interface{}) {
+		body, _ := json.Marshal(map[string]string{"refreshToken": refreshToken})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/auth/refresh", bytes.NewBuffer(body))
+		r.ServeHTTP(w, req)
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	t.Run("RefreshRotatesToken", func(t *testing.T) {
+		_, refreshToken := login()
+
+		code, resp := refresh(refreshToken)
+		assert.Equal(t, http.StatusOK, code)
+		assert.NotEmpty(t, resp["token"])
+		assert.NotEqual(t, refreshToken, resp["refreshToken"])
+		assert.Equal(t, http.StatusOK, me(resp["token"].(string)))
+
+		// The old refresh token can't be reused after rotation
+		code, _ = refresh(refreshToken)
+		assert.Equal(t, http.StatusUnauthorized, code)
+	})
+
+	t.Run("ConcurrentRefreshesRotateOnce", func(t *testing.T) {
+		_, refreshToken := login()
+
+		codes := make(chan int, 8)
+		var wg sync.WaitGroup
+		for i := 0; i < cap(codes); i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				code, _ := refresh(refreshToken)
+				codes <- code
+			}()
+		}
+		wg.Wait()
+		close(codes)
+
+		rotated := 0
+		for code := range codes {
+			if code == http.StatusOK {
+				rotated++
+			}
+		}
+		assert.LessOrEqual(t, rotated, 1)
+	})
+
+	t.Run("Logout", func(t *testing.T) {
+		token, refreshToken := login()
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/auth/logout", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		assert.Equal(t, http.StatusUnauthorized, me(token))
+		code, _ := refresh(refreshToken)
+		assert.Equal(t, http.StatusUnauthorized, code)
+	})
+
+	t.Run("LogoutAll", func(t *testing.T) {
+		tokenA, _ := login()
+		tokenB, _ := login()
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/auth/logout-all", nil)
+		req.Header.Set("Authorization", "Bearer "+tokenA)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		assert.Equal(t, http.StatusUnauthorized, me(tokenA))
+		assert.Equal(t, http.StatusUnauthorized, me(tokenB))
+	})
+
+	t.Run("DeactivationRevokesSessions", func(t *testing.T) {
+		token, refreshToken := login()
+		assert.Equal(t, http.StatusOK, me(token))
+
+		var user models.User
+		database.DB.First(&user, "email = ?", "session@example.com")
+
+		body, _ := json.Marshal(map[string]interface{}{"active": false})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/api/users/"+user.ID, bytes.NewBuffer(body))
+		req.Header.Set("Authorization", adminHeader)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		assert.Equal(t, http.StatusUnauthorized, me(token))
+		code, _ := refresh(refreshToken)
+		assert.Equal(t, http.StatusUnauthorized, code)
+	})
+}
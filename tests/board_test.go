@@ -0,0 +1,196 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"Wrk_Api/internal/database"
+	"Wrk_Api/internal/models"
+	"Wrk_Api/internal/routes"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKanbanBoard(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	SetupTestDB()
+	r := gin.Default()
+	routes.SetupRoutes(r)
+
+	owner := models.User{ID: "kb-owner", Name: "Owner", Email: "owner@kb.com", Role: "SCRUM_MASTER"}
+	dev := models.User{ID: "kb-dev", Name: "Dev", Email: "dev@kb.com", Role: "TEAM_DEVELOPER"}
+	database.DB.Create(&owner)
+	database.DB.Create(&dev)
+	project := models.Project{ID: "kb-p", Name: "Board Project", OwnerID: owner.ID}
+	database.DB.Create(&project)
+	database.DB.Create(&models.ProjectMember{ID: "kb-m", ProjectID: project.ID, UserID: dev.ID, Role: "TEAM_DEVELOPER"})
+	sprint := models.Sprint{ID: "kb-s", ProjectID: project.ID, Name: "Sprint", Status: "ACTIVE", StartDate: time.Now(), EndDate: time.Now().AddDate(0, 0, 14)}
+	database.DB.Create(&sprint)
+
+	base := time.Now().Add(-time.Hour)
+	for i, id := range []string{"a", "b", "c", "d"} {
+		database.DB.Create(&models.Task{ID: "kb-" + id, ProjectID: project.ID, SprintID: &sprint.ID, Title: id, Status: "TODO", CreatedAt: base.Add(time.Duration(i) * time.Minute)})
+	}
+	database.DB.Create(&models.Task{ID: "kb-done", ProjectID: project.ID, SprintID: &sprint.ID, Title: "done", Status: "COMPLETED"})
+	database.DB.Create(&models.Task{ID: "kb-other", ProjectID: project.ID, Title: "unscheduled", Status: "TODO"})
+
+	send := func(user models.User, method, url string, body interface{}) *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, url, bytes.NewBuffer(jsonBody))
+		req.Header.Set("Authorization", "Bearer "+generateTestToken(user.ID, user.Email, user.Role))
+		r.ServeHTTP(w, req)
+		return w
+	}
+	type column struct {
+		Status    string                   `json:"status"`
+		Count     int                      `json:"count"`
+		OverLimit bool                     `json:"overLimit"`
+		Cards     []map[string]interface{} `json:"cards"`
+	}
+	board := func() map[string][]string {
+		w := send(dev, "GET", "/api/boards/sprints/"+sprint.ID, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp struct {
+			Data []column `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		cards := map[string][]string{}
+		for _, col := range resp.Data {
+			cards[col.Status] = []string{}
+			for _, card := range col.Cards {
+				cards[col.Status] = append(cards[col.Status], card["Title"].(string))
+			}
+		}
+		return cards
+	}
+
+	t.Run("DefaultColumns", func(t *testing.T) {
+		cards := board()
+		assert.Equal(t, []string{"a", "b", "c", "d"}, cards["TODO"])
+		assert.Empty(t, cards["IN_PROGRESS"])
+		assert.Equal(t, []string{"done"}, cards["DONE"], "COMPLETED tasks show in the DONE column")
+
+		w := send(dev, "GET", "/api/boards/projects/"+project.ID, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp struct {
+			Data []column `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.Equal(t, 5, resp.Data[0].Count, "the project board includes unscheduled tasks")
+	})
+
+	t.Run("MoveWithinColumn", func(t *testing.T) {
+		w := send(dev, "POST", "/api/tasks/kb-d/move", map[string]interface{}{"status": "TODO", "beforeId": "kb-a"})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []string{"d", "a", "b", "c"}, board()["TODO"])
+
+		w = send(dev, "POST", "/api/tasks/kb-d/move", map[string]interface{}{"status": "TODO", "afterId": "kb-b"})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []string{"a", "b", "d", "c"}, board()["TODO"])
+	})
+
+	t.Run("MoveAcrossColumns", func(t *testing.T) {
+		w := send(dev, "POST", "/api/tasks/kb-b/move", map[string]interface{}{"status": "IN_PROGRESS"})
+		require.Equal(t, http.StatusOK, w.Code)
+		w = send(dev, "POST", "/api/tasks/kb-c/move", map[string]interface{}{"status": "IN_PROGRESS", "beforeId": "kb-b"})
+		require.Equal(t, http.StatusOK, w.Code)
+
+		cards := board()
+		assert.Equal(t, []string{"a", "d"}, cards["TODO"])
+		assert.Equal(t, []string{"c", "b"}, cards["IN_PROGRESS"])
+
+		w = send(dev, "POST", "/api/tasks/kb-c/move", map[string]interface{}{"status": "DONE"})
+		require.Equal(t, http.StatusOK, w.Code)
+		var task models.Task
+		database.DB.First(&task, "id = ?", "kb-c")
+		assert.Equal(t, "DONE", task.Status)
+		assert.NotNil(t, task.CompletedAt)
+
+		var history int64
+		database.DB.Model(&models.HistoryEntry{}).Where("entity_id = ? AND field = ?", "kb-c", "status").Count(&history)
+		assert.Equal(t, int64(2), history)
+	})
+
+	t.Run("RejectsInvalidMoves", func(t *testing.T) {
+		w := send(dev, "POST", "/api/tasks/kb-a/move", map[string]interface{}{"status": "ARCHIVED"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		// The anchor must be in the target column
+		w = send(dev, "POST", "/api/tasks/kb-a/move", map[string]interface{}{"status": "IN_PROGRESS", "beforeId": "kb-d"})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("ConfigureColumns", func(t *testing.T) {
+		columns := map[string]interface{}{"columns": []map[string]interface{}{
+			{"status": "TODO", "name": "To do"},
+			{"status": "IN_PROGRESS", "name": "Doing", "wipLimit": 1},
+			{"status": "REVIEW", "name": "Review", "wipLimit": 1, "wipPolicy": "WARN"},
+			{"status": "DONE", "name": "Done"},
+		}}
+		w := send(dev, "PUT", "/api/boards/projects/"+project.ID+"/columns", columns)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = send(owner, "PUT", "/api/boards/projects/"+project.ID+"/columns", columns)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		bad := map[string]interface{}{"columns": []map[string]interface{}{{"status": "TODO", "wipLimit": 0}}}
+		w = send(owner, "PUT", "/api/boards/projects/"+project.ID+"/columns", bad)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("WipLimits", func(t *testing.T) {
+		// IN_PROGRESS already holds b and its limit is 1
+		w := send(dev, "POST", "/api/tasks/kb-a/move", map[string]interface{}{"status": "IN_PROGRESS"})
+		require.Equal(t, http.StatusConflict, w.Code)
+		var rejected map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &rejected)
+		assert.Equal(t, "WIP_LIMIT_EXCEEDED", rejected["code"])
+		assert.Equal(t, []string{"a", "d"}, board()["TODO"])
+
+		// Status updates outside the board hit the same limit, and the limit
+		// counts the whole project, not just the sprint
+		w = send(dev, "PUT", "/api/tasks/kb-d", map[string]interface{}{"status": "IN_PROGRESS"})
+		assert.Equal(t, http.StatusConflict, w.Code)
+		w = send(dev, "POST", "/api/tasks/kb-other/move", map[string]interface{}{"status": "IN_PROGRESS"})
+		assert.Equal(t, http.StatusConflict, w.Code)
+		var task models.Task
+		database.DB.First(&task, "id = ?", "kb-d")
+		assert.Equal(t, "TODO", task.Status)
+
+		// So do tasks created straight into the column
+		w = send(dev, "POST", "/api/tasks/", map[string]interface{}{"title": "Straight in", "projectId": project.ID, "status": "IN_PROGRESS"})
+		assert.Equal(t, http.StatusConflict, w.Code)
+		var created int64
+		database.DB.Model(&models.Task{}).Where("title = ?", "Straight in").Count(&created)
+		assert.Zero(t, created)
+
+		// Reordering inside a full column is fine
+		w = send(dev, "POST", "/api/tasks/kb-b/move", map[string]interface{}{"status": "IN_PROGRESS"})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		// WARN columns accept the move and say so
+		w = send(dev, "POST", "/api/tasks/kb-a/move", map[string]interface{}{"status": "REVIEW"})
+		require.Equal(t, http.StatusOK, w.Code)
+		w = send(dev, "POST", "/api/tasks/kb-d/move", map[string]interface{}{"status": "REVIEW"})
+		require.Equal(t, http.StatusOK, w.Code)
+		var warned map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &warned)
+		assert.NotEmpty(t, warned["warning"])
+
+		w = send(dev, "GET", "/api/boards/sprints/"+sprint.ID, nil)
+		var resp struct {
+			Data []column `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		require.Len(t, resp.Data, 4)
+		assert.Equal(t, "REVIEW", resp.Data[2].Status)
+		assert.True(t, resp.Data[2].OverLimit)
+	})
+}
@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"Wrk_Api/internal/database"
+	"Wrk_Api/internal/models"
+	"Wrk_Api/internal/realtime"
+	"Wrk_Api/internal/routes"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChatSocket(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	SetupTestDB()
+	r := gin.Default()
+	routes.SetupRoutes(r)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	user1 := models.User{ID: "u1", Name: "User 1", Email: "u1@ws.com", Role: "TEAM_DEVELOPER"}
+	user2 := models.User{ID: "u2", Name: "User 2", Email: "u2@ws.com", Role: "TEAM_DEVELOPER"}
+	database.DB.Create(&user1)
+	database.DB.Create(&user2)
+
+	project := models.Project{ID: "p1", Name: "WS Project", OwnerID: user1.ID}
+	database.DB.Create(&project)
+	database.DB.Create(&models.ProjectMember{ID: "m1", ProjectID: project.ID, UserID: user2.ID, Role: "TEAM_DEVELOPER"})
+
+	token1 := generateTestToken(user1.ID, user1.Email, user1.Role)
+	token2 := generateTestToken(user2.ID, user2.Email, user2.Role)
+
+	dial := func(token string) *websocket.Conn {
+		url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/chat/ws?token=" + token
+		conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+		require.NoError(t, err)
+		return conn
+	}
+
+	readEvent := func(conn *websocket.Conn) realtime.Event {
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		var event realtime.Event
+		require.NoError(t, conn.ReadJSON(&event))
+		return event
+	}
+
+	send := func(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(jsonBody))
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("RejectsMissingToken", func(t *testing.T) {
+		url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/chat/ws"
+		_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("QueryTokenOnlyOnStreams", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/projects/?token="+token1, nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("LogoutClosesSocket", func(t *testing.T) {
+		token := generateTestToken(user2.ID, user2.Email, user2.Role)
+		conn := dial(token)
+		defer conn.Close()
+		other := dial(token2)
+		defer other.Close()
+		time.Sleep(50 * time.Millisecond)
+
+		assert.Equal(t, http.StatusOK, send("POST", "/api/auth/logout", token, nil).Code)
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		_, _, err := conn.ReadMessage()
+		assert.True(t, websocket.IsCloseError(err, websocket.ClosePolicyViolation), "got %v", err)
+
+		// Other sessions of the user stay connected
+		send("POST", "/api/chat/"+project.ID+"/messages", token1, map[string]string{"content": "Sigues ahí"})
+		assert.Equal(t, "message.created", readEvent(other).Type)
+	})
+
+	t.Run("ProjectMessageLifecycle", func(t *testing.T) {
+		conn := dial(token2)
+		defer conn.Close()
+		time.Sleep(50 * time.Millisecond)
+
+		w := send("POST", "/api/chat/"+project.ID+"/messages", token1, map[string]string{"content": "Hola equipo"})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var resp map[string]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		messageID := resp["data"]["ID"].(string)
+
+		event := readEvent(conn)
+		assert.Equal(t, "message.created", event.Type)
+		assert.Equal(t, "Hola equipo", event.Data.(map[string]interface{})["Content"])
+
+		// Only the author can edit
+		assert.Equal(t, http.StatusForbidden, send("PUT", "/api/chat/messages/"+messageID, token2, map[string]string{"content": "x"}).Code)
+		assert.Equal(t, http.StatusOK, send("PUT", "/api/chat/messages/"+messageID, token1, map[string]string{"content": "Hola a todos"}).Code)
+		event = readEvent(conn)
+		assert.Equal(t, "message.updated", event.Type)
+		assert.Equal(t, "Hola a todos", event.Data.(map[string]interface{})["Content"])
+
+		assert.Equal(t, http.StatusOK, send("DELETE", "/api/chat/messages/"+messageID, token1, nil).Code)
+		event = readEvent(conn)
+		assert.Equal(t, "message.deleted", event.Type)
+		assert.Equal(t, messageID, event.Data.(map[string]interface{})["id"])
+	})
+
+	t.Run("DirectChatJoinedWhileConnected", func(t *testing.T) {
+		conn := dial(token2)
+		defer conn.Close()
+		time.Sleep(50 * time.Millisecond)
+
+		w := send("POST", "/api/chat/direct", token1, map[string]string{"targetUserId": user2.ID})
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp map[string]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		chatID := resp["data"]["ID"].(string)
+
+		event := readEvent(conn)
+		assert.Equal(t, "chat.joined", event.Type)
+
+		w = send("POST", "/api/chat/conversation/"+chatID+"/messages", token1, map[string]string{"content": "Privado"})
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		event = readEvent(conn)
+		assert.Equal(t, "message.created", event.Type)
+		assert.Equal(t, chatID, event.Data.(map[string]interface{})["ChatID"])
+	})
+}
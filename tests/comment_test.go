@@ -0,0 +1,155 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Wrk_Api/internal/database"
+	"Wrk_Api/internal/models"
+	"Wrk_Api/internal/routes"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComments(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	SetupTestDB()
+	r := gin.Default()
+	routes.SetupRoutes(r)
+
+	owner := models.User{ID: "cm-owner", Name: "Olga Owner", Email: "olga@cm.com", Role: "SCRUM_MASTER"}
+	ana := models.User{ID: "cm-ana", Name: "Ana Diaz", Email: "ana@cm.com", Role: "TEAM_DEVELOPER"}
+	luis := models.User{ID: "cm-luis", Name: "Luis", Email: "luis@cm.com", Role: "TEAM_DEVELOPER"}
+	outsider := models.User{ID: "cm-out", Name: "Otto", Email: "otto@cm.com", Role: "TEAM_DEVELOPER"}
+	for _, u := range []*models.User{&owner, &ana, &luis, &outsider} {
+		database.DB.Create(u)
+	}
+	project := models.Project{ID: "cm-p", Name: "Comment Project", OwnerID: owner.ID}
+	database.DB.Create(&project)
+	database.DB.Create(&models.ProjectMember{ID: "cm-m1", ProjectID: project.ID, UserID: ana.ID, Role: "TEAM_DEVELOPER"})
+	database.DB.Create(&models.ProjectMember{ID: "cm-m2", ProjectID: project.ID, UserID: luis.ID, Role: "TEAM_DEVELOPER"})
+	task := models.Task{ID: "cm-t", ProjectID: project.ID, Title: "Set up CI", Status: "TODO"}
+	story := models.UserStory{ID: "cm-us", ProjectID: project.ID, Title: "Sign up", Status: "BACKLOG"}
+	database.DB.Create(&task)
+	database.DB.Create(&story)
+
+	send := func(user models.User, method, url string, body interface{}) *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, url, bytes.NewBuffer(jsonBody))
+		req.Header.Set("Authorization", "Bearer "+generateTestToken(user.ID, user.Email, user.Role))
+		r.ServeHTTP(w, req)
+		return w
+	}
+	mentions := func(userID string) int64 {
+		var count int64
+		database.DB.Model(&models.Notification{}).Where("user_id = ? AND type = ?", userID, "MENTION").Count(&count)
+		return count
+	}
+
+	var rootID, replyID string
+
+	t.Run("CommentWithMentions", func(t *testing.T) {
+		content := "Can @ana look at this? `@luis` is in code, @otto isn't in the project and mail olga@cm.com"
+		w := send(owner, "POST", "/api/tasks/"+task.ID+"/comments", map[string]string{"content": content})
+		require.Equal(t, http.StatusCreated, w.Code)
+		var resp struct {
+			Data     map[string]interface{}   `json:"data"`
+			Mentions []map[string]interface{} `json:"mentions"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		rootID = resp.Data["ID"].(string)
+		require.Len(t, resp.Mentions, 1)
+		assert.Equal(t, ana.ID, resp.Mentions[0]["ID"])
+
+		assert.Equal(t, int64(1), mentions(ana.ID))
+		assert.Equal(t, int64(0), mentions(luis.ID))
+		assert.Equal(t, int64(0), mentions(outsider.ID))
+		assert.Equal(t, int64(0), mentions(owner.ID))
+	})
+
+	t.Run("MentionByName", func(t *testing.T) {
+		w := send(ana, "POST", "/api/user-stories/"+story.ID+"/comments", map[string]string{"content": "Thanks @OlgaOwner!"})
+		require.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, int64(1), mentions(owner.ID))
+	})
+
+	t.Run("Replies", func(t *testing.T) {
+		w := send(ana, "POST", "/api/tasks/"+task.ID+"/comments", map[string]string{"content": "On it", "parentId": rootID})
+		require.Equal(t, http.StatusCreated, w.Code)
+		var resp map[string]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		replyID = resp["data"]["ID"].(string)
+
+		// Replying to a reply stays in the same thread
+		w = send(luis, "POST", "/api/tasks/"+task.ID+"/comments", map[string]string{"content": "Me too", "parentId": replyID})
+		require.Equal(t, http.StatusCreated, w.Code)
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.Equal(t, rootID, resp["data"]["ParentID"])
+
+		// The parent must be on the same task
+		w = send(luis, "POST", "/api/user-stories/"+story.ID+"/comments", map[string]string{"content": "Wrong", "parentId": rootID})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = send(luis, "GET", "/api/tasks/"+task.ID+"/comments", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var list map[string][]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &list)
+		require.Len(t, list["data"], 1)
+		assert.Len(t, list["data"][0]["Replies"], 2)
+	})
+
+	t.Run("EditByAuthorNotifiesNewMentions", func(t *testing.T) {
+		w := send(luis, "PUT", "/api/comments/"+replyID, map[string]string{"content": "Hijack"})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = send(ana, "PUT", "/api/comments/"+replyID, map[string]string{"content": "On it, @luis can review"})
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp map[string]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.NotNil(t, resp["data"]["EditedAt"])
+		assert.Equal(t, int64(1), mentions(luis.ID))
+
+		// Editing again without new mentions doesn't notify twice
+		w = send(ana, "PUT", "/api/comments/"+replyID, map[string]string{"content": "On it, @luis can review later"})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, int64(1), mentions(luis.ID))
+	})
+
+	t.Run("DeleteKeepsThread", func(t *testing.T) {
+		w := send(ana, "DELETE", "/api/comments/"+rootID, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = send(owner, "DELETE", "/api/comments/"+rootID, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w = send(ana, "GET", "/api/tasks/"+task.ID+"/comments", nil)
+		var list map[string][]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &list)
+		require.Len(t, list["data"], 1)
+		assert.Equal(t, true, list["data"][0]["Deleted"])
+		assert.Equal(t, "", list["data"][0]["Content"])
+		assert.Len(t, list["data"][0]["Replies"], 2)
+
+		// Once its last reply goes, the blanked root goes too
+		var replies []models.Comment
+		database.DB.Where("parent_id = ?", rootID).Find(&replies)
+		for _, reply := range replies {
+			w = send(owner, "DELETE", "/api/comments/"+reply.ID, nil)
+			require.Equal(t, http.StatusOK, w.Code)
+		}
+		var left int64
+		database.DB.Model(&models.Comment{}).Where("id = ?", rootID).Count(&left)
+		assert.Equal(t, int64(0), left)
+	})
+
+	t.Run("OutsidersCannotComment", func(t *testing.T) {
+		w := send(outsider, "POST", "/api/tasks/"+task.ID+"/comments", map[string]string{"content": "Hi"})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
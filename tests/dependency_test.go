@@ -0,0 +1,170 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"Wrk_Api/internal/database"
+	"Wrk_Api/internal/models"
+	"Wrk_Api/internal/routes"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDependencies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	SetupTestDB()
+	r := gin.Default()
+	routes.SetupRoutes(r)
+
+	owner := models.User{ID: "dep-owner", Name: "Owner", Email: "owner@dep.com", Role: "SCRUM_MASTER"}
+	database.DB.Create(&owner)
+	project := models.Project{ID: "dep-p", Name: "Dependency Project", OwnerID: owner.ID}
+	database.DB.Create(&project)
+	authHeader := "Bearer " + generateTestToken(owner.ID, owner.Email, owner.Role)
+
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	sprint := models.Sprint{ID: "dep-s", ProjectID: project.ID, Name: "Sprint", Status: "ACTIVE", StartDate: start, EndDate: start.AddDate(0, 0, 14)}
+	database.DB.Create(&sprint)
+
+	day := func(n int) *time.Time {
+		d := start.AddDate(0, 0, n)
+		return &d
+	}
+	// design -> build -> release, with docs running in parallel
+	for _, task := range []models.Task{
+		{ID: "design", ProjectID: project.ID, SprintID: &sprint.ID, Title: "Design", Status: "TODO", Deadline: day(2)},
+		{ID: "build", ProjectID: project.ID, SprintID: &sprint.ID, Title: "Build", Status: "TODO", Deadline: day(7)},
+		{ID: "docs", ProjectID: project.ID, SprintID: &sprint.ID, Title: "Docs", Status: "TODO", Deadline: day(4)},
+		{ID: "release", ProjectID: project.ID, SprintID: &sprint.ID, Title: "Release", Status: "TODO", Deadline: day(6)},
+	} {
+		database.DB.Create(&task)
+	}
+
+	send := func(method, url string, body interface{}) *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, url, bytes.NewBuffer(jsonBody))
+		req.Header.Set("Authorization", authHeader)
+		r.ServeHTTP(w, req)
+		return w
+	}
+	blocked := func(id string) bool {
+		var task models.Task
+		database.DB.First(&task, "id = ?", id)
+		return task.Blocked
+	}
+
+	t.Run("LinkTasksAndFlagBlocked", func(t *testing.T) {
+		w := send("POST", "/api/tasks/build/blocked-by", map[string]string{"blockerId": "design"})
+		require.Equal(t, http.StatusCreated, w.Code)
+		w = send("POST", "/api/tasks/release/blocked-by", map[string]string{"blockerId": "build"})
+		require.Equal(t, http.StatusCreated, w.Code)
+		w = send("POST", "/api/tasks/release/blocked-by", map[string]string{"blockerId": "docs"})
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		assert.True(t, blocked("build"))
+		assert.True(t, blocked("release"))
+		assert.False(t, blocked("design"))
+
+		w = send("GET", "/api/tasks/release/dependencies", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp map[string]map[string][]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.Len(t, resp["data"]["blockedBy"], 2)
+		assert.Len(t, resp["data"]["blocks"], 0)
+	})
+
+	t.Run("RejectsInvalidLinks", func(t *testing.T) {
+		w := send("POST", "/api/tasks/design/blocked-by", map[string]string{"blockerId": "design"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = send("POST", "/api/tasks/build/blocked-by", map[string]string{"blockerId": "design"})
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		// release depends on build, which depends on design
+		w = send("POST", "/api/tasks/design/blocked-by", map[string]string{"blockerId": "release"})
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+
+	t.Run("CriticalPath", func(t *testing.T) {
+		w := send("GET", "/api/sprints/"+sprint.ID+"/dependencies", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp struct {
+			Data struct {
+				Nodes        []map[string]interface{} `json:"nodes"`
+				Edges        []map[string]string      `json:"edges"`
+				CriticalPath []string                 `json:"criticalPath"`
+				Conflicts    []map[string]string      `json:"conflicts"`
+			} `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.Len(t, resp.Data.Nodes, 4)
+		assert.Len(t, resp.Data.Edges, 3)
+		assert.Equal(t, []string{"design", "build", "release"}, resp.Data.CriticalPath)
+
+		// release is due before build, which it waits for
+		require.Len(t, resp.Data.Conflicts, 1)
+		assert.Equal(t, "release", resp.Data.Conflicts[0]["taskId"])
+		assert.Equal(t, "build", resp.Data.Conflicts[0]["blockerId"])
+	})
+
+	t.Run("CompletingBlockerUnblocks", func(t *testing.T) {
+		w := send("PUT", "/api/tasks/design", map[string]string{"status": "DONE"})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.False(t, blocked("build"))
+		assert.True(t, blocked("release"))
+
+		w = send("PUT", "/api/tasks/build", map[string]string{"status": "DONE"})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, blocked("release"), "docs is still pending")
+
+		w = send("DELETE", "/api/tasks/release/blocked-by/docs", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.False(t, blocked("release"))
+
+		w = send("DELETE", "/api/tasks/release/blocked-by/docs", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("DeletingTaskRemovesLinks", func(t *testing.T) {
+		w := send("PUT", "/api/tasks/design", map[string]string{"status": "TODO"})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, blocked("build"))
+
+		w = send("DELETE", "/api/tasks/design", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.False(t, blocked("build"))
+
+		var count int64
+		database.DB.Model(&models.Dependency{}).Where("blocker_id = ? OR blocked_id = ?", "design", "design").Count(&count)
+		assert.Zero(t, count)
+	})
+
+	t.Run("UserStoryDependencies", func(t *testing.T) {
+		first := models.UserStory{ID: "dep-us1", ProjectID: project.ID, Title: "Accounts", Status: "BACKLOG"}
+		second := models.UserStory{ID: "dep-us2", ProjectID: project.ID, Title: "Profiles", Status: "BACKLOG"}
+		database.DB.Create(&first)
+		database.DB.Create(&second)
+
+		w := send("POST", "/api/user-stories/"+second.ID+"/blocked-by", map[string]string{"blockerId": first.ID})
+		require.Equal(t, http.StatusCreated, w.Code)
+		database.DB.First(&second, "id = ?", second.ID)
+		assert.True(t, second.Blocked)
+
+		// Tasks and stories can't be mixed
+		w = send("POST", "/api/user-stories/"+second.ID+"/blocked-by", map[string]string{"blockerId": "build"})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = send("PUT", "/api/user-stories/"+first.ID, map[string]string{"status": "DONE"})
+		require.Equal(t, http.StatusOK, w.Code)
+		database.DB.First(&second, "id = ?", second.ID)
+		assert.False(t, second.Blocked)
+	})
+}
@@ -2,6 +2,9 @@ package tests
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
@@ -10,14 +13,18 @@ import (
 	"Wrk_Api/internal/database"
 	"Wrk_Api/internal/models"
 	"Wrk_Api/internal/routes"
+	"Wrk_Api/internal/storage"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 )
 
 func TestDocumentHandlers(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	SetupTestDB()
+	storage.Default = storage.NewLocal(t.TempDir())
 	r := gin.Default()
 	routes.SetupRoutes(r)
 
@@ -54,4 +61,175 @@ func TestDocumentHandlers(t *testing.T) {
 		database.DB.Where("project_id = ?", project.ID).First(&doc)
 		assert.Equal(t, "test.txt", doc.Name)
 	})
+
+	upload := func(filename string, content []byte) models.Document {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		part, _ := writer.CreateFormFile("file", filename)
+		part.Write(content)
+		writer.WriteField("projectId", project.ID)
+		writer.Close()
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/documents/", body)
+		req.Header.Set("Authorization", authHeader)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		var resp struct{ Data models.Document }
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.Data
+	}
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", authHeader)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 32)...)
+	var first, second models.Document
+
+	t.Run("DownloadDocument", func(t *testing.T) {
+		first = upload("diagram.png", png)
+		assert.Equal(t, "image/png", first.Type)
+		sum := sha256.Sum256(png)
+		assert.Equal(t, hex.EncodeToString(sum[:]), first.Checksum)
+
+		w := get("/api/documents/" + first.ID + "/download")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, png, w.Body.Bytes())
+		assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Header().Get("Content-Disposition"), "diagram.png")
+
+		// Downloads require authentication
+		w = httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/documents/"+first.ID+"/download", nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("ReuploadCreatesVersion", func(t *testing.T) {
+		second = upload("diagram.png", append(png, 1))
+		assert.Equal(t, 2, second.Version)
+		require.NotNil(t, second.ParentID)
+		assert.Equal(t, first.ID, *second.ParentID)
+
+		// Two uploads can't end up with the same version number
+		clash := models.Document{ID: "doc-clash", ProjectID: project.ID, Name: "diagram.png", ParentID: second.ParentID, Version: 2}
+		err := database.DB.Create(&clash).Error
+		assert.ErrorIs(t, err, gorm.ErrDuplicatedKey)
+
+		w := get("/api/documents/" + first.ID + "/versions")
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp struct{ Data []models.Document }
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		require.Len(t, resp.Data, 2)
+		assert.Equal(t, second.ID, resp.Data[0].ID)
+
+		// The project listing only shows the latest version
+		w = get("/api/documents/" + project.ID)
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		ids := []string{}
+		for _, d := range resp.Data {
+			ids = append(ids, d.ID)
+		}
+		assert.Contains(t, ids, second.ID)
+		assert.NotContains(t, ids, first.ID)
+	})
+
+	t.Run("DeleteFirstVersion", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/api/documents/"+first.ID, nil)
+		req.Header.Set("Authorization", authHeader)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var doc models.Document
+		database.DB.First(&doc, "id = ?", second.ID)
+		assert.Nil(t, doc.ParentID)
+
+		w = get("/api/documents/" + first.ID + "/download")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		w = get("/api/documents/" + second.ID + "/download")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("StorageLimits", func(t *testing.T) {
+		adminUser := models.User{ID: "admin", Name: "Admin", Email: "admin@docs.com", Role: "ADMIN"}
+		database.DB.Create(&adminUser)
+		adminHeader := "Bearer " + generateTestToken(adminUser.ID, adminUser.Email, adminUser.Role)
+
+		setLimits := func(auth string, limits map[string]interface{}) int {
+			jsonBody, _ := json.Marshal(limits)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("PUT", "/api/projects/"+project.ID+"/storage", bytes.NewBuffer(jsonBody))
+			req.Header.Set("Authorization", auth)
+			r.ServeHTTP(w, req)
+			return w.Code
+		}
+		tryUpload := func(filename string, content []byte) (int, string) {
+			body := &bytes.Buffer{}
+			writer := multipart.NewWriter(body)
+			part, _ := writer.CreateFormFile("file", filename)
+			part.Write(content)
+			writer.WriteField("projectId", project.ID)
+			writer.Close()
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/api/documents/", body)
+			req.Header.Set("Authorization", authHeader)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			r.ServeHTTP(w, req)
+
+			var resp map[string]interface{}
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			code, _ := resp["code"].(string)
+			return w.Code, code
+		}
+
+		// Only admins set limits, not the project owner
+		assert.Equal(t, http.StatusForbidden, setLimits(authHeader, map[string]interface{}{"storageQuota": 0}))
+
+		w := get("/api/projects/" + project.ID + "/storage")
+		assert.Equal(t, http.StatusOK, w.Code)
+		var usage struct {
+			Data struct {
+				Used      int64 `json:"used"`
+				Documents int64 `json:"documents"`
+			} `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &usage)
+		used := usage.Data.Used
+		assert.Equal(t, int64(len(png)+1+len("file content")), used)
+
+		require.Equal(t, http.StatusOK, setLimits(adminHeader, map[string]interface{}{"maxFileSize": 10}))
+		code, errCode := tryUpload("big.txt", bytes.Repeat([]byte("a"), 11))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, code)
+		assert.Equal(t, "FILE_TOO_LARGE", errCode)
+
+		require.Equal(t, http.StatusOK, setLimits(adminHeader, map[string]interface{}{"maxFileSize": 0, "storageQuota": used + 5}))
+		code, errCode = tryUpload("six.txt", []byte("123456"))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, code)
+		assert.Equal(t, "QUOTA_EXCEEDED", errCode)
+
+		require.Equal(t, http.StatusOK, setLimits(adminHeader, map[string]interface{}{"storageQuota": 0, "allowedTypes": "image/*, application/pdf"}))
+		code, errCode = tryUpload("notes.txt", []byte("plain text"))
+		assert.Equal(t, http.StatusUnsupportedMediaType, code)
+		assert.Equal(t, "UNSUPPORTED_MEDIA_TYPE", errCode)
+
+		code, _ = tryUpload("photo.png", png)
+		assert.Equal(t, http.StatusCreated, code)
+
+		// Bodies past the largest limit any project has are cut off before parsing
+		require.Equal(t, http.StatusOK, setLimits(adminHeader, map[string]interface{}{"maxFileSize": 10, "allowedTypes": ""}))
+		defaultMax := storage.DefaultMaxFileSize
+		storage.DefaultMaxFileSize = 10
+		defer func() { storage.DefaultMaxFileSize = defaultMax }()
+		code, errCode = tryUpload("huge.txt", bytes.Repeat([]byte("a"), 2<<20))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, code)
+		assert.Equal(t, "FILE_TOO_LARGE", errCode)
+	})
 }
@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"Wrk_Api/internal/database"
+	"Wrk_Api/internal/models"
+	"Wrk_Api/internal/routes"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEpics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	SetupTestDB()
+	r := gin.Default()
+	routes.SetupRoutes(r)
+
+	owner := models.User{ID: "epic-owner", Name: "Owner", Email: "owner@epic.com", Role: "PRODUCT_OWNER"}
+	database.DB.Create(&owner)
+	project := models.Project{ID: "epic-p", Name: "Epic Project", OwnerID: owner.ID}
+	other := models.Project{ID: "epic-other", Name: "Other Project", OwnerID: owner.ID}
+	database.DB.Create(&project)
+	database.DB.Create(&other)
+	authHeader := "Bearer " + generateTestToken(owner.ID, owner.Email, owner.Role)
+
+	send := func(method, url string, body interface{}) *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, url, bytes.NewBuffer(jsonBody))
+		req.Header.Set("Authorization", authHeader)
+		r.ServeHTTP(w, req)
+		return w
+	}
+	createStory := func(title string, points int, epicID string) string {
+		w := send("POST", "/api/user-stories/", map[string]interface{}{"title": title, "projectId": project.ID, "storyPoints": points, "epicId": epicID})
+		require.Equal(t, http.StatusCreated, w.Code)
+		var resp map[string]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp["data"]["ID"].(string)
+	}
+
+	var epicID string
+	var storyIDs []string
+
+	t.Run("CreateEpic", func(t *testing.T) {
+		w := send("POST", "/api/epics/", map[string]interface{}{"title": "Checkout", "projectId": project.ID})
+		require.Equal(t, http.StatusCreated, w.Code)
+		var resp map[string]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		epicID = resp["data"]["ID"].(string)
+		assert.Equal(t, "OPEN", resp["data"]["Status"])
+	})
+
+	t.Run("AssignStories", func(t *testing.T) {
+		storyIDs = append(storyIDs, createStory("Cart", 5, epicID), createStory("Payment", 3, epicID))
+		loose := createStory("Login", 8, "")
+
+		w := send("PUT", "/api/user-stories/"+loose, map[string]interface{}{"epicId": epicID})
+		require.Equal(t, http.StatusOK, w.Code)
+		storyIDs = append(storyIDs, loose)
+
+		// An epic of another project is rejected
+		foreign := models.Epic{ID: "epic-foreign", ProjectID: other.ID, Title: "Foreign"}
+		database.DB.Create(&foreign)
+		w = send("PUT", "/api/user-stories/"+loose, map[string]interface{}{"epicId": foreign.ID})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		// An empty epicId detaches the story
+		w = send("PUT", "/api/user-stories/"+loose, map[string]interface{}{"epicId": ""})
+		require.Equal(t, http.StatusOK, w.Code)
+		var story models.UserStory
+		database.DB.First(&story, "id = ?", loose)
+		assert.Nil(t, story.EpicID)
+		storyIDs = storyIDs[:2]
+	})
+
+	t.Run("ProgressRollup", func(t *testing.T) {
+		w := send("PUT", "/api/user-stories/"+storyIDs[0], map[string]interface{}{"status": "DONE"})
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w = send("GET", "/api/epics/project/"+project.ID, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp map[string][]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		require.Len(t, resp["data"], 1)
+		progress := resp["data"][0]["progress"].(map[string]interface{})
+		assert.Equal(t, float64(8), progress["totalPoints"])
+		assert.Equal(t, float64(5), progress["donePoints"])
+		assert.Equal(t, float64(2), progress["totalStories"])
+		assert.Equal(t, 62.5, progress["percent"])
+	})
+
+	t.Run("FilterByEpic", func(t *testing.T) {
+		task := models.Task{ID: "epic-task", ProjectID: project.ID, UserStoryID: &storyIDs[1], Title: "Integrate gateway"}
+		database.DB.Create(&task)
+		database.DB.Create(&models.Task{ID: "epic-loose-task", ProjectID: project.ID, Title: "Unrelated"})
+
+		w := send("GET", "/api/user-stories/?epicId="+epicID, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var stories map[string][]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &stories)
+		assert.Len(t, stories["data"], 2)
+
+		w = send("GET", "/api/tasks/?epicId="+epicID, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var tasks map[string][]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &tasks)
+		require.Len(t, tasks["data"], 1)
+		assert.Equal(t, "epic-task", tasks["data"][0]["ID"])
+	})
+
+	t.Run("Burnup", func(t *testing.T) {
+		// Backdate the epic so the series spans several days
+		threeDaysAgo := time.Now().AddDate(0, 0, -3)
+		database.DB.Model(&models.Epic{}).Where("id = ?", epicID).UpdateColumn("created_at", threeDaysAgo)
+		database.DB.Model(&models.UserStory{}).Where("id IN ?", storyIDs).UpdateColumn("created_at", threeDaysAgo)
+		database.DB.Model(&models.HistoryEntry{}).Where("entity_id IN ?", storyIDs).UpdateColumn("created_at", threeDaysAgo)
+
+		// An old story added to the epic today only counts from today
+		late := createStory("Refunds", 2, "")
+		database.DB.Model(&models.UserStory{}).Where("id = ?", late).UpdateColumn("created_at", threeDaysAgo)
+		require.Equal(t, http.StatusOK, send("PUT", "/api/user-stories/"+late, map[string]interface{}{"epicId": epicID}).Code)
+
+		w := send("GET", "/api/metrics/epics/"+epicID+"/burnup", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp struct {
+			Series []struct {
+				Scope int  `json:"scope"`
+				Done  *int `json:"done"`
+			} `json:"series"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		require.Len(t, resp.Series, 4)
+		assert.Equal(t, 8, resp.Series[0].Scope)
+		assert.Equal(t, 0, *resp.Series[0].Done)
+		assert.Equal(t, 8, resp.Series[2].Scope)
+		assert.Equal(t, 10, resp.Series[3].Scope)
+		assert.Equal(t, 5, *resp.Series[3].Done)
+	})
+
+	t.Run("DeleteEpicKeepsStories", func(t *testing.T) {
+		w := send("DELETE", "/api/epics/"+epicID, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var story models.UserStory
+		require.NoError(t, database.DB.First(&story, "id = ?", storyIDs[0]).Error)
+		assert.Nil(t, story.EpicID)
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 
 	"Wrk_Api/internal/database"
@@ -43,10 +44,9 @@ func TestEvaluationHandlers(t *testing.T) {
 
 	t.Run("CreateGenericEvaluation", func(t *testing.T) {
 		body := map[string]interface{}{
-			"projectId":   project.ID,
-			"evaluatorId": user.ID,
-			"feedback":    "Great work",
-			"score":       10,
+			"projectId": project.ID,
+			"feedback":  "Great work",
+			"score":     10,
 			"criteriaScores": []map[string]interface{}{
 				{"criteriaId": crit.ID, "score": 10},
 			},
@@ -99,6 +99,357 @@ func TestEvaluationHandlers(t *testing.T) {
 		var eval models.Evaluation
 		database.DB.First(&eval, "id = ?", evalID)
 		assert.Equal(t, "Updated feedback", *eval.Feedback)
-		assert.Equal(t, 9, *eval.Score)
+		// The score is computed from the rubric: 9/10 on the only criterion
+		assert.Equal(t, 90, *eval.Score)
+		assert.Equal(t, rubric.ID, *eval.RubricID)
+	})
+}
+
+func TestWeightedRubricScoring(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	SetupTestDB()
+	r := gin.Default()
+	routes.SetupRoutes(r)
+
+	user := models.User{ID: "eval-u1", Name: "Evaluator", Email: "eval@weights.com", Role: "SCRUM_MASTER"}
+	database.DB.Create(&user)
+	project := models.Project{ID: "p1", Name: "Weights Project", OwnerID: user.ID}
+	database.DB.Create(&project)
+
+	rubric := models.Rubric{ID: "rub1", ProjectID: &project.ID, Name: "Rubric"}
+	database.DB.Create(&rubric)
+	style := models.Criteria{ID: "style", RubricID: rubric.ID, Name: "Style", MaxScore: 10, Weight: 1}
+	logic := models.Criteria{ID: "logic", RubricID: rubric.ID, Name: "Logic", MaxScore: 20, Weight: 3}
+	database.DB.Create(&style)
+	database.DB.Create(&logic)
+
+	other := models.Rubric{ID: "rub2", ProjectID: &project.ID, Name: "Other"}
+	database.DB.Create(&other)
+	foreign := models.Criteria{ID: "foreign", RubricID: other.ID, Name: "Foreign", MaxScore: 10, Weight: 1}
+	database.DB.Create(&foreign)
+
+	authHeader := "Bearer " + generateTestToken(user.ID, user.Email, user.Role)
+
+	create := func(scores []map[string]interface{}) (int, map[string]interface{}) {
+		jsonBody, _ := json.Marshal(map[string]interface{}{
+			"projectId":      project.ID,
+			"rubricId":       rubric.ID,
+			"score":          100,
+			"criteriaScores": scores,
+			"status":         "SUBMITTED", // drafts may be scored partially
+		})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/evaluations/", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Authorization", authHeader)
+		r.ServeHTTP(w, req)
+
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	t.Run("ComputesWeightedGrade", func(t *testing.T) {
+		// (1 * 5/10 + 3 * 20/20) / 4 = 0.875; the client-supplied score is ignored
+		code, resp := create([]map[string]interface{}{
+			{"criteriaId": style.ID, "score": 5},
+			{"criteriaId": logic.ID, "score": 20},
+		})
+		assert.Equal(t, http.StatusCreated, code)
+		id := resp["data"].(map[string]interface{})["id"].(string)
+
+		var eval models.Evaluation
+		database.DB.First(&eval, "id = ?", id)
+		assert.Equal(t, 88, *eval.Score)
+		assert.Equal(t, rubric.ID, *eval.RubricID)
+	})
+
+	t.Run("RejectsScoreAboveMax", func(t *testing.T) {
+		code, _ := create([]map[string]interface{}{
+			{"criteriaId": style.ID, "score": 11},
+			{"criteriaId": logic.ID, "score": 20},
+		})
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("RejectsCriteriaOutsideRubric", func(t *testing.T) {
+		code, _ := create([]map[string]interface{}{
+			{"criteriaId": style.ID, "score": 5},
+			{"criteriaId": logic.ID, "score": 5},
+			{"criteriaId": foreign.ID, "score": 5},
+		})
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("RejectsMissingCriteria", func(t *testing.T) {
+		code, _ := create([]map[string]interface{}{
+			{"criteriaId": style.ID, "score": 5},
+		})
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("ManualScoreNeedsMaxScore", func(t *testing.T) {
+		manual := func(body map[string]interface{}) (int, map[string]interface{}) {
+			body["projectId"] = project.ID
+			body["status"] = "SUBMITTED"
+			jsonBody, _ := json.Marshal(body)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/api/evaluations/", bytes.NewBuffer(jsonBody))
+			req.Header.Set("Authorization", authHeader)
+			r.ServeHTTP(w, req)
+			var resp map[string]interface{}
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			return w.Code, resp
+		}
+
+		code, _ := manual(map[string]interface{}{"score": 90})
+		assert.Equal(t, http.StatusBadRequest, code)
+		code, _ = manual(map[string]interface{}{"score": 11, "maxScore": 10})
+		assert.Equal(t, http.StatusBadRequest, code)
+
+		// 7 out of 8 is stored on the 0-100 scale
+		code, resp := manual(map[string]interface{}{"score": 7, "maxScore": 8})
+		assert.Equal(t, http.StatusCreated, code)
+		assert.Equal(t, float64(88), resp["data"].(map[string]interface{})["score"])
+	})
+}
+
+func TestEvaluationLifecycle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	SetupTestDB()
+	r := gin.Default()
+	routes.SetupRoutes(r)
+
+	owner := models.User{ID: "owner", Name: "Owner", Email: "owner@lifecycle.com"}
+	student := models.User{ID: "student", Name: "Student", Email: "student@lifecycle.com"}
+	database.DB.Create(&owner)
+	database.DB.Create(&student)
+	project := models.Project{ID: "p1", Name: "Lifecycle Project", OwnerID: owner.ID}
+	database.DB.Create(&project)
+	database.DB.Create(&models.ProjectMember{ID: "m1", ProjectID: project.ID, UserID: student.ID, Role: "TEAM_DEVELOPER"})
+
+	rubric := models.Rubric{ID: "rub1", ProjectID: &project.ID, Name: "Rubric"}
+	database.DB.Create(&rubric)
+	design := models.Criteria{ID: "design", RubricID: rubric.ID, Name: "Design", MaxScore: 10, Weight: 1}
+	tests := models.Criteria{ID: "tests", RubricID: rubric.ID, Name: "Tests", MaxScore: 10, Weight: 1}
+	database.DB.Create(&design)
+	database.DB.Create(&tests)
+
+	ownerHeader := "Bearer " + generateTestToken(owner.ID, owner.Email, owner.Role)
+	studentHeader := "Bearer " + generateTestToken(student.ID, student.Email, student.Role)
+
+	send := func(method, path, auth string, body interface{}) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		if body != nil {
+			json.NewEncoder(&buf).Encode(body)
+		}
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, &buf)
+		req.Header.Set("Authorization", auth)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	var evalID string
+
+	t.Run("DraftAllowsPartialScores", func(t *testing.T) {
+		w := send("POST", "/api/evaluations/", ownerHeader, map[string]interface{}{
+			"projectId": project.ID,
+			"rubricId":  rubric.ID,
+			"criteriaScores": []map[string]interface{}{
+				{"criteriaId": design.ID, "score": 8},
+			},
+		})
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var resp map[string]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		evalID = resp["data"]["id"].(string)
+		assert.Equal(t, models.EvaluationDraft, resp["data"]["status"])
+		assert.Nil(t, resp["data"]["score"])
+	})
+
+	t.Run("SubmitRequiresCompleteScore", func(t *testing.T) {
+		w := send("POST", "/api/evaluations/"+evalID+"/submit", ownerHeader, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = send("PUT", "/api/evaluations/"+evalID, ownerHeader, map[string]interface{}{
+			"criteriaScores": []map[string]interface{}{
+				{"criteriaId": design.ID, "score": 8},
+				{"criteriaId": tests.ID, "score": 6},
+			},
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = send("POST", "/api/evaluations/"+evalID+"/submit", ownerHeader, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var eval models.Evaluation
+		database.DB.First(&eval, "id = ?", evalID)
+		assert.Equal(t, models.EvaluationSubmitted, eval.Status)
+		assert.Equal(t, 70, *eval.Score)
+		assert.NotNil(t, eval.SubmittedAt)
+	})
+
+	t.Run("SubmittedIsReadOnly", func(t *testing.T) {
+		w := send("PUT", "/api/evaluations/"+evalID, ownerHeader, map[string]interface{}{"score": 100})
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		w = send("POST", "/api/evaluations/"+evalID+"/submit", ownerHeader, nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("HiddenFromStudentUntilPublished", func(t *testing.T) {
+		w := send("GET", "/api/evaluations/"+evalID, studentHeader, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = send("GET", "/api/evaluations/student/"+student.ID, studentHeader, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), evalID)
+	})
+
+	t.Run("StudentCannotPublish", func(t *testing.T) {
+		w := send("POST", "/api/evaluations/"+evalID+"/publish", studentHeader, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("PublishNotifiesStudents", func(t *testing.T) {
+		w := send("POST", "/api/evaluations/"+evalID+"/publish", ownerHeader, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var eval models.Evaluation
+		database.DB.First(&eval, "id = ?", evalID)
+		assert.Equal(t, models.EvaluationPublished, eval.Status)
+		assert.NotNil(t, eval.PublishedAt)
+
+		var count int64
+		database.DB.Model(&models.Notification{}).Where("user_id = ? AND type = ?", student.ID, "EVALUATION_COMPLETED").Count(&count)
+		assert.Equal(t, int64(1), count)
+
+		w = send("GET", "/api/evaluations/"+evalID, studentHeader, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		w = send("GET", "/api/evaluations/student/"+student.ID, studentHeader, nil)
+		assert.Contains(t, w.Body.String(), evalID)
+	})
+
+	t.Run("ReopenReturnsToDraft", func(t *testing.T) {
+		w := send("POST", "/api/evaluations/"+evalID+"/reopen", ownerHeader, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var eval models.Evaluation
+		database.DB.First(&eval, "id = ?", evalID)
+		assert.Equal(t, models.EvaluationDraft, eval.Status)
+		assert.Nil(t, eval.PublishedAt)
+
+		w = send("PUT", "/api/evaluations/"+evalID, ownerHeader, map[string]interface{}{"feedback": "Revisado"})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		// Changing only the feedback keeps the criterion scores and the grade
+		database.DB.Preload("Criteria").First(&eval, "id = ?", evalID)
+		assert.Equal(t, "Revisado", *eval.Feedback)
+		assert.Len(t, eval.Criteria, 2)
+		assert.Equal(t, 70, *eval.Score)
+	})
+
+	t.Run("TaskHidesUnpublishedEvaluations", func(t *testing.T) {
+		database.DB.Create(&models.Task{ID: "graded", ProjectID: project.ID, Title: "Graded", Status: "DONE", AssigneeID: &student.ID})
+		w := send("POST", "/api/tasks/graded/evaluate", ownerHeader, map[string]interface{}{
+			"rubricId": rubric.ID,
+			"status":   models.EvaluationSubmitted,
+			"criteriaScores": []map[string]interface{}{
+				{"criteriaId": design.ID, "score": 4},
+				{"criteriaId": tests.ID, "score": 5},
+			},
+		})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var created map[string]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &created)
+		taskEvalID := created["data"]["id"].(string)
+
+		w = send("GET", "/api/tasks/graded", studentHeader, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), taskEvalID, "students don't see submitted evaluations")
+		w = send("GET", "/api/tasks/?include=evaluations", studentHeader, nil)
+		assert.NotContains(t, w.Body.String(), taskEvalID)
+
+		w = send("GET", "/api/tasks/graded", ownerHeader, nil)
+		assert.Contains(t, w.Body.String(), taskEvalID, "evaluators do")
+
+		send("POST", "/api/evaluations/"+taskEvalID+"/publish", ownerHeader, nil)
+		w = send("GET", "/api/tasks/graded", studentHeader, nil)
+		assert.Contains(t, w.Body.String(), taskEvalID)
+	})
+
+	t.Run("TargetsMustBelongToProject", func(t *testing.T) {
+		outsider := models.User{ID: "outsider", Name: "Outsider", Email: "outsider@lifecycle.com"}
+		database.DB.Create(&outsider)
+		other := models.Project{ID: "p2", Name: "Other Project", OwnerID: outsider.ID}
+		database.DB.Create(&other)
+		database.DB.Create(&models.Task{ID: "foreign-task", ProjectID: other.ID, Title: "Foreign", Status: "DONE"})
+		database.DB.Create(&models.Sprint{ID: "foreign-sprint", ProjectID: other.ID, Name: "Foreign"})
+		database.DB.Create(&models.Task{ID: "outsider-task", ProjectID: project.ID, Title: "Outsider", Status: "DONE", AssigneeID: &outsider.ID})
+
+		evaluate := func(target map[string]interface{}) int {
+			body := map[string]interface{}{"projectId": project.ID, "score": 7, "maxScore": 10}
+			for key, value := range target {
+				body[key] = value
+			}
+			return send("POST", "/api/evaluations/", ownerHeader, body).Code
+		}
+		assert.Equal(t, http.StatusBadRequest, evaluate(map[string]interface{}{"taskId": "foreign-task"}))
+		assert.Equal(t, http.StatusBadRequest, evaluate(map[string]interface{}{"sprintId": "foreign-sprint"}))
+		assert.Equal(t, http.StatusBadRequest, evaluate(map[string]interface{}{"taskId": "missing"}))
+		assert.Equal(t, http.StatusBadRequest, evaluate(map[string]interface{}{"taskId": "outsider-task"}))
+		assert.Equal(t, http.StatusCreated, evaluate(map[string]interface{}{"taskId": "graded"}))
+
+		var count int64
+		database.DB.Model(&models.Evaluation{}).Where("task_id IN ? OR sprint_id = ?", []string{"foreign-task", "outsider-task"}, "foreign-sprint").Count(&count)
+		assert.Zero(t, count)
+	})
+
+	t.Run("StudentEvaluationsOnlyFromGradedProjects", func(t *testing.T) {
+		// The outsider owns p2, where the student is also a member
+		database.DB.Create(&models.ProjectMember{ID: "m2", ProjectID: "p2", UserID: student.ID, Role: "TEAM_DEVELOPER"})
+		database.DB.Create(&models.Evaluation{ID: "p2-eval", ProjectID: "p2", EvaluatorID: "outsider", Status: models.EvaluationPublished})
+		outsiderHeader := "Bearer " + generateTestToken("outsider", "outsider@lifecycle.com", "")
+
+		w := send("GET", "/api/evaluations/student/"+student.ID, outsiderHeader, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp struct{ Data []models.Evaluation }
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.NotEmpty(t, resp.Data)
+		for _, eval := range resp.Data {
+			assert.Equal(t, "p2", eval.ProjectID)
+		}
+
+		w = send("GET", "/api/evaluations/student/"+student.ID, studentHeader, nil)
+		assert.Contains(t, w.Body.String(), "p2-eval")
+		assert.Contains(t, w.Body.String(), `"ProjectID":"p1"`)
+	})
+
+	t.Run("ConcurrentPublishesApplyOnce", func(t *testing.T) {
+		score := 80
+		database.DB.Create(&models.Evaluation{ID: "racy", ProjectID: project.ID, EvaluatorID: owner.ID, Score: &score, Status: models.EvaluationSubmitted})
+
+		codes := make(chan int, 8)
+		var wg sync.WaitGroup
+		for i := 0; i < cap(codes); i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				codes <- send("POST", "/api/evaluations/racy/publish", ownerHeader, nil).Code
+			}()
+		}
+		wg.Wait()
+		close(codes)
+
+		published := 0
+		for code := range codes {
+			if code == http.StatusOK {
+				published++
+			} else {
+				assert.Equal(t, http.StatusConflict, code)
+			}
+		}
+		assert.Equal(t, 1, published)
 	})
 }
@@ -0,0 +1,190 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Wrk_Api/internal/database"
+	"Wrk_Api/internal/models"
+	"Wrk_Api/internal/routes"
+	"Wrk_Api/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGradebook(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	SetupTestDB()
+	r := gin.Default()
+	routes.SetupRoutes(r)
+
+	teacher := models.User{ID: "teacher", Name: "Teacher", Email: "teacher@grades.com"}
+	ana := models.User{ID: "ana", Name: "Ana", Email: "ana@grades.com"}
+	luis := models.User{ID: "luis", Name: "Luis", Email: "luis@grades.com"}
+	for _, u := range []*models.User{&teacher, &ana, &luis} {
+		database.DB.Create(u)
+	}
+	project := models.Project{ID: "p1", Name: "Grades Project", OwnerID: teacher.ID}
+	database.DB.Create(&project)
+	database.DB.Create(&models.ProjectMember{ID: "m0", ProjectID: project.ID, UserID: teacher.ID, Role: "EVALUATOR"})
+	database.DB.Create(&models.ProjectMember{ID: "m1", ProjectID: project.ID, UserID: ana.ID, Role: "TEAM_DEVELOPER"})
+	database.DB.Create(&models.ProjectMember{ID: "m2", ProjectID: project.ID, UserID: luis.ID, Role: "TEAM_DEVELOPER"})
+	sprint := models.Sprint{ID: "s1", ProjectID: project.ID, Name: "Sprint 1"}
+	database.DB.Create(&sprint)
+	task := models.Task{ID: "t1", ProjectID: project.ID, Title: "Login", AssigneeID: &ana.ID}
+	database.DB.Create(&task)
+	crit := models.Criteria{ID: "quality", RubricID: "rub1", Name: "Quality", MaxScore: 10, Weight: 1}
+	database.DB.Create(&models.Rubric{ID: "rub1", ProjectID: &project.ID, Name: "Rubric"})
+	database.DB.Create(&crit)
+
+	score := func(v int) *int { return &v }
+	evals := []models.Evaluation{
+		{ID: "e1", ProjectID: project.ID, TaskID: &task.ID, EvaluatorID: teacher.ID, Score: score(80), Status: models.EvaluationPublished},
+		{ID: "e2", ProjectID: project.ID, SprintID: &sprint.ID, EvaluatorID: teacher.ID, Score: score(60), Status: models.EvaluationPublished},
+		{ID: "e3", ProjectID: project.ID, EvaluatorID: teacher.ID, Score: score(100), Status: models.EvaluationCompleted},
+		// Drafts never reach the gradebook
+		{ID: "e4", ProjectID: project.ID, EvaluatorID: teacher.ID, Score: score(0), Status: models.EvaluationDraft},
+	}
+	for i := range evals {
+		database.DB.Create(&evals[i])
+	}
+	database.DB.Create(&models.EvaluationCriteria{ID: "ec1", EvaluationID: "e1", CriteriaID: crit.ID, Score: 8})
+
+	teacherHeader := "Bearer " + generateTestToken(teacher.ID, teacher.Email, teacher.Role)
+	get := func(path, auth string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", auth)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	type gradebookResponse struct {
+		Data struct {
+			Rows []struct {
+				StudentID string              `json:"studentId"`
+				Task      *float64            `json:"task"`
+				Sprint    *float64            `json:"sprint"`
+				General   *float64            `json:"general"`
+				Criteria  map[string]*float64 `json:"criteria"`
+				Final     *float64            `json:"final"`
+			} `json:"rows"`
+		} `json:"data"`
+	}
+
+	t.Run("WeightedJSON", func(t *testing.T) {
+		w := get("/api/metrics/projects/"+project.ID+"/gradebook", teacherHeader)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp gradebookResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		require.Len(t, resp.Data.Rows, 2)
+
+		for _, row := range resp.Data.Rows {
+			switch row.StudentID {
+			case ana.ID:
+				// (80*50 + 60*30 + 100*20) / 100
+				assert.Equal(t, 78.0, *row.Final)
+				assert.Equal(t, 80.0, *row.Criteria["Quality"])
+			case luis.ID:
+				// No task grades: (60*30 + 100*20) / 50
+				assert.Nil(t, row.Task)
+				assert.Equal(t, 76.0, *row.Final)
+			}
+		}
+	})
+
+	t.Run("CustomWeights", func(t *testing.T) {
+		w := get("/api/metrics/projects/"+project.ID+"/gradebook?taskWeight=1&sprintWeight=0&generalWeight=0", teacherHeader)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp gradebookResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		for _, row := range resp.Data.Rows {
+			if row.StudentID == ana.ID {
+				assert.Equal(t, 80.0, *row.Final)
+			} else {
+				assert.Nil(t, row.Final)
+			}
+		}
+
+		w = get("/api/metrics/projects/"+project.ID+"/gradebook?taskWeight=-1", teacherHeader)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		w := get("/api/metrics/projects/"+project.ID+"/gradebook?format=csv", teacherHeader)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+
+		records, err := csv.NewReader(w.Body).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 3)
+		assert.Equal(t, []string{"Proyecto", "Estudiante", "Email", "Tareas", "Sprints", "General", "Quality (%)", "Nota Final"}, records[0])
+	})
+
+	t.Run("XLSX", func(t *testing.T) {
+		w := get("/api/metrics/projects/"+project.ID+"/gradebook?format=xlsx", teacherHeader)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+		require.NoError(t, err)
+		var sheet string
+		for _, f := range zr.File {
+			if f.Name == "xl/worksheets/sheet1.xml" {
+				rc, _ := f.Open()
+				raw, _ := io.ReadAll(rc)
+				sheet = string(raw)
+			}
+		}
+		assert.Contains(t, sheet, "Ana")
+		assert.Contains(t, sheet, "<v>78</v>")
+	})
+
+	t.Run("StudentGradebook", func(t *testing.T) {
+		w := get("/api/metrics/students/"+ana.ID+"/gradebook", "Bearer "+generateTestToken(ana.ID, ana.Email, ana.Role))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp gradebookResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		require.Len(t, resp.Data.Rows, 1)
+		assert.Equal(t, 78.0, *resp.Data.Rows[0].Final)
+
+		// Students can't see the whole project's gradebook
+		w = get("/api/metrics/projects/"+project.ID+"/gradebook", "Bearer "+generateTestToken(ana.ID, ana.Email, ana.Role))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
+
+func TestSpreadsheetCells(t *testing.T) {
+	rows := [][]string{{"=HYPERLINK(\"http://evil\")", "+1", "-2.5", "@SUM(A1)", "NaN", "1e400", "78"}}
+
+	assert.Equal(t, [][]string{{"'=HYPERLINK(\"http://evil\")", "'+1", "-2.5", "'@SUM(A1)", "NaN", "1e400", "78"}}, utils.CSVSafe(rows))
+
+	var buf bytes.Buffer
+	require.NoError(t, utils.WriteXLSX(&buf, "Sheet", rows))
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	var sheet string
+	for _, f := range zr.File {
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			rc, _ := f.Open()
+			raw, _ := io.ReadAll(rc)
+			sheet = string(raw)
+		}
+	}
+	// Only plain decimals become numeric cells
+	assert.Contains(t, sheet, "<v>-2.5</v>")
+	assert.Contains(t, sheet, "<v>78</v>")
+	assert.NotContains(t, sheet, "<v>NaN</v>")
+	assert.NotContains(t, sheet, "<v>1e400</v>")
+	assert.NotContains(t, sheet, "<v>+1</v>")
+}
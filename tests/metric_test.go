@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -9,6 +10,7 @@ import (
 	"Wrk_Api/internal/database"
 	"Wrk_Api/internal/models"
 	"Wrk_Api/internal/routes"
+	"Wrk_Api/internal/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -47,3 +49,108 @@ func TestMetricHandlers(t *testing.T) {
 		assert.Equal(t, http.StatusOK, w.Code)
 	})
 }
+
+func TestFlowMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	SetupTestDB()
+	r := gin.Default()
+	routes.SetupRoutes(r)
+
+	user := models.User{ID: "u1", Name: "User", Email: "flow@metrics.com"}
+	database.DB.Create(&user)
+	project := models.Project{ID: "p1", Name: "Flow Project", OwnerID: user.ID}
+	database.DB.Create(&project)
+
+	day := func(d, hour int) time.Time {
+		return time.Date(2026, 1, 5+d, hour, 0, 0, 0, time.UTC)
+	}
+	sprint := models.Sprint{ID: "s1", Name: "Sprint 1", ProjectID: project.ID, StartDate: day(0, 0), EndDate: day(4, 0)}
+	database.DB.Create(&sprint)
+
+	// done: TODO -> IN_PROGRESS (day 1) -> DONE (day 2); doing: TODO -> IN_PROGRESS (day 1)
+	done := models.Task{ID: "t-done", Title: "Done", ProjectID: project.ID, SprintID: &sprint.ID, Status: "DONE", CreatedAt: day(0, 9)}
+	doing := models.Task{ID: "t-doing", Title: "Doing", ProjectID: project.ID, SprintID: &sprint.ID, Status: "IN_PROGRESS", CreatedAt: day(0, 9)}
+	database.DB.Create(&done)
+	database.DB.Create(&doing)
+
+	transition := func(taskID, from, to string, at time.Time) {
+		entry := models.HistoryEntry{ID: utils.GenerateCUID(), EntityType: models.EntityTask, EntityID: taskID, ProjectID: project.ID, Field: "status", NewValue: &to, CreatedAt: at}
+		if from != "" {
+			entry.OldValue = &from
+		}
+		database.DB.Create(&entry)
+	}
+	transition(done.ID, "", "TODO", day(0, 9))
+	transition(done.ID, "TODO", "IN_PROGRESS", day(1, 9))
+	transition(done.ID, "IN_PROGRESS", "DONE", day(2, 9))
+	transition(doing.ID, "", "TODO", day(0, 9))
+	transition(doing.ID, "TODO", "IN_PROGRESS", day(1, 9))
+
+	authHeader := "Bearer " + generateTestToken(user.ID, user.Email, user.Role)
+
+	get := func(path string) map[string]interface{} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", authHeader)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp
+	}
+
+	t.Run("CycleTime", func(t *testing.T) {
+		data := get("/api/metrics/sprints/s1/cycle-time")["data"].(map[string]interface{})
+		summary := data["summary"].(map[string]interface{})
+		assert.Equal(t, float64(1), summary["count"])
+		assert.Equal(t, float64(24), summary["averageHours"])
+	})
+
+	t.Run("LeadTime", func(t *testing.T) {
+		data := get("/api/metrics/projects/p1/lead-time?from=2026-01-05&to=2026-01-09")["data"].(map[string]interface{})
+		summary := data["summary"].(map[string]interface{})
+		assert.Equal(t, float64(48), summary["averageHours"])
+	})
+
+	t.Run("Throughput", func(t *testing.T) {
+		series := get("/api/metrics/sprints/s1/throughput")["data"].([]interface{})
+		first := series[0].(map[string]interface{})
+		assert.Equal(t, "2026-W02", first["week"])
+		assert.Equal(t, float64(1), first["count"])
+	})
+
+	t.Run("CumulativeFlow", func(t *testing.T) {
+		data := get("/api/metrics/sprints/s1/cumulative-flow")["data"].(map[string]interface{})
+		assert.Equal(t, []interface{}{"TODO", "IN_PROGRESS", "DONE"}, data["statuses"])
+
+		series := data["series"].([]interface{})
+		assert.Len(t, series, 5)
+		countsOn := func(i int) map[string]interface{} {
+			return series[i].(map[string]interface{})["counts"].(map[string]interface{})
+		}
+		assert.Equal(t, float64(2), countsOn(0)["TODO"])
+		assert.Equal(t, float64(2), countsOn(1)["IN_PROGRESS"])
+		assert.Equal(t, float64(1), countsOn(2)["IN_PROGRESS"])
+		assert.Equal(t, float64(1), countsOn(2)["DONE"])
+	})
+
+	t.Run("ToOnly", func(t *testing.T) {
+		series := get("/api/metrics/projects/p1/cumulative-flow?to=2026-01-07")["data"].(map[string]interface{})["series"].([]interface{})
+		assert.Len(t, series, 3)
+
+		// Before the first task the range shrinks to that day instead of failing
+		series = get("/api/metrics/projects/p1/cumulative-flow?to=2026-01-01")["data"].(map[string]interface{})["series"].([]interface{})
+		assert.Len(t, series, 1)
+	})
+
+	t.Run("InvalidRange", func(t *testing.T) {
+		for _, query := range []string{"?from=yesterday", "?to=2026-13-01", "?from=2026-01-09&to=2026-01-05", "?from=2000-01-01&to=2026-01-01"} {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/api/metrics/projects/p1/cumulative-flow"+query, nil)
+			req.Header.Set("Authorization", authHeader)
+			r.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+}
@@ -0,0 +1,264 @@
+package tests
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"testing"
+
+	"Wrk_Api/internal/database"
+	"Wrk_Api/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+func TestMigrations(t *testing.T) {
+	// A database of its own, so the shared test schema is left alone
+	open := func(t *testing.T, name string) *gorm.DB {
+		db, err := gorm.Open(sqlite.Open("file:"+name+"?mode=memory&cache=shared"), database.GormConfig())
+		require.NoError(t, err)
+		sqlDB, _ := db.DB()
+		t.Cleanup(func() { sqlDB.Close() })
+		return db
+	}
+
+	t.Run("VersionsAscend", func(t *testing.T) {
+		require.NotEmpty(t, database.Migrations)
+		for i := 1; i < len(database.Migrations); i++ {
+			assert.Less(t, database.Migrations[i-1].Version, database.Migrations[i].Version)
+		}
+		for _, m := range database.Migrations {
+			assert.NotNil(t, m.Up, m.String())
+			assert.NotNil(t, m.Down, m.String())
+		}
+	})
+
+	t.Run("UpAndDown", func(t *testing.T) {
+		db := open(t, "migrations-updown")
+
+		pending, err := database.Pending(db)
+		require.NoError(t, err)
+		assert.Len(t, pending, len(database.Migrations))
+
+		applied, err := database.Migrate(db)
+		require.NoError(t, err)
+		assert.Len(t, applied, len(database.Migrations))
+		assert.True(t, db.Migrator().HasTable(&models.Task{}))
+		assert.True(t, db.Migrator().HasColumn(&models.UserStory{}, "Rank"))
+
+		applied, err = database.Migrate(db)
+		require.NoError(t, err)
+		assert.Empty(t, applied, "applied migrations do not run again")
+
+		states, err := database.Status(db)
+		require.NoError(t, err)
+		for _, state := range states {
+			assert.NotNil(t, state.AppliedAt, state.String())
+		}
+
+		reverted, err := database.Rollback(db, len(database.Migrations), false)
+		require.NoError(t, err)
+		assert.Len(t, reverted, len(database.Migrations))
+		assert.Equal(t, database.Migrations[len(database.Migrations)-1].Version, reverted[0].Version, "newest first")
+		assert.False(t, db.Migrator().HasTable(&models.Task{}))
+		pending, _ = database.Pending(db)
+		assert.Len(t, pending, len(database.Migrations))
+
+		reverted, err = database.Rollback(db, 1, false)
+		require.NoError(t, err)
+		assert.Empty(t, reverted)
+	})
+
+	t.Run("AdoptsAutoMigratedDatabase", func(t *testing.T) {
+		db := open(t, "migrations-legacy")
+		// Databases from before versioned migrations have the tables but no history
+		require.NoError(t, db.AutoMigrate(&models.User{}, &models.Project{}))
+		require.NoError(t, db.Create(&models.User{ID: "mg-u", Name: "Legacy", Email: "legacy@mg.com", Role: "ADMIN"}).Error)
+
+		_, err := database.Migrate(db)
+		require.NoError(t, err)
+		var count int64
+		db.Model(&models.User{}).Where("id = ?", "mg-u").Count(&count)
+		assert.Equal(t, int64(1), count, "existing rows survive")
+		assert.True(t, db.Migrator().HasTable(&models.Sprint{}))
+
+		// Reverting 0001 would drop tables it didn't create
+		reverted, err := database.Rollback(db, len(database.Migrations), false)
+		assert.ErrorContains(t, err, "--force")
+		assert.Len(t, reverted, len(database.Migrations)-1)
+		db.Model(&models.User{}).Where("id = ?", "mg-u").Count(&count)
+		assert.Equal(t, int64(1), count)
+
+		reverted, err = database.Rollback(db, 1, true)
+		require.NoError(t, err)
+		assert.Len(t, reverted, 1)
+		assert.False(t, db.Migrator().HasTable(&models.User{}))
+	})
+
+	t.Run("UnknownAppliedVersion", func(t *testing.T) {
+		db := open(t, "migrations-unknown")
+		_, err := database.Migrate(db)
+		require.NoError(t, err)
+		db.Create(&database.SchemaMigration{Version: "9999", Name: "from_a_newer_build"})
+
+		_, err = database.Rollback(db, 1, false)
+		assert.ErrorContains(t, err, "unknown to this build")
+	})
+
+	t.Run("ModelsMatchSchema", func(t *testing.T) {
+		// A model change without a migration leaves the schema behind
+		db := open(t, "migrations-drift")
+		_, err := database.Migrate(db)
+		require.NoError(t, err)
+		for _, model := range allModels() {
+			stmt := &gorm.Statement{DB: db}
+			require.NoError(t, stmt.Parse(model))
+			for _, field := range stmt.Schema.Fields {
+				if field.DBName != "" {
+					assert.True(t, db.Migrator().HasColumn(model, field.DBName), "%s.%s", stmt.Schema.Table, field.DBName)
+				}
+			}
+			for _, index := range stmt.Schema.ParseIndexes() {
+				assert.True(t, db.Migrator().HasIndex(model, index.Name), "%s.%s", stmt.Schema.Table, index.Name)
+			}
+		}
+	})
+
+	t.Run("MySQLKeyColumns", func(t *testing.T) {
+		// MySQL can't index TEXT columns, so every key needs a bounded VARCHAR
+		// that fits the 3072 byte InnoDB key limit in utf8mb4
+		dialector, err := database.Dialector(database.DriverMySQL, "wrk:secret@tcp(localhost:3306)/wrk")
+		require.NoError(t, err)
+		cache := &sync.Map{}
+		for _, model := range allModels() {
+			sch, err := schema.Parse(model, cache, schema.NamingStrategy{})
+			require.NoError(t, err)
+			keys := sch.ParseIndexes()
+			if sch.PrioritizedPrimaryField != nil {
+				keys = append(keys, &schema.Index{Name: "PRIMARY", Fields: []schema.IndexOption{{Field: sch.PrioritizedPrimaryField}}})
+			}
+			for _, index := range keys {
+				bytes := 0
+				for _, option := range index.Fields {
+					if option.DataType != schema.String {
+						continue
+					}
+					dataType := dialector.DataTypeOf(option.Field)
+					var size int
+					_, err := fmt.Sscanf(dataType, "varchar(%d)", &size)
+					assert.NoError(t, err, "%s.%s is %s", sch.Table, option.DBName, dataType)
+					bytes += size * 4
+				}
+				assert.LessOrEqual(t, bytes, 3072, "%s.%s", sch.Table, index.Name)
+			}
+		}
+	})
+
+	t.Run("ForeignKeys", func(t *testing.T) {
+		recorder := &sqlRecorder{Interface: logger.Discard}
+		db, err := gorm.Open(postgres.Open("host=localhost dbname=wrk"), &gorm.Config{DryRun: true, DisableAutomaticPing: true, Logger: recorder})
+		require.NoError(t, err)
+		var up func(*gorm.DB) error
+		for _, migration := range database.Migrations {
+			if migration.Name == "foreign_keys" {
+				up = migration.Up
+			}
+		}
+		require.NotNil(t, up)
+		require.NoError(t, up(db))
+		assert.Contains(t, recorder.statements, "ALTER TABLE tasks ADD CONSTRAINT fk_tasks_project_id FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE")
+		assert.Contains(t, recorder.statements, "ALTER TABLE tasks ADD CONSTRAINT fk_tasks_sprint_id FOREIGN KEY (sprint_id) REFERENCES sprints (id) ON DELETE SET NULL")
+		assert.Contains(t, recorder.statements, "ALTER TABLE projects ADD CONSTRAINT fk_projects_owner_id FOREIGN KEY (owner_id) REFERENCES users (id)")
+
+		// Every constraint joins columns of the same type, and the polymorphic
+		// and history columns are left without one
+		tables := map[string]*schema.Schema{}
+		cache := &sync.Map{}
+		for _, model := range allModels() {
+			sch, err := schema.Parse(model, cache, schema.NamingStrategy{})
+			require.NoError(t, err)
+			tables[sch.Table] = sch
+		}
+		constraint := regexp.MustCompile(`^ALTER TABLE (\w+) ADD CONSTRAINT \w+ FOREIGN KEY \((\w+)\) REFERENCES (\w+) \(id\)`)
+		added := map[string]bool{}
+		for _, statement := range recorder.statements {
+			match := constraint.FindStringSubmatch(statement)
+			if match == nil {
+				continue
+			}
+			table, parent := tables[match[1]], tables[match[3]]
+			require.NotNil(t, table, statement)
+			require.NotNil(t, parent, statement)
+			column := table.LookUpField(match[2])
+			require.NotNil(t, column, statement)
+			assert.Equal(t, db.Dialector.DataTypeOf(parent.LookUpField("id")), db.Dialector.DataTypeOf(column), statement)
+			added[match[1]+"."+match[2]] = true
+		}
+		assert.NotEmpty(t, added)
+		for _, column := range []string{
+			"comments.entity_id", "history_entries.entity_id", "retro_votes.target_id", "dependencies.blocker_id",
+			"sprint_scope_changes.user_story_id", "evaluation_criteria.criteria_id", "peer_review_scores.criteria_id",
+		} {
+			assert.False(t, added[column], column)
+		}
+	})
+
+	t.Run("Drivers", func(t *testing.T) {
+		_, err := database.Dialector("oracle", "")
+		assert.Error(t, err)
+		dialector, err := database.Dialector(database.DriverMySQL, "wrk:secret@tcp(localhost:3306)/wrk")
+		require.NoError(t, err)
+		assert.Equal(t, "mysql", dialector.Name())
+		dialector, err = database.Dialector(database.DriverPostgres, "host=localhost dbname=wrk")
+		require.NoError(t, err)
+		assert.Equal(t, "postgres", dialector.Name())
+
+		t.Setenv("DB_DRIVER", "postgres")
+		t.Setenv("DB_DSN", "")
+		_, err = database.Open()
+		assert.ErrorContains(t, err, "DB_DSN")
+	})
+}
+
+// allModels are the tables the migrations build.
+func allModels() []interface{} {
+	return []interface{}{
+		&models.User{},
+		&models.Session{},
+		&models.Project{},
+		&models.ProjectMember{},
+		&models.Sprint{},
+		&models.SprintReport{},
+		&models.SprintScopeChange{},
+		&models.Epic{},
+		&models.UserStory{},
+		&models.Task{},
+		&models.Dependency{},
+		&models.Worklog{},
+		&models.Comment{},
+		&models.BoardColumn{},
+		&models.Rubric{},
+		&models.Criteria{},
+		&models.Evaluation{},
+		&models.EvaluationCriteria{},
+		&models.Chat{},
+		&models.ChatParticipant{},
+		&models.Message{},
+		&models.Notification{},
+		&models.RetroSession{},
+		&models.RetroGroup{},
+		&models.RetrospectiveItem{},
+		&models.RetroVote{},
+		&models.Document{},
+		&models.HistoryEntry{},
+		&models.PeerReviewRound{},
+		&models.PeerReview{},
+		&models.PeerReviewScore{},
+	}
+}
@@ -1,8 +1,14 @@
 package tests
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,6 +19,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNotificationHandlers(t *testing.T) {
@@ -62,3 +69,107 @@ func TestNotificationHandlers(t *testing.T) {
 		assert.True(t, n.Read)
 	})
 }
+
+func TestNotificationStream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	SetupTestDB()
+	r := gin.Default()
+	routes.SetupRoutes(r)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	owner := models.User{ID: "owner", Name: "Owner", Email: "owner@sse.com"}
+	dev := models.User{ID: "dev", Name: "Dev", Email: "dev@sse.com"}
+	database.DB.Create(&owner)
+	database.DB.Create(&dev)
+	project := models.Project{ID: "p1", Name: "SSE Project", OwnerID: owner.ID}
+	database.DB.Create(&project)
+
+	ownerToken := generateTestToken(owner.ID, owner.Email, owner.Role)
+	devToken := generateTestToken(dev.ID, dev.Email, dev.Role)
+
+	createTask := func(title string) {
+		body, _ := json.Marshal(map[string]string{"title": title, "projectId": project.ID, "assigneeId": dev.ID})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/tasks/", bytes.NewBuffer(body))
+		req.Header.Set("Authorization", "Bearer "+ownerToken)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusCreated, w.Code)
+	}
+
+	// readEvent returns the id and data of the next SSE event
+	readEvent := func(reader *bufio.Reader) (string, string) {
+		var id, data string
+		for {
+			line, err := reader.ReadString('\n')
+			require.NoError(t, err)
+			line = strings.TrimRight(line, "\n")
+			switch {
+			case strings.HasPrefix(line, "id:"):
+				id = strings.TrimSpace(strings.TrimPrefix(line, "id:"))
+			case strings.HasPrefix(line, "data:"):
+				data = strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			case line == "" && data != "":
+				return id, data
+			}
+		}
+	}
+
+	connect := func(lastEventID string) (*bufio.Reader, context.CancelFunc) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		req, _ := http.NewRequestWithContext(ctx, "GET", server.URL+"/api/notifications/stream?token="+devToken, nil)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, resp.Header.Get("Content-Type"), "text/event-stream")
+		return bufio.NewReader(resp.Body), cancel
+	}
+
+	var firstID string
+
+	t.Run("StreamsNewNotifications", func(t *testing.T) {
+		reader, cancel := connect("")
+		defer cancel()
+		time.Sleep(50 * time.Millisecond)
+
+		createTask("Live Task")
+		id, data := readEvent(reader)
+		assert.NotEmpty(t, id)
+		assert.Contains(t, data, "Live Task")
+		firstID = id
+	})
+
+	t.Run("ResumesFromLastEventID", func(t *testing.T) {
+		// Created while the client is disconnected
+		time.Sleep(5 * time.Millisecond)
+		createTask("Missed Task")
+
+		reader, cancel := connect(firstID)
+		defer cancel()
+
+		id, data := readEvent(reader)
+		assert.NotEqual(t, firstID, id)
+		assert.Contains(t, data, "Missed Task")
+	})
+
+	t.Run("DeactivationEndsStream", func(t *testing.T) {
+		reader, cancel := connect("")
+		defer cancel()
+		time.Sleep(50 * time.Millisecond)
+
+		admin := models.User{ID: "admin", Name: "Admin", Email: "admin@sse.com", Role: "ADMIN"}
+		database.DB.Create(&admin)
+		body, _ := json.Marshal(map[string]bool{"active": false})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/api/users/"+dev.ID, bytes.NewBuffer(body))
+		req.Header.Set("Authorization", "Bearer "+generateTestToken(admin.ID, admin.Email, admin.Role))
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		_, err := io.ReadAll(reader)
+		assert.NoError(t, err, "the server ends the stream before the client times out")
+	})
+}
@@ -0,0 +1,169 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"Wrk_Api/internal/database"
+	"Wrk_Api/internal/models"
+	"Wrk_Api/internal/routes"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListPagination(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	SetupTestDB()
+	r := gin.Default()
+	routes.SetupRoutes(r)
+
+	owner := models.User{ID: "pg-owner", Name: "Owner", Email: "owner@pg.com", Role: "SCRUM_MASTER"}
+	database.DB.Create(&owner)
+	project := models.Project{ID: "pg-p", Name: "Paged Project", OwnerID: owner.ID}
+	database.DB.Create(&project)
+	sprint := models.Sprint{ID: "pg-s", ProjectID: project.ID, Name: "Sprint", StartDate: time.Now(), EndDate: time.Now().AddDate(0, 0, 14)}
+	database.DB.Create(&sprint)
+
+	// 7 tasks: priorities cycle LOW, MEDIUM, HIGH; the first three go in the sprint
+	base := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	priorities := []string{"LOW", "MEDIUM", "HIGH"}
+	for i := 0; i < 7; i++ {
+		task := models.Task{
+			ID:        fmt.Sprintf("pg-t%d", i),
+			ProjectID: project.ID,
+			Title:     fmt.Sprintf("Task %d", i),
+			Priority:  priorities[i%3],
+			Status:    "TODO",
+			CreatedAt: base.AddDate(0, 0, i),
+		}
+		if i < 3 {
+			task.SprintID = &sprint.ID
+		}
+		if i == 6 {
+			task.AssigneeID = &owner.ID
+		}
+		database.DB.Create(&task)
+	}
+
+	token := generateTestToken(owner.ID, owner.Email, owner.Role)
+	get := func(path string, params url.Values) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path+"?"+params.Encode(), nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, req)
+		return w
+	}
+	type page struct {
+		Data       []map[string]interface{} `json:"data"`
+		NextCursor *string                  `json:"nextCursor"`
+		Total      int64                    `json:"total"`
+	}
+	read := func(w *httptest.ResponseRecorder) page {
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var p page
+		json.Unmarshal(w.Body.Bytes(), &p)
+		return p
+	}
+	// walk follows nextCursor to the end and returns every ID in order
+	walk := func(path string, params url.Values) []string {
+		ids := []string{}
+		for pages := 0; pages < 10; pages++ {
+			p := read(get(path, params))
+			for _, item := range p.Data {
+				ids = append(ids, item["ID"].(string))
+			}
+			if p.NextCursor == nil {
+				return ids
+			}
+			params.Set("cursor", *p.NextCursor)
+		}
+		t.Fatal("pagination did not end")
+		return nil
+	}
+
+	t.Run("CursorWalk", func(t *testing.T) {
+		p := read(get("/api/tasks/", url.Values{"projectId": {project.ID}, "limit": {"3"}}))
+		assert.Len(t, p.Data, 3)
+		assert.Equal(t, int64(7), p.Total)
+		require.NotNil(t, p.NextCursor)
+
+		ids := walk("/api/tasks/", url.Values{"projectId": {project.ID}, "limit": {"3"}})
+		assert.Equal(t, []string{"pg-t0", "pg-t1", "pg-t2", "pg-t3", "pg-t4", "pg-t5", "pg-t6"}, ids)
+	})
+
+	t.Run("MultiFieldSort", func(t *testing.T) {
+		ids := walk("/api/tasks/", url.Values{"projectId": {project.ID}, "sort": {"-priority,-createdAt"}, "limit": {"2"}})
+		assert.Equal(t, []string{"pg-t5", "pg-t2", "pg-t4", "pg-t1", "pg-t6", "pg-t3", "pg-t0"}, ids,
+			"HIGH before MEDIUM before LOW, newest first within each, across page boundaries")
+
+		w := get("/api/tasks/", url.Values{"sort": {"password"}})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Filters", func(t *testing.T) {
+		p := read(get("/api/tasks/", url.Values{"projectId": {project.ID}, "priority": {"HIGH,LOW"}}))
+		assert.Equal(t, int64(5), p.Total)
+
+		p = read(get("/api/tasks/", url.Values{"projectId": {project.ID}, "sprintId": {sprint.ID}}))
+		assert.Equal(t, int64(3), p.Total)
+		p = read(get("/api/tasks/", url.Values{"projectId": {project.ID}, "sprintId": {"null"}}))
+		assert.Equal(t, int64(4), p.Total, "null matches unscheduled tasks")
+
+		p = read(get("/api/tasks/", url.Values{"assigneeId": {owner.ID}}))
+		assert.Equal(t, int64(1), p.Total)
+
+		p = read(get("/api/tasks/", url.Values{"projectId": {project.ID}, "createdFrom": {"2026-03-02"}, "createdTo": {"2026-03-04"}}))
+		assert.Equal(t, int64(3), p.Total, "a bare day includes the whole day")
+
+		w := get("/api/tasks/", url.Values{"createdFrom": {"yesterday"}})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = get("/api/tasks/", url.Values{"limit": {"0"}})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = get("/api/tasks/", url.Values{"cursor": {"bm9wZQ"}})
+		assert.Equal(t, http.StatusBadRequest, w.Code, "cursor of a row that does not exist")
+	})
+
+	t.Run("SprintIncludes", func(t *testing.T) {
+		p := read(get("/api/sprints/", url.Values{"projectId": {project.ID}}))
+		require.Len(t, p.Data, 1)
+		assert.Empty(t, p.Data[0]["Tasks"], "collections are not loaded by default")
+
+		p = read(get("/api/sprints/", url.Values{"projectId": {project.ID}, "include": {"tasks"}}))
+		assert.Len(t, p.Data[0]["Tasks"], 3)
+	})
+
+	t.Run("OtherLists", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			database.DB.Create(&models.User{ID: fmt.Sprintf("pg-u%d", i), Name: fmt.Sprintf("User %d", i), Email: fmt.Sprintf("u%d@pg.com", i), Role: "TEAM_DEVELOPER"})
+		}
+		p := read(get("/api/users", url.Values{"role": {"TEAM_DEVELOPER"}, "sort": {"-name"}, "limit": {"2"}}))
+		assert.Equal(t, int64(3), p.Total)
+		require.Len(t, p.Data, 2)
+		assert.Equal(t, "User 2", p.Data[0]["name"])
+		assert.NotNil(t, p.NextCursor)
+
+		chat := models.Chat{ID: "pg-chat", ProjectID: &project.ID, Type: "PROJECT"}
+		database.DB.Create(&chat)
+		for i := 0; i < 4; i++ {
+			database.DB.Create(&models.Message{ID: fmt.Sprintf("pg-msg%d", i), ChatID: chat.ID, UserID: owner.ID, Content: fmt.Sprintf("m%d", i), CreatedAt: base.Add(time.Duration(i) * time.Minute)})
+		}
+		p = read(get("/api/chat/"+project.ID+"/messages", url.Values{"limit": {"3"}}))
+		assert.Equal(t, int64(4), p.Total)
+		assert.Equal(t, "m3", p.Data[0]["Content"], "latest first when scrolling back")
+		ids := walk("/api/chat/"+project.ID+"/messages", url.Values{"limit": {"3"}})
+		assert.Equal(t, []string{"pg-msg3", "pg-msg2", "pg-msg1", "pg-msg0"}, ids)
+		ids = walk("/api/chat/"+project.ID+"/messages", url.Values{"sort": {"createdAt"}, "limit": {"3"}})
+		assert.Equal(t, []string{"pg-msg0", "pg-msg1", "pg-msg2", "pg-msg3"}, ids)
+
+		p = read(get("/api/documents/"+project.ID, nil))
+		assert.Equal(t, int64(0), p.Total)
+		assert.NotNil(t, p.Data)
+	})
+}
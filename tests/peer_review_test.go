@@ -0,0 +1,202 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Wrk_Api/internal/database"
+	"Wrk_Api/internal/models"
+	"Wrk_Api/internal/routes"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeerReviews(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	SetupTestDB()
+	r := gin.Default()
+	routes.SetupRoutes(r)
+
+	teacher := models.User{ID: "teacher", Name: "Teacher", Email: "teacher@peer.com"}
+	ana := models.User{ID: "ana", Name: "Ana", Email: "ana@peer.com"}
+	luis := models.User{ID: "luis", Name: "Luis", Email: "luis@peer.com"}
+	outsider := models.User{ID: "outsider", Name: "Outsider", Email: "outsider@peer.com"}
+	for _, u := range []*models.User{&teacher, &ana, &luis, &outsider} {
+		database.DB.Create(u)
+	}
+	project := models.Project{ID: "p1", Name: "Peer Project", OwnerID: teacher.ID}
+	database.DB.Create(&project)
+	// The teacher owns the project as its evaluator, so it only grades
+	database.DB.Create(&models.ProjectMember{ID: "m0", ProjectID: project.ID, UserID: teacher.ID, Role: "EVALUATOR"})
+	database.DB.Create(&models.ProjectMember{ID: "m1", ProjectID: project.ID, UserID: ana.ID, Role: "TEAM_DEVELOPER"})
+	database.DB.Create(&models.ProjectMember{ID: "m2", ProjectID: project.ID, UserID: luis.ID, Role: "SCRUM_MASTER"})
+	sprint := models.Sprint{ID: "s1", ProjectID: project.ID, Name: "Sprint 1"}
+	database.DB.Create(&sprint)
+
+	rubric := models.Rubric{ID: "rub1", ProjectID: &project.ID, Name: "Teamwork"}
+	database.DB.Create(&rubric)
+	collab := models.Criteria{ID: "collab", RubricID: rubric.ID, Name: "Collaboration", MaxScore: 10, Weight: 1}
+	database.DB.Create(&collab)
+
+	header := func(u models.User) string {
+		return "Bearer " + generateTestToken(u.ID, u.Email, u.Role)
+	}
+	send := func(method, path, auth string, body interface{}) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		if body != nil {
+			json.NewEncoder(&buf).Encode(body)
+		}
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, &buf)
+		req.Header.Set("Authorization", auth)
+		r.ServeHTTP(w, req)
+		return w
+	}
+	var roundID string
+	review := func(reviewer models.User, revieweeID string, score int, comment string) int {
+		return send("POST", "/api/peer-reviews/"+roundID+"/reviews", header(reviewer), map[string]interface{}{
+			"revieweeId":     revieweeID,
+			"criteriaScores": []map[string]interface{}{{"criteriaId": collab.ID, "score": score}},
+			"comment":        comment,
+		}).Code
+	}
+
+	t.Run("CreateRound", func(t *testing.T) {
+		w := send("POST", "/api/peer-reviews/", header(ana), map[string]interface{}{
+			"projectId": project.ID, "sprintId": sprint.ID, "rubricId": rubric.ID, "title": "Sprint 1 review",
+		})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = send("POST", "/api/peer-reviews/", header(teacher), map[string]interface{}{
+			"projectId": project.ID, "sprintId": sprint.ID, "rubricId": rubric.ID, "title": "Sprint 1 review", "anonymous": true,
+		})
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		var resp map[string]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		roundID = resp["data"]["ID"].(string)
+
+		var count int64
+		database.DB.Model(&models.Notification{}).Where("type = ? AND user_id IN ?", "PEER_REVIEW", []string{ana.ID, luis.ID}).Count(&count)
+		assert.Equal(t, int64(2), count)
+	})
+
+	t.Run("SubmitReviews", func(t *testing.T) {
+		assert.Equal(t, http.StatusCreated, review(ana, luis.ID, 8, "Buen líder"))
+		assert.Equal(t, http.StatusCreated, review(ana, ana.ID, 9, ""))
+		assert.Equal(t, http.StatusCreated, review(luis, ana.ID, 6, "Debe comunicar más"))
+
+		// Resubmitting replaces the previous review
+		assert.Equal(t, http.StatusCreated, review(luis, ana.ID, 7, "Debe comunicar más"))
+		var count int64
+		database.DB.Model(&models.PeerReview{}).Where("reviewer_id = ? AND reviewee_id = ?", luis.ID, ana.ID).Count(&count)
+		assert.Equal(t, int64(1), count)
+
+		assert.Equal(t, http.StatusBadRequest, review(ana, outsider.ID, 5, ""))
+		assert.Equal(t, http.StatusBadRequest, review(ana, luis.ID, 11, ""))
+		assert.Equal(t, http.StatusForbidden, review(outsider, ana.ID, 5, ""))
+	})
+
+	t.Run("Pending", func(t *testing.T) {
+		w := send("GET", "/api/peer-reviews/"+roundID, header(luis), nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp struct {
+			Data struct {
+				Pending []map[string]interface{} `json:"pending"`
+			} `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		require.Len(t, resp.Data.Pending, 1)
+		assert.Equal(t, luis.ID, resp.Data.Pending[0]["id"])
+	})
+
+	t.Run("Progress", func(t *testing.T) {
+		w := send("GET", "/api/peer-reviews/"+roundID+"/progress", header(teacher), nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp map[string]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.Equal(t, float64(1), resp["data"]["completed"])
+		assert.Equal(t, float64(2), resp["data"]["total"])
+
+		w = send("GET", "/api/peer-reviews/"+roundID+"/progress", header(ana), nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("StudentResultsHiddenUntilClosed", func(t *testing.T) {
+		w := send("GET", "/api/peer-reviews/"+roundID+"/results", header(ana), nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = send("POST", "/api/peer-reviews/"+roundID+"/close", header(teacher), nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.StatusConflict, review(luis, luis.ID, 10, ""))
+	})
+
+	t.Run("AnonymousResults", func(t *testing.T) {
+		w := send("GET", "/api/peer-reviews/"+roundID+"/results", header(ana), nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp struct {
+			Data []map[string]interface{} `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		require.Len(t, resp.Data, 1)
+		result := resp.Data[0]
+		assert.Equal(t, float64(70), result["peerAverage"])
+		assert.Equal(t, float64(90), result["selfScore"])
+
+		comments := result["comments"].([]interface{})
+		require.Len(t, comments, 1)
+		assert.NotContains(t, comments[0], "reviewer")
+
+		// Instructors see who wrote each comment
+		w = send("GET", "/api/peer-reviews/"+roundID+"/results", header(teacher), nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"reviewer"`)
+	})
+
+	t.Run("CombinedWithStudentEvaluations", func(t *testing.T) {
+		w := send("GET", "/api/evaluations/student/"+ana.ID, header(teacher), nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp struct {
+			PeerReviews []map[string]interface{} `json:"peerReviews"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		require.Len(t, resp.PeerReviews, 1)
+		assert.Equal(t, float64(70), resp.PeerReviews[0]["peerAverage"])
+	})
+
+	t.Run("OwnerTakesPart", func(t *testing.T) {
+		// A student-owned project: the owner reviews and is reviewed like any member
+		own := models.Project{ID: "p2", Name: "Student Project", OwnerID: ana.ID}
+		database.DB.Create(&own)
+		database.DB.Create(&models.ProjectMember{ID: "m3", ProjectID: own.ID, UserID: luis.ID, Role: "TEAM_DEVELOPER"})
+		ownRubric := models.Rubric{ID: "rub2", ProjectID: &own.ID, Name: "Teamwork"}
+		database.DB.Create(&ownRubric)
+		database.DB.Create(&models.Criteria{ID: "collab2", RubricID: ownRubric.ID, Name: "Collaboration", MaxScore: 10, Weight: 1})
+
+		w := send("POST", "/api/peer-reviews/", header(ana), map[string]interface{}{"projectId": own.ID, "rubricId": ownRubric.ID, "title": "Team review"})
+		require.Equal(t, http.StatusCreated, w.Code)
+		var resp map[string]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		ownRound := resp["data"]["ID"].(string)
+
+		w = send("POST", "/api/peer-reviews/"+ownRound+"/reviews", header(luis), map[string]interface{}{
+			"revieweeId":     ana.ID,
+			"criteriaScores": []map[string]interface{}{{"criteriaId": "collab2", "score": 9}},
+		})
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		w = send("GET", "/api/peer-reviews/"+ownRound+"/progress", header(ana), nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.Equal(t, float64(2), resp["data"]["total"])
+	})
+}
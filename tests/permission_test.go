@@ -0,0 +1,131 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Wrk_Api/internal/database"
+	"Wrk_Api/internal/models"
+	"Wrk_Api/internal/routes"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjectPermissions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	SetupTestDB()
+	r := gin.Default()
+	routes.SetupRoutes(r)
+
+	owner := models.User{ID: "owner", Name: "Owner", Email: "owner@perm.com", Role: "TEAM_DEVELOPER"}
+	dev := models.User{ID: "dev", Name: "Dev", Email: "dev@perm.com", Role: "TEAM_DEVELOPER"}
+	evaluator := models.User{ID: "teacher", Name: "Teacher", Email: "teacher@perm.com", Role: "TEAM_DEVELOPER"}
+	outsider := models.User{ID: "outsider", Name: "Outsider", Email: "out@perm.com", Role: "TEAM_DEVELOPER"}
+	admin := models.User{ID: "admin", Name: "Admin", Email: "admin@perm.com", Role: "ADMIN"}
+	for _, u := range []*models.User{&owner, &dev, &evaluator, &outsider, &admin} {
+		database.DB.Create(u)
+	}
+
+	project := models.Project{ID: "p1", Name: "Perm Project", OwnerID: owner.ID}
+	database.DB.Create(&project)
+	database.DB.Create(&models.ProjectMember{ID: "m1", ProjectID: project.ID, UserID: dev.ID, Role: "TEAM_DEVELOPER"})
+	database.DB.Create(&models.ProjectMember{ID: "m2", ProjectID: project.ID, UserID: evaluator.ID, Role: "EVALUATOR"})
+
+	sprint := models.Sprint{ID: "s1", Name: "Sprint 1", ProjectID: project.ID}
+	database.DB.Create(&sprint)
+	task := models.Task{ID: "t1", Title: "Task", ProjectID: project.ID}
+	database.DB.Create(&task)
+
+	tokens := map[string]string{}
+	for _, u := range []models.User{owner, dev, evaluator, outsider, admin} {
+		tokens[u.ID] = "Bearer " + generateTestToken(u.ID, u.Email, u.Role)
+	}
+
+	do := func(method, path, userID string, body interface{}) int {
+		var buf *bytes.Buffer
+		if body != nil {
+			jsonBody, _ := json.Marshal(body)
+			buf = bytes.NewBuffer(jsonBody)
+		} else {
+			buf = bytes.NewBuffer(nil)
+		}
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, buf)
+		req.Header.Set("Authorization", tokens[userID])
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("MembersCanView", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, do("GET", "/api/projects/p1", dev.ID, nil))
+		assert.Equal(t, http.StatusForbidden, do("GET", "/api/projects/p1", outsider.ID, nil))
+		assert.Equal(t, http.StatusOK, do("GET", "/api/projects/p1", admin.ID, nil))
+	})
+
+	t.Run("DevelopersCanWriteTasks", func(t *testing.T) {
+		body := map[string]interface{}{"title": "Dev Task", "projectId": project.ID}
+		assert.Equal(t, http.StatusCreated, do("POST", "/api/tasks/", dev.ID, body))
+		assert.Equal(t, http.StatusForbidden, do("POST", "/api/tasks/", outsider.ID, body))
+		assert.Equal(t, http.StatusForbidden, do("POST", "/api/tasks/", evaluator.ID, body))
+	})
+
+	t.Run("ListsAreScopedToMembership", func(t *testing.T) {
+		other := models.Project{ID: "p2", Name: "Other Project", OwnerID: outsider.ID}
+		database.DB.Create(&other)
+		database.DB.Create(&models.Task{ID: "t-other", Title: "Other task", ProjectID: other.ID})
+		database.DB.Create(&models.Rubric{ID: "r-other", ProjectID: &other.ID, Name: "Other rubric"})
+		database.DB.Create(&models.Rubric{ID: "r-global", Name: "Global rubric"})
+
+		list := func(path, userID string) string {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", path, nil)
+			req.Header.Set("Authorization", tokens[userID])
+			r.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusOK, w.Code, path)
+			return w.Body.String()
+		}
+		for _, path := range []string{"/api/projects/", "/api/tasks/", "/api/rubrics/"} {
+			body := list(path, dev.ID)
+			assert.NotContains(t, body, "Other", path)
+			assert.Contains(t, list(path, admin.ID), "Other", path)
+		}
+		assert.Contains(t, list("/api/projects/", dev.ID), "Perm Project")
+		assert.Contains(t, list("/api/tasks/", dev.ID), `"t1"`)
+		assert.Contains(t, list("/api/rubrics/", dev.ID), "Global rubric")
+		assert.Contains(t, list("/api/projects/", outsider.ID), "Other Project")
+		assert.Equal(t, http.StatusNotFound, do("GET", "/api/rubrics/r-other", dev.ID, nil))
+	})
+
+	t.Run("OnlyManagersDeleteSprints", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, do("DELETE", "/api/sprints/s1", dev.ID, nil))
+		assert.Equal(t, http.StatusOK, do("DELETE", "/api/sprints/s1", owner.ID, nil))
+		assert.Equal(t, http.StatusNotFound, do("DELETE", "/api/sprints/missing", owner.ID, nil))
+	})
+
+	t.Run("OnlyEvaluatorsEvaluate", func(t *testing.T) {
+		body := map[string]interface{}{"score": 90, "maxScore": 100}
+		assert.Equal(t, http.StatusForbidden, do("POST", "/api/tasks/t1/evaluate", dev.ID, body))
+		assert.Equal(t, http.StatusCreated, do("POST", "/api/tasks/t1/evaluate", evaluator.ID, body))
+	})
+
+	t.Run("MemberRoleIsEnforced", func(t *testing.T) {
+		// Promote the developer to SCRUM_MASTER; the new role applies immediately
+		body := map[string]interface{}{"userId": dev.ID, "role": "SCRUM_MASTER"}
+		assert.Equal(t, http.StatusForbidden, do("POST", "/api/projects/p1/members", dev.ID, body))
+		assert.Equal(t, http.StatusOK, do("POST", "/api/projects/p1/members", owner.ID, body))
+
+		sprintBody := map[string]interface{}{"name": "Sprint 2", "projectId": project.ID}
+		assert.Equal(t, http.StatusCreated, do("POST", "/api/sprints/", dev.ID, sprintBody))
+	})
+
+	t.Run("OnlyAdminsChangeGlobalRoles", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, do("PUT", "/api/users/dev", dev.ID, map[string]interface{}{"role": "ADMIN"}))
+		assert.Equal(t, http.StatusForbidden, do("PUT", "/api/users/owner", dev.ID, map[string]interface{}{"name": "Hacked"}))
+		assert.Equal(t, http.StatusOK, do("PUT", "/api/users/dev", dev.ID, map[string]interface{}{"name": "Dev Renamed"}))
+		assert.Equal(t, http.StatusOK, do("PUT", "/api/users/dev", admin.ID, map[string]interface{}{"role": "SCRUM_MASTER"}))
+	})
+}
@@ -11,26 +11,25 @@ import (
 	"Wrk_Api/internal/database"
 	"Wrk_Api/internal/models"
 	"Wrk_Api/internal/routes"
+	"Wrk_Api/internal/utils"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 )
 
 // Helper to generate a valid JWT for tests
-// We need to match the secret used in AuthMiddleware
+// Tokens are bound to a server-side session, so one is created alongside
 func generateTestToken(userID, email, role string) string {
-	// Must match the fallback or env var in middleware/auth.go
-	secret := []byte("default_secret_key")
-	
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"userId": userID,
-		"email":  email,
-		"role":   role,
-		"exp":    time.Now().Add(time.Hour * 1).Unix(),
-	})
+	session := models.Session{
+		ID:               utils.GenerateCUID(),
+		UserID:           userID,
+		RefreshTokenHash: utils.HashToken(utils.GenerateRefreshToken()),
+		ExpiresAt:        time.Now().Add(time.Hour * 1),
+		LastUsedAt:       time.Now(),
+	}
+	database.DB.Create(&session)
 
-	tokenString, _ := token.SignedString(secret)
+	tokenString, _ := utils.GenerateAccessToken(userID, email, role, session.ID)
 	return tokenString
 }
 
@@ -115,6 +114,23 @@ func TestProjectHandlers(t *testing.T) {
 	})
 
 	t.Run("DeleteProject", func(t *testing.T) {
+		// Everything in the project goes with it
+		sprint := models.Sprint{ID: "del-sprint", ProjectID: createdProjectID, Name: "Sprint"}
+		story := models.UserStory{ID: "del-story", ProjectID: createdProjectID, Title: "Story", Description: "Desc", SprintID: &sprint.ID}
+		task := models.Task{ID: "del-task", ProjectID: createdProjectID, Title: "Task", SprintID: &sprint.ID}
+		chat := models.Chat{ID: "del-chat", ProjectID: &createdProjectID}
+		evaluation := models.Evaluation{ID: "del-eval", ProjectID: createdProjectID, TaskID: &task.ID, EvaluatorID: user.ID}
+		rows := []interface{}{
+			&sprint, &story, &task, &chat, &evaluation,
+			&models.Worklog{ID: "del-log", TaskID: task.ID, ProjectID: createdProjectID, UserID: user.ID, Minutes: 30},
+			&models.Message{ID: "del-msg", ChatID: chat.ID, UserID: user.ID, Content: "Hi"},
+			&models.EvaluationCriteria{ID: "del-score", EvaluationID: evaluation.ID, CriteriaID: "c1"},
+			&models.SprintReport{ID: "del-report", SprintID: sprint.ID},
+		}
+		for _, row := range rows {
+			assert.NoError(t, database.DB.Create(row).Error)
+		}
+
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("DELETE", "/api/projects/"+createdProjectID, nil)
 		req.Header.Set("Authorization", authHeader)
@@ -126,5 +142,9 @@ func TestProjectHandlers(t *testing.T) {
 		var count int64
 		database.DB.Model(&models.Project{}).Where("id = ?", createdProjectID).Count(&count)
 		assert.Equal(t, int64(0), count)
+		for _, row := range rows {
+			database.DB.Model(row).Where("id LIKE ?", "del-%").Count(&count)
+			assert.Zero(t, count, "%T", row)
+		}
 	})
 }
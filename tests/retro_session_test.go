@@ -0,0 +1,246 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"Wrk_Api/internal/database"
+	"Wrk_Api/internal/models"
+	"Wrk_Api/internal/routes"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetroSessions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	SetupTestDB()
+	r := gin.Default()
+	routes.SetupRoutes(r)
+
+	owner := models.User{ID: "rs-owner", Name: "Owner", Email: "owner@rs.com", Role: "SCRUM_MASTER"}
+	dev := models.User{ID: "rs-dev", Name: "Dev", Email: "dev@rs.com", Role: "TEAM_DEVELOPER"}
+	outsider := models.User{ID: "rs-out", Name: "Out", Email: "out@rs.com", Role: "TEAM_DEVELOPER"}
+	database.DB.Create(&owner)
+	database.DB.Create(&dev)
+	database.DB.Create(&outsider)
+	project := models.Project{ID: "rs-p", Name: "Retro Project", OwnerID: owner.ID}
+	database.DB.Create(&project)
+	database.DB.Create(&models.ProjectMember{ID: "rs-m", ProjectID: project.ID, UserID: dev.ID, Role: "TEAM_DEVELOPER"})
+	sprint := models.Sprint{ID: "rs-s", ProjectID: project.ID, Name: "Sprint 1", Status: "COMPLETED", StartDate: time.Now().AddDate(0, 0, -14), EndDate: time.Now()}
+	database.DB.Create(&sprint)
+
+	send := func(user models.User, method, url string, body interface{}) *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, url, bytes.NewBuffer(jsonBody))
+		req.Header.Set("Authorization", "Bearer "+generateTestToken(user.ID, user.Email, user.Role))
+		r.ServeHTTP(w, req)
+		return w
+	}
+	dataID := func(w *httptest.ResponseRecorder) string {
+		var resp struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		id, _ := resp.Data["ID"].(string)
+		return id
+	}
+	type boardView struct {
+		Groups []struct {
+			ID    string                   `json:"ID"`
+			Items []map[string]interface{} `json:"items"`
+			Votes *int                     `json:"votes"`
+		} `json:"groups"`
+		Items []struct {
+			ID    string `json:"ID"`
+			Votes *int   `json:"votes"`
+		} `json:"items"`
+		MyVotes        map[string]int `json:"myVotes"`
+		VotesRemaining int            `json:"votesRemaining"`
+	}
+	board := func(w *httptest.ResponseRecorder) boardView {
+		var resp struct {
+			Data boardView `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.Data
+	}
+	advance := func(sessionID string) {
+		w := send(owner, "POST", "/api/retro-sessions/"+sessionID+"/advance", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var sessionID, goodA, goodB, bad, action, groupID string
+
+	t.Run("CreateSession", func(t *testing.T) {
+		w := send(dev, "POST", "/api/retro-sessions/", map[string]interface{}{"sprintId": sprint.ID})
+		assert.Equal(t, http.StatusForbidden, w.Code, "developers cannot open a session")
+
+		w = send(owner, "POST", "/api/retro-sessions/", map[string]interface{}{"sprintId": sprint.ID, "voteBudget": 2})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		sessionID = dataID(w)
+
+		w = send(owner, "POST", "/api/retro-sessions/", map[string]interface{}{"sprintId": sprint.ID})
+		assert.Equal(t, http.StatusConflict, w.Code, "one session per sprint")
+
+		w = send(dev, "GET", "/api/retro-sessions/sprint/"+sprint.ID, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 2, board(w).VotesRemaining)
+	})
+
+	t.Run("CollectPhase", func(t *testing.T) {
+		item := func(kind, content string) string {
+			w := send(dev, "POST", "/api/retro-sessions/"+sessionID+"/items", map[string]string{"type": kind, "content": content})
+			require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+			return dataID(w)
+		}
+		goodA = item("GOOD", "Pairing")
+		goodB = item("GOOD", "Pair reviews")
+		bad = item("BAD", "Flaky CI")
+		action = item("ACTION", "Fix CI pipeline")
+
+		w := send(outsider, "POST", "/api/retro-sessions/"+sessionID+"/items", map[string]string{"type": "GOOD", "content": "x"})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = send(dev, "POST", "/api/retro-sessions/"+sessionID+"/groups", map[string]interface{}{"title": "Pairing"})
+		assert.Equal(t, http.StatusConflict, w.Code, "grouping waits for the GROUP phase")
+
+		// Items also show in the legacy sprint retrospective
+		w = send(dev, "GET", "/api/retrospectives/"+sprint.ID, nil)
+		assert.Contains(t, w.Body.String(), "Flaky CI")
+	})
+
+	t.Run("GroupPhase", func(t *testing.T) {
+		advance(sessionID)
+
+		w := send(dev, "POST", "/api/retro-sessions/"+sessionID+"/items", map[string]string{"type": "GOOD", "content": "late"})
+		assert.Equal(t, http.StatusConflict, w.Code, "collection is over")
+
+		w = send(dev, "POST", "/api/retro-sessions/"+sessionID+"/groups", map[string]interface{}{"title": "Pairing", "itemIds": []string{goodA}})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		groupID = dataID(w)
+
+		w = send(dev, "PUT", "/api/retro-sessions/"+sessionID+"/items/"+goodB+"/group", map[string]interface{}{"groupId": groupID})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		view := board(w)
+		require.Len(t, view.Groups, 1)
+		assert.Len(t, view.Groups[0].Items, 2)
+		assert.Len(t, view.Items, 2, "bad and action stay ungrouped")
+
+		w = send(dev, "POST", "/api/retro-sessions/"+sessionID+"/groups", map[string]interface{}{"title": "Bogus", "itemIds": []string{"missing"}})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("VotePhase", func(t *testing.T) {
+		advance(sessionID)
+
+		w := send(dev, "POST", "/api/retro-sessions/"+sessionID+"/votes", map[string]string{"targetId": goodA})
+		assert.Equal(t, http.StatusBadRequest, w.Code, "grouped items are voted through their group")
+
+		w = send(dev, "POST", "/api/retro-sessions/"+sessionID+"/votes", map[string]string{"targetId": bad})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		w = send(dev, "POST", "/api/retro-sessions/"+sessionID+"/votes", map[string]string{"targetId": bad})
+		require.Equal(t, http.StatusCreated, w.Code)
+		view := board(w)
+		assert.Equal(t, 0, view.VotesRemaining)
+		assert.Equal(t, 2, view.MyVotes[bad])
+		assert.Nil(t, view.Items[0].Votes, "totals stay hidden while voting")
+
+		w = send(dev, "POST", "/api/retro-sessions/"+sessionID+"/votes", map[string]string{"targetId": groupID})
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "VOTE_BUDGET_EXCEEDED")
+
+		w = send(dev, "DELETE", "/api/retro-sessions/"+sessionID+"/votes/"+bad, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 1, board(w).VotesRemaining)
+		w = send(dev, "POST", "/api/retro-sessions/"+sessionID+"/votes", map[string]string{"targetId": groupID})
+		require.Equal(t, http.StatusCreated, w.Code)
+		w = send(owner, "POST", "/api/retro-sessions/"+sessionID+"/votes", map[string]string{"targetId": groupID})
+		require.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("DiscussPhase", func(t *testing.T) {
+		w := send(owner, "POST", "/api/retro-sessions/"+sessionID+"/items/"+action+"/convert", map[string]string{"assigneeId": dev.ID})
+		assert.Equal(t, http.StatusConflict, w.Code, "actions are converted while discussing")
+
+		advance(sessionID)
+		w = send(dev, "GET", "/api/retro-sessions/"+sessionID, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		view := board(w)
+		require.NotNil(t, view.Groups[0].Votes)
+		assert.Equal(t, 2, *view.Groups[0].Votes)
+		require.NotNil(t, view.Items[0].Votes)
+		assert.Equal(t, bad, view.Items[0].ID, "most voted ungrouped item first")
+		assert.Equal(t, 1, *view.Items[0].Votes)
+
+		w = send(dev, "POST", "/api/retro-sessions/"+sessionID+"/items", map[string]string{"type": "ACTION", "content": "Write runbook"})
+		assert.Equal(t, http.StatusCreated, w.Code, "actions can still be added while discussing")
+	})
+
+	t.Run("ConvertAction", func(t *testing.T) {
+		w := send(owner, "POST", "/api/retro-sessions/"+sessionID+"/items/"+bad+"/convert", map[string]string{"assigneeId": dev.ID})
+		assert.Equal(t, http.StatusBadRequest, w.Code, "only ACTION items")
+
+		w = send(owner, "POST", "/api/retro-sessions/"+sessionID+"/items/"+action+"/convert", map[string]string{"assigneeId": outsider.ID})
+		assert.Equal(t, http.StatusBadRequest, w.Code, "assignee must be a member")
+
+		w = send(owner, "POST", "/api/retro-sessions/"+sessionID+"/items/"+action+"/convert", map[string]string{"assigneeId": dev.ID})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		taskID := dataID(w)
+
+		var task models.Task
+		require.NoError(t, database.DB.First(&task, "id = ?", taskID).Error)
+		assert.Equal(t, "Fix CI pipeline", task.Title)
+		assert.Equal(t, project.ID, task.ProjectID)
+		require.NotNil(t, task.RetroItemID)
+		assert.Equal(t, action, *task.RetroItemID)
+		require.NotNil(t, task.AssigneeID)
+		assert.Equal(t, dev.ID, *task.AssigneeID)
+
+		var notifications int64
+		database.DB.Model(&models.Notification{}).Where("user_id = ? AND type = ?", dev.ID, "TASK_ASSIGNED").Count(&notifications)
+		assert.Equal(t, int64(1), notifications)
+
+		w = send(owner, "POST", "/api/retro-sessions/"+sessionID+"/items/"+action+"/convert", map[string]string{"assigneeId": dev.ID})
+		assert.Equal(t, http.StatusConflict, w.Code, "already converted")
+
+		w = send(dev, "GET", "/api/retro-sessions/"+sessionID, nil)
+		assert.Contains(t, w.Body.String(), taskID, "the item links to its task")
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		advance(sessionID)
+		var session models.RetroSession
+		database.DB.First(&session, "id = ?", sessionID)
+		assert.Equal(t, models.RetroPhaseClosed, session.Phase)
+		assert.NotNil(t, session.ClosedAt)
+
+		w := send(owner, "POST", "/api/retro-sessions/"+sessionID+"/advance", nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("TemplateAndAnonymity", func(t *testing.T) {
+		next := models.Sprint{ID: "rs-s2", ProjectID: project.ID, Name: "Sprint 2", Status: "COMPLETED", StartDate: time.Now(), EndDate: time.Now()}
+		database.DB.Create(&next)
+		w := send(owner, "POST", "/api/retro-sessions/", map[string]interface{}{"sprintId": next.ID, "template": "MAD_SAD_GLAD"})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		id := dataID(w)
+
+		w = send(dev, "POST", "/api/retro-sessions/"+id+"/items", map[string]interface{}{"type": "GOOD", "content": "x"})
+		assert.Equal(t, http.StatusBadRequest, w.Code, "GOOD is not a Mad/Sad/Glad column")
+		w = send(dev, "POST", "/api/retro-sessions/"+id+"/items", map[string]interface{}{"type": "sad", "content": "Overtime", "anonymous": true})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		w = send(owner, "GET", "/api/retro-sessions/"+id, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "Overtime")
+		assert.Contains(t, w.Body.String(), `"Type":"GLAD"`, "columns come from the template")
+		assert.NotContains(t, w.Body.String(), dev.ID, "anonymous authors are hidden")
+	})
+}
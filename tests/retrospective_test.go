@@ -53,4 +53,123 @@ func TestRetrospectiveHandlers(t *testing.T) {
 		assert.Equal(t, 1, len(items))
 		assert.Equal(t, "GOOD", items[0].Type)
 	})
+
+	t.Run("AuthorFromToken", func(t *testing.T) {
+		other := models.User{ID: "u-other", Name: "Other", Email: "other@retro.com"}
+		database.DB.Create(&other)
+		body, _ := json.Marshal(map[string]string{"sprintId": sprint.ID, "type": "bad", "content": "Spoofed", "userId": other.ID})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/retrospectives/", bytes.NewBuffer(body))
+		req.Header.Set("Authorization", authHeader)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var item models.RetrospectiveItem
+		database.DB.First(&item, "content = ?", "Spoofed")
+		assert.Equal(t, user.ID, item.UserID, "the author comes from the token, not the body")
+		assert.Equal(t, "BAD", item.Type)
+	})
+
+	t.Run("TemplateValidation", func(t *testing.T) {
+		post := func(body map[string]interface{}) int {
+			jsonBody, _ := json.Marshal(body)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/api/retrospectives/", bytes.NewBuffer(jsonBody))
+			req.Header.Set("Authorization", authHeader)
+			r.ServeHTTP(w, req)
+			return w.Code
+		}
+		assert.Equal(t, http.StatusBadRequest, post(map[string]interface{}{"sprintId": sprint.ID, "type": "START", "content": "x"}))
+
+		jsonBody, _ := json.Marshal(map[string]string{"template": "start_stop_continue"})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/api/projects/"+project.ID+"/retro-template", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Authorization", authHeader)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/api/projects/"+project.ID+"/retro-template", nil)
+		req.Header.Set("Authorization", authHeader)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"Key":"START_STOP_CONTINUE"`)
+		assert.Contains(t, w.Body.String(), "MAD_SAD_GLAD")
+
+		assert.Equal(t, http.StatusCreated, post(map[string]interface{}{"sprintId": sprint.ID, "type": "START", "content": "Pairing"}))
+		assert.Equal(t, http.StatusCreated, post(map[string]interface{}{"sprintId": sprint.ID, "type": "ACTION", "content": "Fix CI"}))
+		assert.Equal(t, http.StatusBadRequest, post(map[string]interface{}{"sprintId": sprint.ID, "type": "GOOD", "content": "x"}))
+
+		jsonBody, _ = json.Marshal(map[string]string{"template": "UNKNOWN"})
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("PUT", "/api/projects/"+project.ID+"/retro-template", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Authorization", authHeader)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("AnonymousItems", func(t *testing.T) {
+		admin := models.User{ID: "u-admin", Name: "Admin", Email: "admin@retro.com", Role: "ADMIN"}
+		database.DB.Create(&admin)
+
+		jsonBody, _ := json.Marshal(map[string]interface{}{"sprintId": sprint.ID, "type": "STOP", "content": "Secret", "anonymous": true})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/retrospectives/", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Authorization", authHeader)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.NotContains(t, w.Body.String(), user.Email, "not even the author sees it in the response")
+
+		var stored models.RetrospectiveItem
+		database.DB.First(&stored, "content = ?", "Secret")
+		assert.Equal(t, user.ID, stored.UserID, "the author is still recorded")
+
+		authorOf := func(token string) string {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/api/retrospectives/"+sprint.ID, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			r.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusOK, w.Code)
+			var resp struct {
+				Data []models.RetrospectiveItem `json:"data"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			for _, item := range resp.Data {
+				if item.Content == "Secret" {
+					return item.UserID
+				}
+			}
+			return "missing"
+		}
+		assert.Equal(t, "", authorOf(token))
+		assert.Equal(t, user.ID, authorOf(generateTestToken(admin.ID, admin.Email, admin.Role)))
+	})
+
+	t.Run("DeleteOnlyByAuthorOrManager", func(t *testing.T) {
+		dev := models.User{ID: "u-dev", Name: "Dev", Email: "dev@retro.com"}
+		database.DB.Create(&dev)
+		database.DB.Create(&models.ProjectMember{ID: "m-dev", ProjectID: project.ID, UserID: dev.ID, Role: "TEAM_DEVELOPER"})
+		ownItem := models.RetrospectiveItem{ID: "ri-own", SprintID: sprint.ID, Type: "GOOD", Content: "Mine", UserID: dev.ID}
+		ownerItem := models.RetrospectiveItem{ID: "ri-owner", SprintID: sprint.ID, Type: "GOOD", Content: "Owner's", UserID: user.ID}
+		database.DB.Create(&ownItem)
+		database.DB.Create(&ownerItem)
+
+		remove := func(id, token string) int {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("DELETE", "/api/retrospectives/"+id, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			r.ServeHTTP(w, req)
+			return w.Code
+		}
+		devToken := generateTestToken(dev.ID, dev.Email, dev.Role)
+		assert.Equal(t, http.StatusForbidden, remove(ownerItem.ID, devToken))
+		assert.Equal(t, http.StatusOK, remove(ownItem.ID, devToken))
+
+		// The project owner manages the project, so it can delete anyone's item
+		other := models.RetrospectiveItem{ID: "ri-dev", SprintID: sprint.ID, Type: "BAD", Content: "Dev's", UserID: dev.ID}
+		database.DB.Create(&other)
+		assert.Equal(t, http.StatusOK, remove(other.ID, token))
+		assert.Equal(t, http.StatusNotFound, remove(other.ID, token))
+	})
 }
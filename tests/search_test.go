@@ -0,0 +1,238 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"Wrk_Api/internal/database"
+	"Wrk_Api/internal/models"
+	"Wrk_Api/internal/routes"
+	"Wrk_Api/internal/search"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestSearch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	SetupTestDB()
+	r := gin.Default()
+	routes.SetupRoutes(r)
+
+	owner := models.User{ID: "se-owner", Name: "Owner", Email: "owner@se.com", Role: "SCRUM_MASTER"}
+	dev := models.User{ID: "se-dev", Name: "Dev", Email: "dev@se.com", Role: "TEAM_DEVELOPER"}
+	stranger := models.User{ID: "se-str", Name: "Stranger", Email: "str@se.com", Role: "TEAM_DEVELOPER"}
+	admin := models.User{ID: "se-admin", Name: "Admin", Email: "admin@se.com", Role: "ADMIN"}
+	for _, u := range []*models.User{&owner, &dev, &stranger, &admin} {
+		database.DB.Create(u)
+	}
+	project := models.Project{ID: "se-p", Name: "Payments", OwnerID: owner.ID}
+	other := models.Project{ID: "se-q", Name: "Secret payments", OwnerID: stranger.ID}
+	database.DB.Create(&project)
+	database.DB.Create(&other)
+	database.DB.Create(&models.ProjectMember{ID: "se-m", ProjectID: project.ID, UserID: dev.ID, Role: "TEAM_DEVELOPER"})
+	sprint := models.Sprint{ID: "se-s", ProjectID: project.ID, Name: "Sprint", StartDate: time.Now(), EndDate: time.Now()}
+	database.DB.Create(&sprint)
+
+	description := "Retry the <b>invoice</b> webhook when the gateway times out"
+	database.DB.Create(&models.Task{ID: "se-t1", ProjectID: project.ID, Title: "Webhook retries", Description: &description, Status: "TODO"})
+	database.DB.Create(&models.Task{ID: "se-t2", ProjectID: other.ID, Title: "Invoice webhook for the secret project", Status: "TODO"})
+	acceptance := "Given an expired card the invoice is marked as failed"
+	database.DB.Create(&models.UserStory{ID: "se-us", ProjectID: project.ID, Title: "Card payments", Description: "As a customer I pay by card", Acceptance: &acceptance})
+
+	projectChat := models.Chat{ID: "se-chat", ProjectID: &project.ID, Type: "PROJECT"}
+	direct := models.Chat{ID: "se-dm", Type: "DIRECT"}
+	database.DB.Create(&projectChat)
+	database.DB.Create(&direct)
+	database.DB.Create(&models.ChatParticipant{ChatID: direct.ID, UserID: owner.ID})
+	database.DB.Create(&models.ChatParticipant{ChatID: direct.ID, UserID: stranger.ID})
+	database.DB.Create(&models.Message{ID: "se-msg1", ChatID: projectChat.ID, UserID: dev.ID, Content: "Who owns the invoice emails?"})
+	database.DB.Create(&models.Message{ID: "se-msg2", ChatID: direct.ID, UserID: owner.ID, Content: "Private note about the invoice"})
+	database.DB.Create(&models.RetrospectiveItem{ID: "se-retro", SprintID: sprint.ID, Type: "BAD", Content: "Invoice bugs slipped through", UserID: dev.ID})
+
+	parent := "se-doc1"
+	database.DB.Create(&models.Document{ID: "se-doc1", ProjectID: project.ID, Name: "invoice-spec.pdf", Version: 1})
+	database.DB.Create(&models.Document{ID: "se-doc2", ProjectID: project.ID, Name: "invoice-spec.pdf", Version: 2, ParentID: &parent})
+
+	type hit struct {
+		Type      string  `json:"type"`
+		ID        string  `json:"id"`
+		ProjectID *string `json:"projectId"`
+		Snippet   string  `json:"snippet"`
+	}
+	searchAs := func(user models.User, params url.Values) (int, []hit) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/search?"+params.Encode(), nil)
+		req.Header.Set("Authorization", "Bearer "+generateTestToken(user.ID, user.Email, user.Role))
+		r.ServeHTTP(w, req)
+		var resp struct {
+			Data []hit `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Data
+	}
+	ids := func(hits []hit) []string {
+		found := []string{}
+		for _, h := range hits {
+			found = append(found, h.ID)
+		}
+		return found
+	}
+
+	t.Run("RespectsMembership", func(t *testing.T) {
+		code, hits := searchAs(dev, url.Values{"q": {"invoice"}})
+		require.Equal(t, http.StatusOK, code)
+		assert.ElementsMatch(t, []string{"se-t1", "se-us", "se-msg1", "se-retro", "se-doc2"}, ids(hits),
+			"no other projects, no conversations the dev is not in, only the latest document version")
+
+		_, hits = searchAs(owner, url.Values{"q": {"invoice"}})
+		assert.Contains(t, ids(hits), "se-msg2", "participants find their direct messages")
+		assert.NotContains(t, ids(hits), "se-t2")
+
+		_, hits = searchAs(admin, url.Values{"q": {"invoice"}})
+		assert.Contains(t, ids(hits), "se-t2", "admins search everything")
+	})
+
+	t.Run("Snippets", func(t *testing.T) {
+		_, hits := searchAs(dev, url.Values{"q": {"gateway"}})
+		require.Len(t, hits, 1)
+		assert.Equal(t, "se-t1", hits[0].ID)
+		assert.Contains(t, hits[0].Snippet, "<mark>gateway</mark>")
+		assert.Contains(t, hits[0].Snippet, "&lt;b&gt;invoice&lt;/b&gt;", "content is escaped")
+
+		_, hits = searchAs(dev, url.Values{"q": {"expired card"}})
+		require.Len(t, hits, 1, "every term has to match")
+		assert.Equal(t, "se-us", hits[0].ID, "acceptance criteria are indexed")
+
+		_, hits = searchAs(dev, url.Values{"q": {"Payments"}})
+		assert.Contains(t, ids(hits), project.ID)
+		assert.NotContains(t, ids(hits), other.ID)
+	})
+
+	t.Run("Filters", func(t *testing.T) {
+		_, hits := searchAs(dev, url.Values{"q": {"invoice"}, "types": {"message,retro_item"}})
+		assert.ElementsMatch(t, []string{"se-msg1", "se-retro"}, ids(hits))
+
+		_, hits = searchAs(owner, url.Values{"q": {"invoice"}, "projectId": {project.ID}})
+		assert.NotContains(t, ids(hits), "se-msg2", "a project search leaves direct messages out")
+
+		code, _ := searchAs(dev, url.Values{"q": {"invoice"}, "projectId": {other.ID}})
+		assert.Equal(t, http.StatusNotFound, code)
+		code, _ = searchAs(dev, url.Values{"q": {"x"}})
+		assert.Equal(t, http.StatusBadRequest, code)
+		code, _ = searchAs(dev, url.Values{"q": {"invoice"}, "types": {"USERS"}})
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("FollowsChanges", func(t *testing.T) {
+		database.DB.Model(&models.Task{}).Where("id = ?", "se-t1").Update("title", "Reconciliation job")
+		_, hits := searchAs(dev, url.Values{"q": {"reconciliation"}})
+		assert.Equal(t, []string{"se-t1"}, ids(hits))
+
+		database.DB.Delete(&models.Message{}, "id = ?", "se-msg1")
+		_, hits = searchAs(dev, url.Values{"q": {"emails"}})
+		assert.Empty(t, hits)
+	})
+}
+
+// sqlRecorder is a gorm logger keeping every statement, for dry runs.
+type sqlRecorder struct {
+	logger.Interface
+	statements []string
+}
+
+func (r *sqlRecorder) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	sql, _ := fc()
+	r.statements = append(r.statements, sql)
+}
+
+func TestSearchEngines(t *testing.T) {
+	dryRun := func(t *testing.T, dialector gorm.Dialector) (*gorm.DB, *sqlRecorder) {
+		recorder := &sqlRecorder{Interface: logger.Discard}
+		db, err := gorm.Open(dialector, &gorm.Config{DryRun: true, DisableAutomaticPing: true, Logger: recorder})
+		require.NoError(t, err)
+		return db, recorder
+	}
+	query := search.Query{Text: "Invoice web", Types: []string{search.TypeTask}, Scope: search.Scope{All: true}, Limit: 10}
+
+	t.Run("Postgres", func(t *testing.T) {
+		db, recorder := dryRun(t, postgres.Open("host=localhost dbname=wrk"))
+		require.NoError(t, search.Install(db))
+		assert.Contains(t, recorder.statements, "CREATE INDEX search_tasks ON tasks USING GIN (to_tsvector('simple', COALESCE(title, '') || ' ' || COALESCE(description, '')))")
+
+		recorder.statements = nil
+		// Dry runs build the query but can't scan it
+		_, err := search.TSVector{}.Search(db, query)
+		assert.ErrorIs(t, err, gorm.ErrDryRunModeUnsupported)
+		require.NotEmpty(t, recorder.statements)
+		// The query repeats the indexed expression so Postgres can use the index
+		assert.Contains(t, recorder.statements[0], "to_tsvector('simple', COALESCE(tasks.title, '') || ' ' || COALESCE(tasks.description, '')) @@ to_tsquery('simple', 'invoice:* & web:*')")
+		assert.Contains(t, recorder.statements[0], "ORDER BY ts_rank(")
+	})
+
+	t.Run("MySQL", func(t *testing.T) {
+		db, recorder := dryRun(t, mysql.New(mysql.Config{DSN: "wrk:secret@tcp(localhost:3306)/wrk", SkipInitializeWithVersion: true}))
+		_, err := search.Fulltext{}.Search(db, query)
+		assert.ErrorIs(t, err, gorm.ErrDryRunModeUnsupported)
+		require.NotEmpty(t, recorder.statements)
+		// The column list is the one the FULLTEXT index covers
+		assert.Contains(t, recorder.statements[0], "MATCH (tasks.title, tasks.description) AGAINST ('+invoice* +web*' IN BOOLEAN MODE)")
+	})
+}
+
+func TestSearchSetup(t *testing.T) {
+	open := func(t *testing.T, name string) *gorm.DB {
+		db, err := gorm.Open(sqlite.Open("file:"+name+"?mode=memory&cache=shared"), database.GormConfig())
+		require.NoError(t, err)
+		sqlDB, _ := db.DB()
+		t.Cleanup(func() { sqlDB.Close() })
+		return db
+	}
+	fts5 := func(db *gorm.DB) bool {
+		var used int
+		db.Raw("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&used)
+		return used == 1
+	}
+	engine := search.Default
+	t.Cleanup(func() { search.Default = engine })
+
+	t.Run("IndexWithoutFTS5", func(t *testing.T) {
+		db := open(t, "search-setup-nofts")
+		if fts5(db) {
+			t.Skip("this build has FTS5")
+		}
+		// Stands in for an index built by a binary with FTS5
+		require.NoError(t, db.Exec("CREATE TABLE search_index (title TEXT, body TEXT)").Error)
+		assert.ErrorContains(t, search.Setup(db), "sqlite_fts5")
+	})
+
+	t.Run("BuildsMissingIndex", func(t *testing.T) {
+		db := open(t, "search-setup-late")
+		if !fts5(db) {
+			t.Skip("needs -tags sqlite_fts5")
+		}
+		_, err := database.Migrate(db)
+		require.NoError(t, err)
+		// As if the migration had run on a binary without FTS5
+		require.NoError(t, search.Uninstall(db))
+		require.NoError(t, db.Create(&models.Project{ID: "late-p", Name: "Ledger", OwnerID: "u"}).Error)
+
+		require.NoError(t, search.Setup(db))
+		assert.Equal(t, "fts5", search.Default.Name())
+		hits, err := search.Default.Search(db, search.Query{Text: "ledger", Scope: search.Scope{All: true}, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, hits, 1)
+		assert.Equal(t, "late-p", hits[0].ID)
+	})
+}
@@ -4,7 +4,7 @@ import (
 	"log"
 
 	"Wrk_Api/internal/database"
-	"Wrk_Api/internal/models"
+	"Wrk_Api/internal/search"
 
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -13,50 +13,19 @@ import (
 func SetupTestDB() {
 	var err error
 	// Use in-memory SQLite for tests
-	database.DB, err = gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	database.DB, err = gorm.Open(sqlite.Open("file::memory:?cache=shared"), database.GormConfig())
 	if err != nil {
 		log.Fatal("Failed to connect to test database:", err)
 	}
 
-	// Drop tables to ensure clean state
-	database.DB.Migrator().DropTable(
-		&models.User{},
-		&models.Project{},
-		&models.ProjectMember{},
-		&models.Sprint{},
-		&models.UserStory{},
-		&models.Task{},
-		&models.Rubric{},
-		&models.Criteria{},
-		&models.Evaluation{},
-		&models.EvaluationCriteria{},
-		&models.Chat{},
-		&models.ChatParticipant{},
-		&models.Message{},
-		&models.Notification{},
-		&models.RetrospectiveItem{},
-		&models.Document{},
-	)
-
-	err = database.DB.AutoMigrate(
-		&models.User{},
-		&models.Project{},
-		&models.ProjectMember{},
-		&models.Sprint{},
-		&models.UserStory{},
-		&models.Task{},
-		&models.Rubric{},
-		&models.Criteria{},
-		&models.Evaluation{},
-		&models.EvaluationCriteria{},
-		&models.Chat{},
-		&models.ChatParticipant{},
-		&models.Message{},
-		&models.Notification{},
-		&models.RetrospectiveItem{},
-		&models.Document{},
-	)
-	if err != nil {
+	// Revert every migration and apply them again to ensure clean state
+	if _, err = database.Rollback(database.DB, len(database.Migrations), true); err != nil {
+		log.Fatal("Failed to reset test database:", err)
+	}
+	if _, err = database.Migrate(database.DB); err != nil {
 		log.Fatal("Failed to migrate test database:", err)
 	}
+	if err = search.Setup(database.DB); err != nil {
+		log.Fatal("Failed to set up search:", err)
+	}
 }
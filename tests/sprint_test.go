@@ -14,6 +14,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSprintHandlers(t *testing.T) {
@@ -80,4 +81,276 @@ func TestSprintHandlers(t *testing.T) {
 		database.DB.First(&updatedStory, "id = ?", story.ID)
 		assert.Equal(t, createdSprintID, *updatedStory.SprintID)
 	})
+
+	t.Run("DeleteSprint", func(t *testing.T) {
+		database.DB.Create(&models.SprintReport{ID: "rep1", SprintID: createdSprintID, StartedAt: time.Now()})
+		database.DB.Create(&models.RetrospectiveItem{ID: "ri1", SprintID: createdSprintID, Type: "GOOD", Content: "Pairing", UserID: user.ID})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/api/sprints/"+createdSprintID, nil)
+		req.Header.Set("Authorization", authHeader)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		// The story goes back to the backlog; what only made sense in the sprint goes
+		var story models.UserStory
+		require.NoError(t, database.DB.First(&story, "id = ?", "us1").Error)
+		assert.Nil(t, story.SprintID)
+		var entry models.HistoryEntry
+		require.NoError(t, database.DB.Where("entity_id = ? AND field = ?", "us1", "sprintId").Order("created_at desc").First(&entry).Error)
+		assert.Equal(t, createdSprintID, *entry.OldValue)
+		assert.Nil(t, entry.NewValue)
+		var count int64
+		database.DB.Model(&models.SprintReport{}).Where("sprint_id = ?", createdSprintID).Count(&count)
+		assert.Zero(t, count)
+		database.DB.Model(&models.RetrospectiveItem{}).Where("sprint_id = ?", createdSprintID).Count(&count)
+		assert.Zero(t, count)
+	})
+}
+
+func TestSprintLifecycle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	SetupTestDB()
+	r := gin.Default()
+	routes.SetupRoutes(r)
+
+	user := models.User{ID: "u1", Name: "Test", Email: "test@lifecycle.com"}
+	database.DB.Create(&user)
+	project := models.Project{ID: "p1", Name: "Lifecycle Project", OwnerID: user.ID}
+	database.DB.Create(&project)
+
+	now := time.Now()
+	sprint1 := models.Sprint{ID: "s1", ProjectID: project.ID, Name: "Sprint 1", Status: models.SprintPlanning, StartDate: now}
+	sprint2 := models.Sprint{ID: "s2", ProjectID: project.ID, Name: "Sprint 2", Status: models.SprintPlanning, StartDate: now.Add(14 * 24 * time.Hour)}
+	database.DB.Create(&sprint1)
+	database.DB.Create(&sprint2)
+
+	points := func(p int) *int { return &p }
+	done := models.UserStory{ID: "us-done", ProjectID: project.ID, Title: "Done", Description: "d", SprintID: &sprint1.ID, StoryPoints: points(5), Status: "DONE"}
+	open := models.UserStory{ID: "us-open", ProjectID: project.ID, Title: "Open", Description: "d", SprintID: &sprint1.ID, StoryPoints: points(3), Status: "IN_PROGRESS"}
+	database.DB.Create(&done)
+	database.DB.Create(&open)
+	doneTask := models.Task{ID: "t-done", ProjectID: project.ID, Title: "Done", SprintID: &sprint1.ID, Status: "DONE"}
+	openTask := models.Task{ID: "t-open", ProjectID: project.ID, Title: "Open", SprintID: &sprint1.ID, Status: "TODO"}
+	database.DB.Create(&doneTask)
+	database.DB.Create(&openTask)
+
+	authHeader := "Bearer " + generateTestToken(user.ID, user.Email, user.Role)
+	post := func(path string, body interface{}) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		if body != nil {
+			json.NewEncoder(&buf).Encode(body)
+		}
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, &buf)
+		req.Header.Set("Authorization", authHeader)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("StatusCannotBeSetDirectly", func(t *testing.T) {
+		jsonBody, _ := json.Marshal(map[string]string{"status": "ACTIVE"})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/api/sprints/"+sprint1.ID, bytes.NewBuffer(jsonBody))
+		req.Header.Set("Authorization", authHeader)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("StartSprint", func(t *testing.T) {
+		assert.Equal(t, http.StatusConflict, post("/api/sprints/"+sprint1.ID+"/complete", nil).Code)
+		assert.Equal(t, http.StatusOK, post("/api/sprints/"+sprint1.ID+"/start", nil).Code)
+
+		// Only one active sprint per project
+		assert.Equal(t, http.StatusConflict, post("/api/sprints/"+sprint2.ID+"/start", nil).Code)
+		assert.Equal(t, http.StatusConflict, post("/api/sprints/"+sprint1.ID+"/start", nil).Code)
+
+		var sprint models.Sprint
+		database.DB.First(&sprint, "id = ?", sprint1.ID)
+		assert.Equal(t, models.SprintActive, sprint.Status)
+		assert.NotNil(t, sprint.StartedAt)
+	})
+
+	t.Run("CompleteToNextSprint", func(t *testing.T) {
+		w := post("/api/sprints/"+sprint1.ID+"/complete", map[string]string{"carryOver": "NEXT_SPRINT"})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var story models.UserStory
+		database.DB.First(&story, "id = ?", open.ID)
+		assert.Equal(t, sprint2.ID, *story.SprintID)
+		var doneStory models.UserStory
+		database.DB.First(&doneStory, "id = ?", done.ID)
+		assert.Equal(t, sprint1.ID, *doneStory.SprintID)
+		var task models.Task
+		database.DB.First(&task, "id = ?", openTask.ID)
+		assert.Equal(t, sprint2.ID, *task.SprintID)
+
+		var report models.SprintReport
+		database.DB.First(&report, "sprint_id = ?", sprint1.ID)
+		assert.Equal(t, 8, report.CommittedPoints)
+		assert.Equal(t, 5, report.CompletedPoints)
+		assert.Equal(t, 3, report.CarriedOverPoints)
+		assert.Equal(t, 2, report.CommittedTasks)
+		assert.Equal(t, 1, report.CompletedTasks)
+		assert.Equal(t, 1, report.CarriedOverTasks)
+		assert.Equal(t, sprint2.ID, *report.NextSprintID)
+
+		// Carry-over shows up in the story history
+		var entries int64
+		database.DB.Model(&models.HistoryEntry{}).Where("entity_id = ? AND field = ?", open.ID, "sprintId").Count(&entries)
+		assert.Equal(t, int64(1), entries)
+
+		w = httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/sprints/"+sprint1.ID+"/report", nil)
+		req.Header.Set("Authorization", authHeader)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("CompleteToBacklog", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, post("/api/sprints/"+sprint2.ID+"/start", nil).Code)
+		assert.Equal(t, http.StatusBadRequest, post("/api/sprints/"+sprint2.ID+"/complete", map[string]string{"carryOver": "NEXT_SPRINT"}).Code)
+		assert.Equal(t, http.StatusOK, post("/api/sprints/"+sprint2.ID+"/complete", map[string]string{"carryOver": "BACKLOG"}).Code)
+
+		var story models.UserStory
+		database.DB.First(&story, "id = ?", open.ID)
+		assert.Nil(t, story.SprintID)
+
+		// Completed sprints don't take new stories
+		w := post("/api/sprints/"+sprint2.ID+"/add-story", map[string]string{"userStoryId": open.ID})
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("UpdatesGoThroughTheSameChecks", func(t *testing.T) {
+		put := func(path string, body interface{}) int {
+			jsonBody, _ := json.Marshal(body)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("PUT", path, bytes.NewBuffer(jsonBody))
+			req.Header.Set("Authorization", authHeader)
+			r.ServeHTTP(w, req)
+			return w.Code
+		}
+		assert.Equal(t, http.StatusConflict, put("/api/user-stories/"+open.ID, map[string]string{"sprintId": sprint2.ID}))
+		assert.Equal(t, http.StatusConflict, put("/api/tasks/"+openTask.ID, map[string]string{"sprintId": sprint2.ID}))
+
+		other := models.Project{ID: "p2", Name: "Other Project", OwnerID: user.ID}
+		database.DB.Create(&other)
+		foreign := models.Sprint{ID: "s3", ProjectID: other.ID, Name: "Foreign", Status: models.SprintPlanning}
+		database.DB.Create(&foreign)
+		assert.Equal(t, http.StatusBadRequest, post("/api/sprints/"+foreign.ID+"/add-story", map[string]string{"userStoryId": open.ID}).Code)
+		assert.Equal(t, http.StatusBadRequest, put("/api/user-stories/"+open.ID, map[string]string{"sprintId": foreign.ID}))
+		assert.Equal(t, http.StatusBadRequest, put("/api/tasks/"+openTask.ID, map[string]string{"sprintId": foreign.ID}))
+
+		var story models.UserStory
+		database.DB.First(&story, "id = ?", open.ID)
+		assert.Nil(t, story.SprintID)
+	})
+}
+
+func TestSprintScopeChanges(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	SetupTestDB()
+	r := gin.Default()
+	routes.SetupRoutes(r)
+
+	user := models.User{ID: "u1", Name: "Test", Email: "test@scope.com"}
+	database.DB.Create(&user)
+	project := models.Project{ID: "p1", Name: "Scope Project", OwnerID: user.ID}
+	database.DB.Create(&project)
+
+	start := time.Now().Add(-48 * time.Hour)
+	sprint := models.Sprint{ID: "s1", ProjectID: project.ID, Name: "Sprint 1", Status: models.SprintPlanning, StartDate: start, EndDate: start.Add(7 * 24 * time.Hour)}
+	database.DB.Create(&sprint)
+	points := func(p int) *int { return &p }
+	committed := models.UserStory{ID: "us-a", ProjectID: project.ID, Title: "Committed", Description: "d", SprintID: &sprint.ID, StoryPoints: points(5)}
+	added := models.UserStory{ID: "us-b", ProjectID: project.ID, Title: "Added", Description: "d", StoryPoints: points(3)}
+	database.DB.Create(&committed)
+	database.DB.Create(&added)
+
+	authHeader := "Bearer " + generateTestToken(user.ID, user.Email, user.Role)
+	send := func(method, path string, body interface{}) int {
+		var buf bytes.Buffer
+		if body != nil {
+			json.NewEncoder(&buf).Encode(body)
+		}
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, &buf)
+		req.Header.Set("Authorization", authHeader)
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("ChangesBeforeStartAreNotTracked", func(t *testing.T) {
+		assert.Equal(t, http.StatusCreated, send("POST", "/api/sprints/"+sprint.ID+"/add-story", map[string]string{"userStoryId": added.ID}))
+		assert.Equal(t, http.StatusOK, send("POST", "/api/sprints/"+sprint.ID+"/remove-story", map[string]string{"userStoryId": added.ID}))
+
+		var count int64
+		database.DB.Model(&models.SprintScopeChange{}).Count(&count)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("ChangesAfterStart", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, send("POST", "/api/sprints/"+sprint.ID+"/start", nil))
+
+		assert.Equal(t, http.StatusCreated, send("POST", "/api/sprints/"+sprint.ID+"/add-story", map[string]string{"userStoryId": added.ID}))
+		assert.Equal(t, http.StatusOK, send("PUT", "/api/user-stories/"+committed.ID, map[string]interface{}{"storyPoints": 8}))
+		assert.Equal(t, http.StatusOK, send("POST", "/api/sprints/"+sprint.ID+"/remove-story", map[string]string{"userStoryId": added.ID}))
+		assert.Equal(t, http.StatusNotFound, send("POST", "/api/sprints/"+sprint.ID+"/remove-story", map[string]string{"userStoryId": added.ID}))
+
+		var changes []models.SprintScopeChange
+		database.DB.Where("sprint_id = ?", sprint.ID).Order("created_at asc").Find(&changes)
+		require.Len(t, changes, 3)
+		assert.Equal(t, models.ScopeAdded, changes[0].Type)
+		assert.Equal(t, 3, changes[0].Delta)
+		assert.Equal(t, models.ScopeEstimateChanged, changes[1].Type)
+		assert.Equal(t, 3, changes[1].Delta)
+		assert.Equal(t, models.ScopeRemoved, changes[2].Type)
+		assert.Equal(t, -3, changes[2].Delta)
+	})
+
+	t.Run("BurndownShowsScope", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/metrics/sprints/"+sprint.ID+"/burndown", nil)
+		req.Header.Set("Authorization", authHeader)
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp struct {
+			Data struct {
+				TotalPoints  int                      `json:"totalPoints"`
+				InitialScope int                      `json:"initialScope"`
+				ScopeChanges []map[string]interface{} `json:"scopeChanges"`
+				Series       []struct {
+					Scope *int `json:"scope"`
+				} `json:"series"`
+			} `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.Equal(t, 8, resp.Data.TotalPoints)
+		assert.Equal(t, 5, resp.Data.InitialScope)
+		assert.Len(t, resp.Data.ScopeChanges, 3)
+
+		// The scope line starts at the committed scope and ends at the current one
+		require.NotNil(t, resp.Data.Series[0].Scope)
+		assert.Equal(t, 5, *resp.Data.Series[0].Scope)
+		var last *int
+		for _, point := range resp.Data.Series {
+			if point.Scope != nil {
+				last = point.Scope
+			}
+		}
+		require.NotNil(t, last)
+		assert.Equal(t, 8, *last)
+	})
+
+	t.Run("DeletingAStoryShrinksScope", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, send("DELETE", "/api/user-stories/"+committed.ID, nil))
+
+		var changes []models.SprintScopeChange
+		database.DB.Where("sprint_id = ?", sprint.ID).Order("created_at asc").Find(&changes)
+		require.Len(t, changes, 4)
+		assert.Equal(t, models.ScopeRemoved, changes[3].Type)
+		assert.Equal(t, -8, changes[3].Delta)
+		assert.Equal(t, committed.ID, changes[3].UserStoryID)
+	})
 }
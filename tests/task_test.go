@@ -92,11 +92,33 @@ func TestTaskHandlers(t *testing.T) {
 		assert.NotNil(t, task.CompletedAt)
 	})
 
+	t.Run("TaskHistory", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/tasks/"+taskID+"/history", nil)
+		req.Header.Set("Authorization", authHeader)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp map[string][]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+
+		// Creation values first, then the status change with who made it
+		var statuses [][2]interface{}
+		for _, entry := range resp["data"] {
+			if entry["field"] == "status" {
+				statuses = append(statuses, [2]interface{}{entry["oldValue"], entry["newValue"]})
+				assert.Equal(t, user.ID, entry["user"].(map[string]interface{})["id"])
+			}
+		}
+		assert.Equal(t, [][2]interface{}{{nil, "TODO"}, {"TODO", "COMPLETED"}}, statuses)
+	})
+
 	t.Run("EvaluateTask", func(t *testing.T) {
 		body := map[string]interface{}{
 			"score":       85,
+			"maxScore":    100,
 			"feedback":    "Good job",
-			"evaluatorId": user.ID,
+			"evaluatorId": assignee.ID, // ignored: the evaluator is the caller
 		}
 		jsonBody, _ := json.Marshal(body)
 
@@ -111,6 +133,21 @@ func TestTaskHandlers(t *testing.T) {
 		var eval models.Evaluation
 		database.DB.Where("task_id = ?", taskID).First(&eval)
 		assert.Equal(t, 85, *eval.Score)
+		assert.Equal(t, models.EvaluationDraft, eval.Status)
+		assert.Equal(t, user.ID, eval.EvaluatorID)
+
+		// The assignee is only notified once the evaluation is published
+		var count int64
+		database.DB.Model(&models.Notification{}).Where("user_id = ? AND type = ?", assignee.ID, "EVALUATION_COMPLETED").Count(&count)
+		assert.Equal(t, int64(0), count)
+
+		for _, action := range []string{"submit", "publish"} {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/api/evaluations/"+eval.ID+"/"+action, nil)
+			req.Header.Set("Authorization", authHeader)
+			r.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusOK, w.Code)
+		}
 
 		// Check Notification for Assignee
 		var notif models.Notification
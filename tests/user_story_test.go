@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"Wrk_Api/internal/database"
 	"Wrk_Api/internal/models"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestUserStoryHandlers(t *testing.T) {
@@ -83,4 +85,160 @@ func TestUserStoryHandlers(t *testing.T) {
 		database.DB.Where("user_id = ? AND type = ?", assignee.ID, "TASK_ASSIGNED").First(&notif)
 		assert.NotEmpty(t, notif.ID)
 	})
+	t.Run("UserStoryHistory", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/user-stories/"+storyID+"/history", nil)
+		req.Header.Set("Authorization", authHeader)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp map[string][]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+
+		var statusChange map[string]interface{}
+		for _, entry := range resp["data"] {
+			if entry["field"] == "status" && entry["oldValue"] != nil {
+				statusChange = entry
+			}
+		}
+		assert.NotNil(t, statusChange)
+		assert.Equal(t, "BACKLOG", statusChange["oldValue"])
+		assert.Equal(t, "COMPLETED", statusChange["newValue"])
+		assert.Equal(t, user.ID, statusChange["user"].(map[string]interface{})["id"])
+	})
+
+	t.Run("DeleteKeepsTasks", func(t *testing.T) {
+		require.NoError(t, database.DB.Create(&models.Task{ID: "us-t1", Title: "Story task", ProjectID: project.ID, UserStoryID: &storyID}).Error)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/api/user-stories/"+storyID, nil)
+		req.Header.Set("Authorization", authHeader)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var task models.Task
+		require.NoError(t, database.DB.First(&task, "id = ?", "us-t1").Error)
+		assert.Nil(t, task.UserStoryID)
+		var entries int64
+		database.DB.Model(&models.HistoryEntry{}).Where("entity_id = ? AND field = ? AND new_value IS NULL", "us-t1", "userStoryId").Count(&entries)
+		assert.Equal(t, int64(1), entries)
+	})
+}
+
+func TestBacklogRanking(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	SetupTestDB()
+	r := gin.Default()
+	routes.SetupRoutes(r)
+
+	owner := models.User{ID: "rank-owner", Name: "Owner", Email: "owner@rank.com", Role: "PRODUCT_OWNER"}
+	database.DB.Create(&owner)
+	project := models.Project{ID: "rank-p", Name: "Rank Project", OwnerID: owner.ID}
+	database.DB.Create(&project)
+	authHeader := "Bearer " + generateTestToken(owner.ID, owner.Email, owner.Role)
+
+	send := func(method, url string, body interface{}) *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, url, bytes.NewBuffer(jsonBody))
+		req.Header.Set("Authorization", authHeader)
+		r.ServeHTTP(w, req)
+		return w
+	}
+	backlog := func() []string {
+		w := send("GET", "/api/projects/"+project.ID+"/backlog", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp map[string][]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		titles := []string{}
+		for _, story := range resp["data"] {
+			titles = append(titles, story["Title"].(string))
+		}
+		return titles
+	}
+
+	ids := map[string]string{}
+	for _, title := range []string{"A", "B", "C", "D"} {
+		w := send("POST", "/api/user-stories/", map[string]interface{}{"title": title, "projectId": project.ID})
+		require.Equal(t, http.StatusCreated, w.Code)
+		var resp map[string]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		ids[title] = resp["data"]["ID"].(string)
+	}
+
+	t.Run("NewStoriesGoLast", func(t *testing.T) {
+		assert.Equal(t, []string{"A", "B", "C", "D"}, backlog())
+	})
+
+	t.Run("MoveBeforeAndAfter", func(t *testing.T) {
+		w := send("POST", "/api/user-stories/"+ids["D"]+"/move", map[string]string{"beforeId": ids["A"]})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []string{"D", "A", "B", "C"}, backlog())
+
+		w = send("POST", "/api/user-stories/"+ids["A"]+"/move", map[string]string{"afterId": ids["C"]})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []string{"D", "B", "C", "A"}, backlog())
+
+		// Only the moved story gets a new rank
+		var other models.UserStory
+		database.DB.First(&other, "id = ?", ids["B"])
+		var before models.UserStory
+		database.DB.First(&before, "id = ?", ids["C"])
+		assert.Less(t, other.Rank, before.Rank)
+	})
+
+	t.Run("RepeatedMovesIntoSameGap", func(t *testing.T) {
+		// Keep squeezing stories between D and B until a rebalance kicks in
+		for i := 0; i < 40; i++ {
+			mover := ids["A"]
+			if i%2 == 1 {
+				mover = ids["C"]
+			}
+			w := send("POST", "/api/user-stories/"+mover+"/move", map[string]string{"afterId": ids["D"]})
+			require.Equal(t, http.StatusOK, w.Code)
+		}
+		assert.Equal(t, []string{"D", "C", "A", "B"}, backlog())
+	})
+
+	t.Run("RejectsInvalidMoves", func(t *testing.T) {
+		w := send("POST", "/api/user-stories/"+ids["A"]+"/move", map[string]string{})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = send("POST", "/api/user-stories/"+ids["A"]+"/move", map[string]string{"beforeId": ids["A"]})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		other := models.Project{ID: "rank-other", Name: "Other", OwnerID: owner.ID}
+		database.DB.Create(&other)
+		foreign := models.UserStory{ID: "rank-foreign", Title: "Foreign", ProjectID: other.ID, Priority: "LOW", Status: "BACKLOG"}
+		database.DB.Create(&foreign)
+		w = send("POST", "/api/user-stories/"+ids["A"]+"/move", map[string]string{"beforeId": foreign.ID})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("BacklogExcludesScheduledStories", func(t *testing.T) {
+		sprint := models.Sprint{ID: "rank-s", Name: "Sprint", ProjectID: project.ID, Status: "PLANNING", StartDate: time.Now(), EndDate: time.Now().AddDate(0, 0, 14)}
+		database.DB.Create(&sprint)
+		database.DB.Model(&models.UserStory{}).Where("id = ?", ids["C"]).Update("sprint_id", sprint.ID)
+
+		assert.Equal(t, []string{"D", "A", "B"}, backlog())
+	})
+
+	t.Run("LegacyStoriesAreRanked", func(t *testing.T) {
+		legacy := models.UserStory{ID: "rank-legacy", Title: "Legacy", ProjectID: project.ID, Priority: "LOW", Status: "BACKLOG"}
+		database.DB.Create(&legacy)
+
+		assert.Equal(t, []string{"D", "A", "B", "Legacy"}, backlog())
+	})
+
+	t.Run("FilterAllStoriesByProject", func(t *testing.T) {
+		w := send("GET", "/api/user-stories/?projectId="+project.ID, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp map[string][]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		titles := []string{}
+		for _, story := range resp["data"] {
+			titles = append(titles, story["Title"].(string))
+		}
+		assert.Equal(t, []string{"D", "C", "A", "B", "Legacy"}, titles)
+	})
 }
@@ -0,0 +1,195 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"Wrk_Api/internal/database"
+	"Wrk_Api/internal/models"
+	"Wrk_Api/internal/routes"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorklogs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	SetupTestDB()
+	r := gin.Default()
+	routes.SetupRoutes(r)
+
+	owner := models.User{ID: "wl-owner", Name: "Owner", Email: "owner@wl.com", Role: "SCRUM_MASTER"}
+	dev := models.User{ID: "wl-dev", Name: "Dev", Email: "dev@wl.com", Role: "TEAM_DEVELOPER"}
+	other := models.User{ID: "wl-other", Name: "Other", Email: "other@wl.com", Role: "TEAM_DEVELOPER"}
+	database.DB.Create(&owner)
+	database.DB.Create(&dev)
+	database.DB.Create(&other)
+	project := models.Project{ID: "wl-p", Name: "Worklog Project", OwnerID: owner.ID}
+	database.DB.Create(&project)
+	database.DB.Create(&models.ProjectMember{ID: "wl-m1", ProjectID: project.ID, UserID: dev.ID, Role: "TEAM_DEVELOPER"})
+	database.DB.Create(&models.ProjectMember{ID: "wl-m2", ProjectID: project.ID, UserID: other.ID, Role: "TEAM_DEVELOPER"})
+	sprint := models.Sprint{ID: "wl-s", ProjectID: project.ID, Name: "Sprint 1", Status: "ACTIVE", StartDate: time.Now(), EndDate: time.Now().AddDate(0, 0, 14)}
+	database.DB.Create(&sprint)
+
+	tokens := map[string]string{
+		owner.ID: "Bearer " + generateTestToken(owner.ID, owner.Email, owner.Role),
+		dev.ID:   "Bearer " + generateTestToken(dev.ID, dev.Email, dev.Role),
+		other.ID: "Bearer " + generateTestToken(other.ID, other.Email, other.Role),
+	}
+	send := func(userID, method, url string, body interface{}) *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, url, bytes.NewBuffer(jsonBody))
+		req.Header.Set("Authorization", tokens[userID])
+		r.ServeHTTP(w, req)
+		return w
+	}
+	remaining := func(taskID string) *int {
+		var task models.Task
+		database.DB.First(&task, "id = ?", taskID)
+		return task.RemainingEstimate
+	}
+
+	var taskID, worklogID string
+
+	t.Run("CreateTaskWithEstimate", func(t *testing.T) {
+		w := send(owner.ID, "POST", "/api/tasks/", map[string]interface{}{
+			"title": "Login form", "projectId": project.ID, "sprintId": sprint.ID, "originalEstimate": 240,
+		})
+		require.Equal(t, http.StatusCreated, w.Code)
+		var resp map[string]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		taskID = resp["data"]["ID"].(string)
+		assert.Equal(t, float64(240), resp["data"]["RemainingEstimate"])
+
+		w = send(owner.ID, "POST", "/api/tasks/", map[string]interface{}{"title": "Bad", "projectId": project.ID, "originalEstimate": -5})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("LogWorkBurnsEstimate", func(t *testing.T) {
+		w := send(dev.ID, "POST", "/api/tasks/"+taskID+"/worklogs", map[string]interface{}{"minutes": 90, "date": "2026-03-03", "note": "Layout"})
+		require.Equal(t, http.StatusCreated, w.Code)
+		var resp map[string]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		worklogID = resp["data"]["ID"].(string)
+		assert.Equal(t, sprint.ID, resp["data"]["SprintID"])
+		assert.Equal(t, 150, *remaining(taskID))
+
+		// The decrease shows up in the task history
+		var entry models.HistoryEntry
+		require.NoError(t, database.DB.Where("entity_id = ? AND field = ? AND new_value = ?", taskID, "remainingEstimate", "150").First(&entry).Error)
+		assert.Equal(t, "240", *entry.OldValue)
+		assert.Equal(t, dev.ID, *entry.UserID)
+
+		// An explicit remaining estimate replaces the automatic decrease
+		w = send(other.ID, "POST", "/api/tasks/"+taskID+"/worklogs", map[string]interface{}{"minutes": 30, "remainingEstimate": 60})
+		require.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, 60, *remaining(taskID))
+
+		w = send(dev.ID, "POST", "/api/tasks/"+taskID+"/worklogs", map[string]interface{}{"minutes": 0})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = send(dev.ID, "GET", "/api/tasks/"+taskID+"/worklogs", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var list map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &list)
+		assert.Equal(t, float64(120), list["totalMinutes"])
+	})
+
+	t.Run("OnlyAuthorOrManagerEdits", func(t *testing.T) {
+		w := send(other.ID, "PUT", "/api/worklogs/"+worklogID, map[string]interface{}{"minutes": 10})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = send(dev.ID, "PUT", "/api/worklogs/"+worklogID, map[string]interface{}{"minutes": 100})
+		require.Equal(t, http.StatusOK, w.Code)
+		// The ten extra minutes come off the remaining estimate too
+		assert.Equal(t, 50, *remaining(taskID))
+
+		w = send(owner.ID, "PUT", "/api/worklogs/"+worklogID, map[string]interface{}{"note": "Layout and styles"})
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Timer", func(t *testing.T) {
+		w := send(dev.ID, "POST", "/api/tasks/"+taskID+"/timer/start", nil)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		w = send(dev.ID, "POST", "/api/tasks/"+taskID+"/timer/start", nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		w = send(dev.ID, "GET", "/api/worklogs/timer", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var running map[string]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &running)
+		assert.Equal(t, taskID, running["data"]["TaskID"])
+
+		// Pretend the timer has been running for 25 minutes
+		database.DB.Model(&models.Worklog{}).Where("user_id = ? AND started_at IS NOT NULL AND ended_at IS NULL", dev.ID).
+			UpdateColumn("started_at", time.Now().Add(-25*time.Minute))
+
+		w = send(other.ID, "POST", "/api/tasks/"+taskID+"/timer/stop", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = send(dev.ID, "POST", "/api/tasks/"+taskID+"/timer/stop", map[string]string{"note": "Validation"})
+		require.Equal(t, http.StatusOK, w.Code)
+		var stopped map[string]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &stopped)
+		assert.InDelta(t, 25, stopped["data"]["Minutes"], 1)
+		assert.InDelta(t, 25, *remaining(taskID), 1)
+	})
+
+	t.Run("EffortReports", func(t *testing.T) {
+		w := send(owner.ID, "GET", "/api/metrics/sprints/"+sprint.ID+"/effort", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp struct {
+			Data []struct {
+				User    map[string]interface{}   `json:"user"`
+				Minutes int                      `json:"minutes"`
+				Tasks   []map[string]interface{} `json:"tasks"`
+			} `json:"data"`
+			TotalMinutes     int `json:"totalMinutes"`
+			OriginalEstimate int `json:"originalEstimate"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		require.Len(t, resp.Data, 2)
+		assert.Equal(t, dev.ID, resp.Data[0].User["ID"])
+		assert.InDelta(t, 125, resp.Data[0].Minutes, 1)
+		assert.Equal(t, 30, resp.Data[1].Minutes)
+		assert.Equal(t, 240, resp.OriginalEstimate)
+
+		w = send(owner.ID, "GET", "/api/metrics/projects/"+project.ID+"/effort?from=2026-03-01&to=2026-03-05", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var ranged struct {
+			TotalMinutes int                      `json:"totalMinutes"`
+			Sprints      []map[string]interface{} `json:"sprints"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &ranged)
+		assert.Equal(t, 100, ranged.TotalMinutes)
+		require.Len(t, ranged.Sprints, 1)
+		assert.Equal(t, "Sprint 1", ranged.Sprints[0]["name"])
+
+		w = send(owner.ID, "GET", "/api/metrics/projects/"+project.ID+"/effort?from=March", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = send(owner.ID, "GET", "/api/metrics/projects/"+project.ID+"/contribution", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var contrib map[string][]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &contrib)
+		assert.Len(t, contrib["data"], 2)
+	})
+
+	t.Run("DeleteWorklog", func(t *testing.T) {
+		w := send(other.ID, "DELETE", "/api/worklogs/"+worklogID, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		before := *remaining(taskID)
+		w = send(dev.ID, "DELETE", "/api/worklogs/"+worklogID, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		// Deleted minutes go back to the remaining estimate
+		assert.Equal(t, before+100, *remaining(taskID))
+	})
+}